@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/replication"
 	"github.com/used255/clipboard_archive/v3/route"
 )
 
@@ -17,9 +20,40 @@ func Start() {
 	bindFlagPtr := flag.String("bind", ":8080", "bind address")
 	versionFlagPtr := flag.Bool("v", false, "show version")
 	disableGinModeFlagPtr := flag.Bool("disable-gin-debug-mode", false, "gin.ReleaseMode")
+	trashRetentionFlagPtr := flag.Duration("trash-retention", 30*24*time.Hour, "purge ClipboardItems from trash after this long, 0 keeps them, workspaces may have their own")
+	bulkConfirmThresholdFlagPtr := flag.Int64("bulk-confirm-threshold", route.BulkConfirmThreshold, "bulk operations touching more ClipboardItems need confirmation, 0 disables it")
+	devicePushTTLFlagPtr := flag.Duration("device-push-ttl", route.DevicePushTTL, "how long a ClipboardItem pushed to a device waits to be pulled, and the longest a push may ask for")
+	syncPeerFlagPtr := flag.String("sync-peer", "", "comma separated peer URLs to sync with in the background")
+	syncIntervalFlagPtr := flag.Duration("sync-interval", 5*time.Minute, "how often to sync with -sync-peer")
+	syncUserFlagPtr := flag.String("sync-user", "", "local user whose ClipboardItems are synced with -sync-peer")
+	syncTokenFlagPtr := flag.String("sync-token", "", "API token to sync with -sync-peer as")
+	syncWorkspaceFlagPtr := flag.String("sync-workspace", "", "workspace synced with the workspace of the same name on -sync-peer")
+	encryptionKeyFileFlagPtr := flag.String("encryption-key-file", "", "file with the base64 encoded key ClipboardItems are encrypted with, or set CLIPBOARD_ARCHIVE_ENCRYPTION_KEY")
+	encryptionOldKeyFilesFlagPtr := flag.String("encryption-old-key-files", "", "comma separated files with keys ClipboardItems were encrypted with before, or set CLIPBOARD_ARCHIVE_ENCRYPTION_OLD_KEYS")
+	encryptionIndexTextFlagPtr := flag.Bool("encryption-index-text", false, "leave ClipboardItemText unencrypted so search can index it")
+	rateLimitFlagPtr := flag.Float64("rate-limit", 20, "requests per second each user, or each client IP without users, may make on average, 0 turns rate limiting off")
+	rateLimitBurstFlagPtr := flag.Int64("rate-limit-burst", route.RateLimitBurst, "requests each user or client IP may make at once")
+	maxBodySizeFlagPtr := flag.Int64("max-body-size", route.MaxBodySize, "largest request body in bytes, 0 lifts the limit")
+	bodyLimitsFlagPtr := flag.String("body-limits", "", `comma separated body limits of single routes overriding -max-body-size, such as "POST /ClipboardItem=67108864"`)
+	auditLogFlagPtr := flag.Bool("audit-log", true, "record every API call in the audit log")
+	auditRetentionFlagPtr := flag.Duration("audit-retention", 90*24*time.Hour, "delete audit log entries after this long, 0 keeps them")
+	metricsBindFlagPtr := flag.String("metrics-bind", "", "serve /metrics on this address instead of -bind")
+	metricsTokenFlagPtr := flag.String("metrics-token", "", "bearer token /metrics asks for on -bind, which serves it only with one; none if empty")
+	detectionRulesFileFlagPtr := flag.String("detection-rules-file", "", "JSON file with the sensitive content detection rules replacing the built-in ones, [] turns detection off")
 
 	flag.Parse()
 
+	setupEncryption(*encryptionKeyFileFlagPtr, *encryptionOldKeyFilesFlagPtr, *encryptionIndexTextFlagPtr)
+
+	switch flag.Arg(0) {
+	case "key":
+		keyAndExit(flag.Args()[1:])
+	case "sync":
+		syncAndExit(flag.Args()[1:])
+	case "user":
+		userAndExit(flag.Args()[1:])
+	}
+
 	if *versionFlagPtr {
 		fmt.Println(database.Version)
 		os.Exit(0)
@@ -29,8 +63,43 @@ func Start() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	route.BulkConfirmThreshold = *bulkConfirmThresholdFlagPtr
+	route.DevicePushTTL = *devicePushTTLFlagPtr
+	route.RateLimit = *rateLimitFlagPtr
+	route.RateLimitBurst = *rateLimitBurstFlagPtr
+	route.MaxBodySize = *maxBodySizeFlagPtr
+	setupBodyLimits(*bodyLimitsFlagPtr)
+	route.AuditLog = *auditLogFlagPtr
+	route.ServeMetrics = *metricsBindFlagPtr == "" && *metricsTokenFlagPtr != ""
+	route.MetricsToken = *metricsTokenFlagPtr
+	if *metricsBindFlagPtr == "" && *metricsTokenFlagPtr == "" {
+		log.Println("Not serving /metrics, set -metrics-token or -metrics-bind")
+	}
+	setupDetection(*detectionRulesFileFlagPtr)
+
 	log.Println("Welcome 🐱‍🏍")
 	database.Open("clipboard_archive.db")
+	go reencryptInBackground()
+	go purgeTrashPeriodically(*trashRetentionFlagPtr)
+	go purgeExpiredPeriodically()
+	go purgeAuditLogPeriodically(*auditRetentionFlagPtr)
+	go deliverWebhooksPeriodically()
+	if *syncPeerFlagPtr != "" && *syncIntervalFlagPtr > 0 {
+		peers := []replication.Peer{}
+		for _, peer := range strings.Split(*syncPeerFlagPtr, ",") {
+			peers = append(peers, replication.Peer{URL: peer, Token: *syncTokenFlagPtr, Workspace: *syncWorkspaceFlagPtr})
+		}
+		owner := findOwner(*syncUserFlagPtr)
+		go syncPeriodically(owner, findWorkspace(owner, *syncWorkspaceFlagPtr), peers, *syncIntervalFlagPtr)
+	}
+	if *metricsBindFlagPtr != "" {
+		go func() {
+			err := route.SetupMetricsRouter().Run(*metricsBindFlagPtr)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 	go func() {
 		err = route.SetupRouter().Run(*bindFlagPtr)
 		if err != nil {
@@ -39,3 +108,118 @@ func Start() {
 	}()
 	awaitSignalAndExit()
 }
+
+// syncAndExit runs the sync subcommand, clipboard_archive sync [-pull|-push]
+// [-user name] [-token token] [-workspace name] <peer>.
+func syncAndExit(args []string) {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	pullFlagPtr := flags.Bool("pull", false, "only pull changes from the peer")
+	pushFlagPtr := flags.Bool("push", false, "only push changes to the peer")
+	userFlagPtr := flags.String("user", "", "local user whose ClipboardItems are synced")
+	tokenFlagPtr := flags.String("token", "", "API token to sync with the peer as")
+	workspaceFlagPtr := flags.String("workspace", "", "workspace synced with the workspace of the same name on the peer")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: clipboard_archive sync [-pull|-push] [-user name] [-token token] [-workspace name] <peer>")
+		os.Exit(2)
+	}
+	peer := replication.Peer{URL: flags.Arg(0), Token: *tokenFlagPtr, Workspace: *workspaceFlagPtr}
+
+	database.Open("clipboard_archive.db")
+	owner := findOwner(*userFlagPtr)
+	workspace := findWorkspace(owner, *workspaceFlagPtr)
+	var pulled, pushed int
+	switch {
+	case *pullFlagPtr && !*pushFlagPtr:
+		pulled, err = replication.Pull(database.Orm, owner, workspace, peer)
+	case *pushFlagPtr && !*pullFlagPtr:
+		pushed, err = replication.Push(database.Orm, owner, workspace, peer)
+	default:
+		pulled, pushed, err = replication.Sync(database.Orm, owner, workspace, peer)
+	}
+	database.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Pulled %d and pushed %d changes", pulled, pushed)
+	os.Exit(0)
+}
+
+// keyAndExit runs the key subcommand, clipboard_archive key generate, which
+// prints a new encryption key.
+func keyAndExit(args []string) {
+	if len(args) != 1 || args[0] != "generate" {
+		fmt.Fprintln(os.Stderr, "usage: clipboard_archive key generate")
+		os.Exit(2)
+	}
+	key, err := database.GenerateEncryptionKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(key)
+	os.Exit(0)
+}
+
+// userAndExit runs the user subcommands,
+// clipboard_archive user add [-adopt] [-admin] <name>, user disable <name>,
+// user enable <name>, user admin [-revoke] <name> and user list.
+func userAndExit(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: clipboard_archive user add [-adopt] [-admin] <name> | disable <name> | enable <name> | admin [-revoke] <name> | list")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ExitOnError)
+	adoptFlagPtr := flags.Bool("adopt", false, "give the user the ClipboardItems stored before accounts existed")
+	adminFlagPtr := flags.Bool("admin", false, "let the user edit server wide settings such as ingest rules")
+	revokeFlagPtr := flags.Bool("revoke", false, "take admin away from the user")
+	_ = flags.Parse(args[1:])
+
+	database.Open("clipboard_archive.db")
+	switch {
+	case args[0] == "add" && flags.NArg() == 1:
+		var user database.User
+		var token string
+		user, token, err = database.CreateUser(flags.Arg(0))
+		if err == nil && *adoptFlagPtr {
+			err = database.AdoptOwnerless(user.Index)
+		}
+		if err == nil && *adminFlagPtr {
+			err = database.SetUserAdmin(user.UserName, true)
+		}
+		if err == nil {
+			fmt.Println(token)
+		}
+	case args[0] == "disable" && flags.NArg() == 1:
+		err = database.SetUserDisabled(flags.Arg(0), true)
+	case args[0] == "enable" && flags.NArg() == 1:
+		err = database.SetUserDisabled(flags.Arg(0), false)
+	case args[0] == "admin" && flags.NArg() == 1:
+		err = database.SetUserAdmin(flags.Arg(0), !*revokeFlagPtr)
+	case args[0] == "list" && flags.NArg() == 0:
+		users := []database.User{}
+		err = database.Orm.Order("`index`").Find(&users).Error
+		for _, user := range users {
+			state := "enabled"
+			if user.UserDisabled {
+				state = "disabled"
+			}
+			if user.UserAdmin {
+				state += ", admin"
+			}
+			fmt.Printf("%s\t%s\n", user.UserName, state)
+		}
+	default:
+		database.Close()
+		usage()
+	}
+	database.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(0)
+}
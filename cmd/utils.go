@@ -1,16 +1,230 @@
 package cmd
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
+
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/replication"
+	"github.com/used255/clipboard_archive/v3/route"
+	"github.com/used255/clipboard_archive/v3/utils"
 )
 
 func awaitSignalAndExit() {
 	s := make(chan os.Signal, 1)
 	signal.Notify(s, syscall.SIGINT)
 	<-s
+	route.FlushAuditLog()
 	log.Println("Bey 🐱‍👤")
 	os.Exit(0)
 }
+
+func purgeTrashPeriodically(retention time.Duration) {
+	for {
+		count, err := database.PurgeTrash(utils.GetUnixMillisTimestamp(), retention)
+		route.ObserveSweep("trash", count, err)
+		if err != nil {
+			log.Println("Error purging trash: ", err)
+		} else if count > 0 {
+			log.Printf("Purged %d ClipboardItems from trash", count)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func purgeAuditLogPeriodically(retention time.Duration) {
+	for {
+		count, err := database.PurgeAuditLog(utils.GetUnixMillisTimestamp(), retention)
+		route.ObserveSweep("audit_log", count, err)
+		if err != nil {
+			log.Println("Error purging audit log: ", err)
+		} else if count > 0 {
+			log.Printf("Purged %d audit log entries", count)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// purgeExpiredPeriodically deletes expired ClipboardItems, often enough
+// for expiry measured in minutes.
+func purgeExpiredPeriodically() {
+	for {
+		count, err := database.PurgeExpired(utils.GetUnixMillisTimestamp())
+		route.ObserveSweep("expired", count, err)
+		if err != nil {
+			log.Println("Error purging expired ClipboardItems: ", err)
+		} else if count > 0 {
+			log.Printf("Purged %d expired ClipboardItems", count)
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+// setupDetection reads the detection rules from a JSON file, keeping the
+// built-in rules when no file is given, and exits if they cannot be used.
+func setupDetection(rulesFile string) {
+	if rulesFile == "" {
+		return
+	}
+	b, err := os.ReadFile(rulesFile)
+	if err != nil {
+		log.Fatalf("Error reading detection rules: %s", err)
+	}
+	rules := []route.DetectionRule{}
+	err = json.Unmarshal(b, &rules)
+	if err != nil {
+		log.Fatalf("Error parsing detection rules: %s", err)
+	}
+	err = route.SetDetectionRules(rules)
+	if err != nil {
+		log.Fatalf("Error setting detection rules: %s", err)
+	}
+}
+
+// setupBodyLimits sets the body limits of single routes, given as
+// "METHOD /route=bytes" separated by commas, and exits if they are malformed.
+func setupBodyLimits(limits string) {
+	if limits == "" {
+		return
+	}
+	for _, limit := range strings.Split(limits, ",") {
+		i := strings.LastIndex(limit, "=")
+		if i < 0 {
+			log.Fatalf("Error parsing body limit %q: missing =", limit)
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(limit[i+1:]), 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing body limit %q: %s", limit, err)
+		}
+		route.BodyLimits[strings.Join(strings.Fields(limit[:i]), " ")] = size
+	}
+}
+
+// setupEncryption reads the encryption keys from their files, or from the
+// environment when no file is given, and exits if they cannot be read.
+func setupEncryption(keyFile string, oldKeyFiles string, indexText bool) {
+	readKey := func(file string) []byte {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading encryption key: %s", err)
+		}
+		return parseKey(string(b))
+	}
+
+	var key []byte
+	if keyFile != "" {
+		key = readKey(keyFile)
+	} else if s := os.Getenv("CLIPBOARD_ARCHIVE_ENCRYPTION_KEY"); s != "" {
+		key = parseKey(s)
+	}
+	oldKeys := [][]byte{}
+	if oldKeyFiles != "" {
+		for _, file := range strings.Split(oldKeyFiles, ",") {
+			oldKeys = append(oldKeys, readKey(file))
+		}
+	} else if s := os.Getenv("CLIPBOARD_ARCHIVE_ENCRYPTION_OLD_KEYS"); s != "" {
+		for _, k := range strings.Split(s, ",") {
+			oldKeys = append(oldKeys, parseKey(k))
+		}
+	}
+	if key == nil && len(oldKeys) > 0 {
+		log.Fatal("Old encryption keys need a current one")
+	}
+
+	err := database.SetEncryptionKeys(key, oldKeys, indexText)
+	if err != nil {
+		log.Fatalf("Error setting encryption keys: %s", err)
+	}
+}
+
+func parseKey(s string) []byte {
+	key, err := database.ParseEncryptionKey(s)
+	if err != nil {
+		log.Fatalf("Error parsing encryption key: %s", err)
+	}
+	return key
+}
+
+// reencryptInBackground brings everything stored in plaintext or under an
+// old key onto the current key, a batch at a time so writes get their turn.
+func reencryptInBackground() {
+	total := 0
+	for {
+		count, err := database.ReencryptClipboardItems(100)
+		if err != nil {
+			log.Println("Error re-encrypting ClipboardItems: ", err)
+			return
+		}
+		if count == 0 {
+			break
+		}
+		total += count
+		time.Sleep(100 * time.Millisecond)
+	}
+	if total > 0 {
+		log.Printf("Re-encrypted %d ClipboardItems and revisions", total)
+	}
+}
+
+// deliverWebhooksPeriodically works through the webhook outbox, right
+// away when deliveries are queued and otherwise for the retries due.
+func deliverWebhooksPeriodically() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		err := route.DeliverWebhooks()
+		if err != nil {
+			log.Println("Error delivering webhooks: ", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-route.WebhookDeliveriesQueued:
+		}
+	}
+}
+
+// findOwner is the Index of the User named name, 0 for no name.
+func findOwner(name string) int64 {
+	if name == "" {
+		return 0
+	}
+	user, err := database.FindUser(name)
+	if err != nil {
+		log.Fatalf("Error finding user %s: %s", name, err)
+	}
+	return user.Index
+}
+
+// findWorkspace is the Index of the Workspace of owner named name, 0 for no
+// name.
+func findWorkspace(owner int64, name string) int64 {
+	if name == "" {
+		return 0
+	}
+	workspace, err := database.FindWorkspace(owner, name)
+	if err != nil {
+		log.Fatalf("Error finding workspace %s: %s", name, err)
+	}
+	return workspace.Index
+}
+
+// syncPeriodically pulls from and pushes to every peer, one after another.
+func syncPeriodically(owner int64, workspace int64, peers []replication.Peer, interval time.Duration) {
+	for {
+		for _, peer := range peers {
+			pulled, pushed, err := replication.Sync(database.Orm, owner, workspace, peer)
+			if err != nil {
+				log.Printf("Error syncing with %s: %s", peer.URL, err)
+			} else if pulled > 0 || pushed > 0 {
+				log.Printf("Synced with %s, pulled %d and pushed %d changes", peer.URL, pulled, pushed)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,23 @@
+// https://copyq.readthedocs.io/en/latest/scripting-api.html
+// Puts ClipboardItems pushed to this device on the clipboard, run it with
+// `copyq eval` or as an automatic command.
+copyq:
+
+var url = "https://127.0.0.1:8080/api/v1/devices/";
+var device = "";
+var token = ""; // API token, printed by clipboard_archive user add
+
+function main() {
+    while (true) {
+        reply = networkGet(url + device + "/pull?wait=30s&access_token=" + token);
+        if (reply.status != 200) {
+            sleep(1000);
+            continue;
+        }
+        Response = JSON.parse(str(reply.data));
+        copy(unpack(fromBase64(Response.ClipboardItem.ClipboardItemData)));
+        networkPost(url + device + "/push/" + Response.DevicePush.Index + "/ack?access_token=" + token, "");
+    }
+}
+
+main()
@@ -2,7 +2,9 @@
 copyq:
 
 var minBytes = 250 * 1000;
-var url = "https://127.0.0.1:8080/api/v1/ClipboardItem";
+var url = "https://127.0.0.1:8080/api/v1/ClipboardItem"; // .../api/v1/workspaces/<name>/ClipboardItem to archive into a workspace
+var device = "";
+var token = ""; // API token, printed by clipboard_archive user add
 
 function hasBigData() {
     var itemSize = 0;
@@ -20,11 +22,15 @@ function clipboardItem(Item) {
     ClipboardItemHash = sha256sum(ClipboardItemData);
     ClipboardItemTime = parseInt(str(Item["application/x-copyq-user-copy-time"]));
     ClipboardItemText = str(Item[mimeText]);
+    ClipboardItemWindowTitle = str(Item["application/x-copyq-owner-window-title"]);
     ClipboardItemObject = {
         "ClipboardItemTime": ClipboardItemTime,
         "ClipboardItemText": ClipboardItemText,
         "ClipboardItemHash": ClipboardItemHash,
-        "ClipboardItemData": ClipboardItemData
+        "ClipboardItemData": ClipboardItemData,
+        "ClipboardItemDevice": device,
+        "ClipboardItemWindowTitle": ClipboardItemWindowTitle,
+        "ClipboardItemMimeTypes": Object.keys(Item)
     };
     return JSON.stringify(ClipboardItemObject);
 }
@@ -34,7 +40,7 @@ function main() {
         return;
     }
     ClipboardItem = clipboardItem(getItem(0));
-    networkPost(url, ClipboardItem).data;
+    networkPost(token ? url + "?access_token=" + token : url, ClipboardItem).data;
 }
 
 main()
@@ -0,0 +1,13 @@
+package database
+
+import "time"
+
+// PurgeAuditLog deletes the AuditEntries older than retention at the unix
+// milliseconds timestamp now, 0 keeps them.
+func PurgeAuditLog(now int64, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	tx := Orm.Where("audit_entry_time <= ?", now-retention.Milliseconds()).Delete(&AuditEntry{})
+	return tx.RowsAffected, tx.Error
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeAuditLog(t *testing.T) {
+	var times []int64
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&AuditEntry{AuditEntryTime: 1000})
+	Orm.Create(&AuditEntry{AuditEntryTime: 5000})
+
+	purged, err := PurgeAuditLog(6000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = PurgeAuditLog(6000, 2*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	Orm.Model(&AuditEntry{}).Pluck("audit_entry_time", &times)
+	assert.Equal(t, []int64{5000}, times)
+
+	Close()
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	Open("file::memory:?cache=shared")
+
+	entry := AuditEntry{AuditEntryTime: 1000, AuditEntryStatus: 200}
+	assert.NoError(t, Orm.Create(&entry).Error)
+	assert.Error(t, Orm.Model(&entry).Update("audit_entry_status", 404).Error)
+
+	Close()
+}
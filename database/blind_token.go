@@ -0,0 +1,42 @@
+package database
+
+import "gorm.io/gorm"
+
+// SetBlindTokens replaces the blind tokens of the ClipboardItem at
+// itemIndex.
+func SetBlindTokens(tx *gorm.DB, itemIndex int64, tokens []string) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	err := tx.Where("clipboard_item_index = ?", itemIndex).Delete(&ClipboardItemBlindToken{}).Error
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+
+	seen := map[string]bool{}
+	rows := []ClipboardItemBlindToken{}
+	for _, token := range tokens {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		rows = append(rows, ClipboardItemBlindToken{ClipboardItemIndex: itemIndex, BlindToken: token})
+	}
+	return tx.Create(&rows).Error
+}
+
+// BlindTokens looks up the blind tokens of the ClipboardItems at indexes.
+func BlindTokens(tx *gorm.DB, indexes []int64) (map[int64][]string, error) {
+	tokens := map[int64][]string{}
+	if len(indexes) == 0 {
+		return tokens, nil
+	}
+
+	rows := []ClipboardItemBlindToken{}
+	err := tx.Where("clipboard_item_index IN ?", indexes).Order("clipboard_item_index, blind_token").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tokens[row.ClipboardItemIndex] = append(tokens[row.ClipboardItemIndex], row.BlindToken)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlindTokens(t *testing.T) {
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	item := ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"}
+	assert.NoError(t, Orm.Create(&item).Error)
+	other := ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a", ClipboardItemWorkspace: 1}
+	assert.NoError(t, Orm.Create(&other).Error)
+	assert.NoError(t, SetBlindTokens(Orm, item.Index, []string{"b", "a", "b"}))
+
+	tokens, err := BlindTokens(Orm, []int64{item.Index, other.Index})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]string{item.Index: {"a", "b"}}, tokens)
+
+	assert.NoError(t, SetBlindTokens(Orm, item.Index, []string{"c"}))
+	tokens, _ = BlindTokens(Orm, []int64{item.Index})
+	assert.Equal(t, []string{"c"}, tokens[item.Index])
+
+	Orm.Delete(&item)
+	Orm.Model(&ClipboardItemBlindToken{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	Close()
+}
+
+func TestEndToEndWorkspaceNotIndexed(t *testing.T) {
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	workspace := Workspace{WorkspaceName: "secret", WorkspaceEndToEnd: true}
+	Orm.Create(&workspace)
+	item := ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "ciphertext", ClipboardItemHash: "a", ClipboardItemWorkspace: workspace.Index}
+	Orm.Create(&item)
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemText: "ciphertext", ClipboardItemHash: "a"})
+
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "ciphertext").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, Orm.Delete(&item).Error)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "ciphertext").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	Close()
+}
@@ -6,7 +6,9 @@ import (
 	"gorm.io/gorm"
 )
 
-const Version = version
+// Version is the version of Clipboard Archive, the database schema is
+// versioned on its own by schemaVersion.
+const Version = "3.0.0"
 
 var Orm *gorm.DB
 var err error
@@ -14,6 +16,12 @@ var err error
 func Open(dns string) {
 	connectDatabase(dns)
 	migrateVersion()
+	if encryption != nil {
+		err = setupHashKey(Orm, encryption)
+		if err != nil {
+			log.Fatalf("Failed to set up the hash key: %s", err)
+		}
+	}
 }
 
 func Close() {
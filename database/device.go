@@ -0,0 +1,26 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TouchDevice records that a device of owner was seen at the unix
+// milliseconds timestamp, an empty name is ignored.
+func TouchDevice(tx *gorm.DB, owner int64, name string, seen int64) error {
+	if name == "" {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "device_owner"}, {Name: "device_name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"device_first_seen_time": gorm.Expr("min(device_first_seen_time, ?)", seen),
+			"device_last_seen_time":  gorm.Expr("max(device_last_seen_time, ?)", seen),
+		}),
+	}).Create(&Device{
+		DeviceOwner:         owner,
+		DeviceName:          name,
+		DeviceFirstSeenTime: seen,
+		DeviceLastSeenTime:  seen,
+	}).Error
+}
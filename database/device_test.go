@@ -0,0 +1,29 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTouchDevice(t *testing.T) {
+	var device Device
+	Open("file::memory:?cache=shared")
+
+	assert.NoError(t, TouchDevice(Orm, 0, "laptop", 20))
+	assert.NoError(t, TouchDevice(Orm, 0, "laptop", 10))
+	assert.NoError(t, TouchDevice(Orm, 0, "laptop", 30))
+	assert.NoError(t, TouchDevice(Orm, 0, "", 40))
+
+	assert.NoError(t, TouchDevice(Orm, 1, "laptop", 50))
+
+	var count int64
+	Orm.Model(&Device{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	Orm.First(&device, "device_owner = 0 AND device_name = ?", "laptop")
+	assert.Equal(t, int64(10), device.DeviceFirstSeenTime)
+	assert.Equal(t, int64(30), device.DeviceLastSeenTime)
+
+	Close()
+}
@@ -0,0 +1,494 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Encrypted payloads are stored as
+// enc1:<key id>:<plaintext size>:<wrapped data key>:<ciphertext>, every
+// value sealed with its own AES-256-GCM data key, which is in turn sealed
+// with the master key named by the key id. Both seals are bound to the
+// place of the value, so a ciphertext copied to another row or column no
+// longer opens. The plaintext size stays readable so SQL can still add up
+// sizes.
+const encryptedPrefix = "enc1:"
+
+// Keyed ClipboardItemHashes are stored as hmac1:<HMAC-SHA256 of the hash>.
+const keyedHashPrefix = "hmac1:"
+
+// hashKeyConfig is the Config holding the hash key, sealed by the master
+// key.
+const hashKeyConfig = "hash_key"
+
+// ClipboardItemSizeQuery is the length of ClipboardItemData in SQL,
+// whether it is encrypted or not.
+const ClipboardItemSizeQuery = "CASE WHEN substr(clipboard_items.clipboard_item_data, 1, 5) = 'enc1:' THEN CAST(substr(clipboard_items.clipboard_item_data, 15) AS INTEGER) ELSE length(clipboard_items.clipboard_item_data) END"
+
+var ErrNoEncryptionKey = errors.New("encrypted with a key that is not configured")
+
+type keyring struct {
+	current   string
+	keys      map[string]masterKey
+	indexText bool
+	hashKey   []byte
+}
+
+// masterKey is a master key under one of its ids. Values sealed before
+// they were bound to their place carry the unbound id.
+type masterKey struct {
+	aead  cipher.AEAD
+	bound bool
+}
+
+var encryption *keyring
+
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+	schema.RegisterSerializer("encrypted_text", encryptedSerializer{text: true})
+}
+
+// ParseEncryptionKey decodes a base64 encoded 32 byte master key, as found
+// in a key file or the environment.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key is %d bytes, want 32", len(key))
+	}
+	return key, nil
+}
+
+// GenerateEncryptionKey returns a new base64 encoded master key.
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// SetEncryptionKeys encrypts ClipboardItems written from now on with key,
+// and decrypts those written with key or any of the old keys. A nil key
+// turns encryption off. ClipboardItemText stays in plaintext, and so
+// searchable, only when indexText is set. Once the database is open it
+// also sets up the hash key, Open does so otherwise.
+func SetEncryptionKeys(key []byte, oldKeys [][]byte, indexText bool) error {
+	if key == nil {
+		encryption = nil
+		return nil
+	}
+
+	ring := &keyring{keys: map[string]masterKey{}, indexText: indexText}
+	for i, k := range append([][]byte{key}, oldKeys...) {
+		aead, err := newAEAD(k)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			ring.current = boundEncryptionKeyID(k)
+		}
+		ring.keys[encryptionKeyID(k)] = masterKey{aead: aead}
+		ring.keys[boundEncryptionKeyID(k)] = masterKey{aead: aead, bound: true}
+	}
+	if Orm != nil {
+		err := setupHashKey(Orm, ring)
+		if err != nil {
+			return err
+		}
+	}
+	encryption = ring
+	return nil
+}
+
+// encryptionKeyID names a master key without giving it away.
+func encryptionKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// boundEncryptionKeyID names a master key for values bound to their place,
+// so those sealed before can be told apart, and are rewritten by
+// ReencryptClipboardItems.
+func boundEncryptionKeyID(key []byte) string {
+	return encryptionKeyID(append([]byte("bound:"), key...))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte, place []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		panic(err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, place)
+}
+
+func unseal(aead cipher.AEAD, sealed []byte, place []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted value too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], place)
+}
+
+// encrypt seals plaintext bound to place, which decrypt has to be given
+// again.
+func (ring *keyring) encrypt(plaintext string, place string) (string, error) {
+	dataKey := make([]byte, 32)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + ring.current + ":" + strconv.Itoa(len(plaintext)) + ":" +
+		base64.StdEncoding.EncodeToString(seal(ring.keys[ring.current].aead, dataKey, []byte(place))) + ":" +
+		base64.StdEncoding.EncodeToString(seal(aead, []byte(plaintext), []byte(place))), nil
+}
+
+func (ring *keyring) decrypt(value string, place string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if len(parts) != 4 {
+		return "", errors.New("malformed encrypted value")
+	}
+	var master masterKey
+	if ring != nil {
+		master = ring.keys[parts[0]]
+	}
+	if master.aead == nil {
+		return "", fmt.Errorf("%w: %s", ErrNoEncryptionKey, parts[0])
+	}
+	var bound []byte
+	if master.bound {
+		bound = []byte(place)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := unseal(master.aead, wrapped, bound)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := unseal(aead, sealed, bound)
+	return string(plaintext), err
+}
+
+// keyHash keys a ClipboardItemHash with the hash key, keyed ones are left
+// as they are.
+func (ring *keyring) keyHash(hash string) string {
+	if ring.hashKey == nil || hash == "" || strings.HasPrefix(hash, keyedHashPrefix) {
+		return hash
+	}
+	mac := hmac.New(sha256.New, ring.hashKey)
+	mac.Write([]byte(hash))
+	return keyedHashPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// KeyClipboardItemHash keys the content hash of a ClipboardItem when
+// encryption is on, so the stored hash no longer tells which content it is
+// of while equal content still hashes equally. End-to-end encrypted
+// Workspaces get their hashes keyed by the client instead.
+func KeyClipboardItemHash(hash string) string {
+	if encryption == nil {
+		return hash
+	}
+	return encryption.keyHash(hash)
+}
+
+// setupHashKey opens the hash key, or makes one when there is none yet,
+// and keys the ClipboardItemHashes stored before. The hash key outlives
+// master key rotations, so hashes never need keying again.
+func setupHashKey(db *gorm.DB, ring *keyring) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		config := Config{}
+		err := tx.Where("key = ?", hashKeyConfig).Limit(1).Find(&config).Error
+		if err != nil {
+			return err
+		}
+		if config.Value == "" {
+			ring.hashKey = make([]byte, 32)
+			_, err = rand.Read(ring.hashKey)
+			if err != nil {
+				return err
+			}
+			config.Key = hashKeyConfig
+			config.Value, err = ring.encrypt(base64.StdEncoding.EncodeToString(ring.hashKey), hashKeyConfig)
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&config).Error
+		} else {
+			var key string
+			key, err = ring.decrypt(config.Value, hashKeyConfig)
+			if err != nil {
+				return fmt.Errorf("decrypting the hash key: %w", err)
+			}
+			ring.hashKey, err = base64.StdEncoding.DecodeString(key)
+		}
+		if err != nil {
+			return err
+		}
+		return keyClipboardItemHashes(tx, ring)
+	})
+}
+
+// keyClipboardItemHashes keys the hashes of ClipboardItems, their revisions
+// and copy counts outside end-to-end encrypted Workspaces that are not
+// keyed yet. Like rewriting payloads it is not an edit. A ClipboardItem
+// whose keyed hash is taken, by a copy inserted while encryption was off,
+// keeps its hash.
+func keyClipboardItemHashes(tx *gorm.DB, ring *keyring) error {
+	unkeyed := "clipboard_item_hash != '' AND clipboard_item_hash NOT LIKE '" + keyedHashPrefix + "%'"
+	notEndToEnd := "NOT IN (SELECT `index` FROM workspaces WHERE workspace_end_to_end)"
+
+	items := []ClipboardItem{}
+	err := tx.Select("index", "clipboard_item_hash", "clipboard_item_owner", "clipboard_item_workspace").
+		Where(unkeyed + " AND clipboard_item_workspace " + notEndToEnd).
+		Find(&items).Error
+	if err != nil {
+		return err
+	}
+	if len(items) > 0 {
+		err = tx.Exec(dropReencryptTriggersQuery).Error
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			keyed := ring.keyHash(item.ClipboardItemHash)
+			err = tx.Exec("UPDATE clipboard_items SET clipboard_item_hash = ? WHERE `index` = ? AND NOT EXISTS (SELECT 1 FROM clipboard_items WHERE clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_hash = ?)",
+				keyed, item.Index, item.ClipboardItemOwner, item.ClipboardItemWorkspace, keyed).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Exec(createRevisionTriggerQuery + createChangeTriggerQuery).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	revisions := []ClipboardItemRevision{}
+	err = tx.Select("index", "clipboard_item_hash").
+		Where(unkeyed + " AND clipboard_item_index IN (SELECT `index` FROM clipboard_items WHERE clipboard_item_workspace " + notEndToEnd + ")").
+		Find(&revisions).Error
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		err = tx.Model(&revision).Update("clipboard_item_hash", ring.keyHash(revision.ClipboardItemHash)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	recopies := []ClipboardItemRecopy{}
+	err = tx.Where(unkeyed + " AND workspace " + notEndToEnd).Find(&recopies).Error
+	if err != nil {
+		return err
+	}
+	for _, recopy := range recopies {
+		err = tx.Exec("UPDATE OR IGNORE clipboard_item_recopies SET clipboard_item_hash = ? WHERE owner = ? AND workspace = ? AND clipboard_item_hash = ?",
+			ring.keyHash(recopy.ClipboardItemHash), recopy.Owner, recopy.Workspace, recopy.ClipboardItemHash).Error
+		if err != nil {
+			return err
+		}
+	}
+	// Counts of a copy whose keyed hash has its own count already
+	return tx.Where(unkeyed + " AND workspace " + notEndToEnd).Delete(&ClipboardItemRecopy{}).Error
+}
+
+// ReserveClipboardItemIndex gives a new ClipboardItem the index it is
+// going to be inserted with when encryption is on, its payload is bound to
+// it before it is written.
+func ReserveClipboardItemIndex(tx *gorm.DB, index *int64) error {
+	if encryption == nil || *index != 0 {
+		return nil
+	}
+	return tx.Raw(nextClipboardItemIndexQuery).Scan(index).Error
+}
+
+// BeforeCreate reserves the index of a new ClipboardItem.
+func (item *ClipboardItem) BeforeCreate(tx *gorm.DB) error {
+	return ReserveClipboardItemIndex(tx, &item.Index)
+}
+
+// placeOf is what the value of field in dst is bound to: the column and
+// index of its ClipboardItem. Revisions are copied from their ClipboardItem
+// as they are, so they stay bound to it, and the index has to be read
+// before the value.
+func placeOf(ctx context.Context, field *schema.Field, dst reflect.Value) (string, error) {
+	indexField := field.Schema.LookUpField("ClipboardItemIndex")
+	if indexField == nil {
+		indexField = field.Schema.LookUpField("Index")
+	}
+	var index int64
+	if indexField != nil {
+		value, _ := indexField.ValueOf(ctx, dst)
+		index, _ = value.(int64)
+	}
+	if index == 0 {
+		return "", errors.New("the index of its ClipboardItem is unknown")
+	}
+	return fmt.Sprintf("clipboard_items.%s:%d", field.DBName, index), nil
+}
+
+// encryptedSerializer encrypts a string column when encryption is on, and
+// decrypts it on read whether or not it is still on. Values written before
+// encryption was turned on are read as they are. The text variant is left
+// in plaintext when text is to be indexed.
+type encryptedSerializer struct {
+	text bool
+}
+
+func (s encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	}
+	if strings.HasPrefix(value, encryptedPrefix) {
+		place, err := placeOf(ctx, field, dst)
+		if err != nil {
+			return fmt.Errorf("decrypting %s: %w", field.Name, err)
+		}
+		plaintext, err := encryption.decrypt(value, place)
+		if err != nil {
+			return fmt.Errorf("decrypting %s: %w", field.Name, err)
+		}
+		value = plaintext
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+func (s encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	if encryption == nil || value == "" || (s.text && encryption.indexText) {
+		return value, nil
+	}
+	place, err := placeOf(ctx, field, dst)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting %s: %w", field.Name, err)
+	}
+	return encryption.encrypt(value, place)
+}
+
+// staleEncryption is the SQL condition for a text and a data column not
+// stored the way the current keys want them.
+func staleEncryption(textColumn string, dataColumn string) string {
+	current := encryptedPrefix + encryption.current + ":%"
+	text := fmt.Sprintf("(%s != '' AND %s NOT LIKE '%s')", textColumn, textColumn, current)
+	if encryption.indexText {
+		text = fmt.Sprintf("%s LIKE '%s%%'", textColumn, encryptedPrefix)
+	}
+	return fmt.Sprintf("%s OR (%s != '' AND %s NOT LIKE '%s')", text, dataColumn, dataColumn, current)
+}
+
+// ReencryptClipboardItems rewrites up to limit ClipboardItems and as many
+// revisions that are in plaintext, under an old key or not bound to their
+// place with the current key, along with the hash key, and returns how many
+// rows it rewrote. Rewriting is not an edit, it records no revision and no
+// change.
+func ReencryptClipboardItems(limit int) (int, error) {
+	if encryption == nil {
+		return 0, nil
+	}
+
+	count := 0
+	err := Orm.Transaction(func(tx *gorm.DB) error {
+		configs := []Config{}
+		err := tx.Where("key = ? AND value NOT LIKE ?", hashKeyConfig, encryptedPrefix+encryption.current+":%").Find(&configs).Error
+		if err != nil {
+			return err
+		}
+		for _, config := range configs {
+			config.Value, err = encryption.encrypt(base64.StdEncoding.EncodeToString(encryption.hashKey), hashKeyConfig)
+			if err != nil {
+				return err
+			}
+			err = tx.Save(&config).Error
+			if err != nil {
+				return err
+			}
+		}
+		count += len(configs)
+
+		items := []ClipboardItem{}
+		err = tx.Where(staleEncryption("clipboard_item_text", "clipboard_item_data")).Limit(limit).Find(&items).Error
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			err = tx.Exec(dropReencryptTriggersQuery).Error
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				err = tx.Model(&item).Select("clipboard_item_text", "clipboard_item_data").Updates(&item).Error
+				if err != nil {
+					return err
+				}
+			}
+			err = tx.Exec(createRevisionTriggerQuery + createChangeTriggerQuery).Error
+			if err != nil {
+				return err
+			}
+		}
+		count += len(items)
+
+		revisions := []ClipboardItemRevision{}
+		err = tx.Where(staleEncryption("clipboard_item_text", "clipboard_item_data")).Limit(limit).Find(&revisions).Error
+		if err != nil {
+			return err
+		}
+		for _, revision := range revisions {
+			err = tx.Model(&revision).Select("clipboard_item_text", "clipboard_item_data").Updates(&revision).Error
+			if err != nil {
+				return err
+			}
+		}
+		count += len(revisions)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEncryptionKey(t *testing.T) []byte {
+	s, err := GenerateEncryptionKey()
+	assert.NoError(t, err)
+	key, err := ParseEncryptionKey(s)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	_, err := ParseEncryptionKey("YQ==")
+	assert.Error(t, err)
+	_, err = ParseEncryptionKey("!")
+	assert.Error(t, err)
+}
+
+func TestEncryptClipboardItem(t *testing.T) {
+	var raw ClipboardItem
+	var stats []ClipboardItemDailyStat
+	var size int64
+	var count int64
+	Open("file::memory:?cache=shared")
+	assert.NoError(t, SetEncryptionKeys(testEncryptionKey(t), nil, false))
+
+	item := ClipboardItem{
+		ClipboardItemTime: 1,
+		ClipboardItemText: "secret",
+		ClipboardItemHash: "a",
+		ClipboardItemData: "c2VjcmV0",
+	}
+	assert.NoError(t, Orm.Create(&item).Error)
+
+	Orm.Raw("SELECT clipboard_item_text, clipboard_item_data FROM clipboard_items").Row().Scan(&raw.ClipboardItemText, &raw.ClipboardItemData)
+	assert.True(t, strings.HasPrefix(raw.ClipboardItemText, "enc1:"))
+	assert.True(t, strings.HasPrefix(raw.ClipboardItemData, "enc1:"))
+	assert.NotContains(t, raw.ClipboardItemData, "c2VjcmV0")
+
+	var found ClipboardItem
+	assert.NoError(t, Orm.First(&found).Error)
+	assert.Equal(t, "secret", found.ClipboardItemText)
+	assert.Equal(t, "c2VjcmV0", found.ClipboardItemData)
+
+	Orm.Table("clipboard_items").Select(ClipboardItemSizeQuery).Row().Scan(&size)
+	assert.Equal(t, int64(8), size)
+	Orm.Find(&stats)
+	assert.Equal(t, int64(8), stats[0].TotalSize)
+
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "secret").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	assert.ErrorIs(t, Orm.First(&found).Error, ErrNoEncryptionKey)
+
+	Close()
+}
+
+func TestEncryptClipboardItemIndexText(t *testing.T) {
+	var text string
+	var count int64
+	Open("file::memory:?cache=shared")
+	assert.NoError(t, SetEncryptionKeys(testEncryptionKey(t), nil, true))
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "searchable", ClipboardItemHash: "a", ClipboardItemData: "YQ=="})
+
+	Orm.Raw("SELECT clipboard_item_text FROM clipboard_items").Row().Scan(&text)
+	assert.Equal(t, "searchable", text)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "searchable").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	Close()
+}
+
+func TestReencryptClipboardItems(t *testing.T) {
+	var data string
+	var count int64
+	var changes []ClipboardItemChange
+	Open("file::memory:?cache=shared")
+
+	item := ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "before", ClipboardItemHash: "a", ClipboardItemData: "YQ=="}
+	Orm.Create(&item)
+	Orm.Model(&item).Updates(ClipboardItem{ClipboardItemText: "after", ClipboardItemRevision: 2})
+	Orm.Find(&changes)
+
+	oldKey := testEncryptionKey(t)
+	assert.NoError(t, SetEncryptionKeys(oldKey, nil, false))
+	reencrypted, err := ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reencrypted)
+
+	Orm.Raw("SELECT clipboard_item_data FROM clipboard_items").Row().Scan(&data)
+	assert.True(t, strings.HasPrefix(data, "enc1:"+boundEncryptionKeyID(oldKey)+":"))
+	Orm.Raw("SELECT clipboard_item_data FROM clipboard_item_revisions").Row().Scan(&data)
+	assert.True(t, strings.HasPrefix(data, "enc1:"+boundEncryptionKeyID(oldKey)+":"))
+	Orm.Model(&ClipboardItemRevision{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	var after []ClipboardItemChange
+	Orm.Find(&after)
+	assert.Equal(t, changes, after)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "after").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	reencrypted, err = ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reencrypted)
+
+	// The hash key is sealed again as well.
+	newKey := testEncryptionKey(t)
+	assert.NoError(t, SetEncryptionKeys(newKey, [][]byte{oldKey}, false))
+	reencrypted, err = ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reencrypted)
+
+	Orm.Raw("SELECT clipboard_item_data FROM clipboard_items").Row().Scan(&data)
+	assert.True(t, strings.HasPrefix(data, "enc1:"+boundEncryptionKeyID(newKey)+":"))
+	var revision ClipboardItemRevision
+	assert.NoError(t, SetEncryptionKeys(newKey, nil, false))
+	assert.NoError(t, Orm.First(&revision).Error)
+	assert.Equal(t, "before", revision.ClipboardItemText)
+
+	// Opting text into the index takes it out of the envelope again.
+	assert.NoError(t, SetEncryptionKeys(newKey, nil, true))
+	reencrypted, err = ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reencrypted)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "after").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	Close()
+}
+
+func TestEncryptClipboardItemBoundToPlace(t *testing.T) {
+	var data string
+	Open("file::memory:?cache=shared")
+	assert.NoError(t, SetEncryptionKeys(testEncryptionKey(t), nil, false))
+
+	first := ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "first", ClipboardItemHash: "a", ClipboardItemData: "YQ=="}
+	assert.NoError(t, Orm.Create(&first).Error)
+	assert.NoError(t, Orm.Delete(&first).Error)
+	second := ClipboardItem{ClipboardItemTime: 2, ClipboardItemText: "second", ClipboardItemHash: "b", ClipboardItemData: "Yg=="}
+	assert.NoError(t, Orm.Create(&second).Error)
+	third := ClipboardItem{ClipboardItemTime: 3, ClipboardItemText: "third", ClipboardItemHash: "c", ClipboardItemData: "Yw=="}
+	assert.NoError(t, Orm.Create(&third).Error)
+	// The index of a deleted one is not given out again.
+	assert.Equal(t, int64(2), second.Index)
+	assert.Equal(t, int64(3), third.Index)
+
+	var found ClipboardItem
+	assert.NoError(t, Orm.First(&found, third.Index).Error)
+	assert.Equal(t, "Yw==", found.ClipboardItemData)
+
+	// Moved to another row or column, a ciphertext no longer opens.
+	Orm.Raw("SELECT clipboard_item_data FROM clipboard_items WHERE `index` = ?", second.Index).Row().Scan(&data)
+	Orm.Exec("UPDATE clipboard_items SET clipboard_item_data = ? WHERE `index` = ?", data, third.Index)
+	assert.Error(t, Orm.First(&found, third.Index).Error)
+	Orm.Exec("UPDATE clipboard_items SET clipboard_item_text = ? WHERE `index` = ?", data, second.Index)
+	assert.Error(t, Orm.First(&found, second.Index).Error)
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	Close()
+}
+
+func TestReencryptUnboundClipboardItems(t *testing.T) {
+	var data string
+	Open("file::memory:?cache=shared")
+	key := testEncryptionKey(t)
+	aead, err := newAEAD(key)
+	assert.NoError(t, err)
+	unbound := &keyring{current: encryptionKeyID(key), keys: map[string]masterKey{encryptionKeyID(key): {aead: aead}}}
+	sealed, err := unbound.encrypt("YQ==", "")
+	assert.NoError(t, err)
+	Orm.Exec("INSERT INTO clipboard_items (clipboard_item_time, clipboard_item_text, clipboard_item_hash, clipboard_item_data) VALUES (1, '', 'a', ?)", sealed)
+
+	assert.NoError(t, SetEncryptionKeys(key, nil, false))
+	var found ClipboardItem
+	assert.NoError(t, Orm.First(&found).Error)
+	assert.Equal(t, "YQ==", found.ClipboardItemData)
+
+	reencrypted, err := ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reencrypted)
+	Orm.Raw("SELECT clipboard_item_data FROM clipboard_items").Row().Scan(&data)
+	assert.True(t, strings.HasPrefix(data, "enc1:"+boundEncryptionKeyID(key)+":"))
+	assert.NoError(t, Orm.First(&found).Error)
+	assert.Equal(t, "YQ==", found.ClipboardItemData)
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	Close()
+}
+
+func TestKeyClipboardItemHashes(t *testing.T) {
+	var hashes []string
+	var changes []ClipboardItemChange
+	Open("file::memory:?cache=shared")
+	assert.Equal(t, "a", KeyClipboardItemHash("a"))
+
+	Orm.Create(&Workspace{Index: 1, WorkspaceName: "e2e", WorkspaceEndToEnd: true})
+	item := ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "before", ClipboardItemHash: "a", ClipboardItemData: "YQ=="}
+	Orm.Create(&item)
+	Orm.Model(&item).Updates(ClipboardItem{ClipboardItemText: "after", ClipboardItemHash: "b", ClipboardItemRevision: 2})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "c", ClipboardItemData: "Yw==", ClipboardItemWorkspace: 1})
+	Orm.Create(&ClipboardItemRecopy{ClipboardItemHash: "b", CopyCount: 2})
+	Orm.Find(&changes)
+
+	key := testEncryptionKey(t)
+	assert.NoError(t, SetEncryptionKeys(key, nil, false))
+	keyed := KeyClipboardItemHash("b")
+	assert.True(t, strings.HasPrefix(keyed, "hmac1:"))
+	assert.Equal(t, keyed, KeyClipboardItemHash(keyed))
+
+	Orm.Model(&ClipboardItem{}).Order("`index`").Pluck("clipboard_item_hash", &hashes)
+	assert.Equal(t, []string{keyed, "c"}, hashes)
+	Orm.Model(&ClipboardItemRevision{}).Pluck("clipboard_item_hash", &hashes)
+	assert.Equal(t, []string{KeyClipboardItemHash("a")}, hashes)
+	Orm.Model(&ClipboardItemRecopy{}).Pluck("clipboard_item_hash", &hashes)
+	assert.Equal(t, []string{keyed}, hashes)
+	var after []ClipboardItemChange
+	Orm.Find(&after)
+	assert.Equal(t, changes, after)
+
+	// The hash key stays the same, whatever master key seals it.
+	newKey := testEncryptionKey(t)
+	assert.NoError(t, SetEncryptionKeys(newKey, [][]byte{key}, false))
+	_, err := ReencryptClipboardItems(100)
+	assert.NoError(t, err)
+	assert.NoError(t, SetEncryptionKeys(newKey, nil, false))
+	assert.Equal(t, keyed, KeyClipboardItemHash("b"))
+
+	assert.NoError(t, SetEncryptionKeys(nil, nil, false))
+	Close()
+}
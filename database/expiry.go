@@ -0,0 +1,10 @@
+package database
+
+// PurgeExpired permanently deletes the ClipboardItems, in trash or not,
+// that expired at the unix milliseconds timestamp now.
+func PurgeExpired(now int64) (int64, error) {
+	tx := Orm.
+		Where("clipboard_item_expires_time != 0 AND clipboard_item_expires_time <= ?", now).
+		Delete(&ClipboardItem{})
+	return tx.RowsAffected, tx.Error
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeExpired(t *testing.T) {
+	var times []int64
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemText: "123456", ClipboardItemHash: "b", ClipboardItemExpiresTime: 10})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "c", ClipboardItemExpiresTime: 10, ClipboardItemDeletedTime: 5})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 4, ClipboardItemHash: "d", ClipboardItemExpiresTime: 30})
+
+	purged, err := PurgeExpired(20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	Orm.Model(&ClipboardItem{}).Pluck("clipboard_item_time", &times)
+	assert.Equal(t, []int64{1, 4}, times)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "123456").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	Close()
+}
+
+func TestSecretNotIndexed(t *testing.T) {
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "hunter2", ClipboardItemHash: "a", ClipboardItemSecret: true})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemText: "hunter2", ClipboardItemHash: "b"})
+
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "hunter2").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	Orm.Model(&ClipboardItem{ClipboardItemTime: 2}).Where("clipboard_item_time = ?", 2).Update("clipboard_item_secret", true)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "hunter2").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	Orm.Where("clipboard_item_time = ?", 1).Delete(&ClipboardItem{})
+	Orm.Where("clipboard_item_time = ?", 2).Delete(&ClipboardItem{})
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "hunter2").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	Close()
+}
@@ -2,9 +2,12 @@ package database
 
 import (
 	"log"
+
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
 )
 
-const version = "3.0.0"
+const schemaVersion = "21.0.0"
 
 func getDatabaseVersion() uint64 {
 	var config Config
@@ -29,7 +32,7 @@ func migrateVersion() {
 	if !Orm.Migrator().HasTable(&ClipboardItem{}) {
 		initializingDatabase()
 	}
-	currentMajorVersion, err := getMajorVersion(version)
+	currentMajorVersion, err := getMajorVersion(schemaVersion)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -40,6 +43,60 @@ func migrateVersion() {
 		switch databaseVersion {
 		case currentMajorVersion:
 			return
+		case 20:
+			migrateVersion20To21()
+			continue
+		case 19:
+			migrateVersion19To20()
+			continue
+		case 18:
+			migrateVersion18To19()
+			continue
+		case 17:
+			migrateVersion17To18()
+			continue
+		case 16:
+			migrateVersion16To17()
+			continue
+		case 15:
+			migrateVersion15To16()
+			continue
+		case 14:
+			migrateVersion14To15()
+			continue
+		case 13:
+			migrateVersion13To14()
+			continue
+		case 12:
+			migrateVersion12To13()
+			continue
+		case 11:
+			migrateVersion11To12()
+			continue
+		case 10:
+			migrateVersion10To11()
+			continue
+		case 9:
+			migrateVersion9To10()
+			continue
+		case 8:
+			migrateVersion8To9()
+			continue
+		case 7:
+			migrateVersion7To8()
+			continue
+		case 6:
+			migrateVersion6To7()
+			continue
+		case 5:
+			migrateVersion5To6()
+			continue
+		case 4:
+			migrateVersion4To5()
+			continue
+		case 3:
+			migrateVersion3To4()
+			continue
 		case 2:
 			migrateVersion2To3()
 			continue
@@ -60,7 +117,23 @@ func initializingDatabase() {
 
 	tx := Orm.Begin()
 
-	err = tx.AutoMigrate(&ClipboardItem{}, &Config{})
+	err = tx.AutoMigrate(
+		&ClipboardItem{},
+		&Config{},
+		&ClipboardItemDailyStat{},
+		&ClipboardItemHourlyStat{},
+		&ClipboardItemRecopy{},
+		&ClipboardItemRevision{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&Device{},
+		&DevicePush{},
+		&User{},
+		&Workspace{},
+		&ClipboardItemBlindToken{},
+		&IngestRule{},
+		&AuditEntry{},
+	)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -69,7 +142,47 @@ func initializingDatabase() {
 		tx.Rollback()
 		log.Fatal(err)
 	}
-	err = tx.Create(&Config{Key: "version", Value: version}).Error
+	err = tx.Exec(createFts5TriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createFts5UpdateTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createRevisionTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createStatsTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createChangeTableQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createChangeTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createBlindTokenTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Exec(createAuditEntryTriggerQuery).Error
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	err = tx.Create(&Config{Key: "version", Value: schemaVersion}).Error
 	if err != nil {
 		tx.Rollback()
 		log.Fatal(err)
@@ -77,8 +190,8 @@ func initializingDatabase() {
 	tx.Commit()
 }
 
-func migrateVersion2To3() {
-	log.Println("Migrating to version 3")
+func migrateVersion12To13() {
+	log.Println("Migrating to version 13")
 	tx := Orm.Begin()
 	defer func() {
 		if err := recover(); err != nil {
@@ -87,15 +200,76 @@ func migrateVersion2To3() {
 		}
 	}()
 
-	err = tx.Migrator().DropColumn(&ClipboardItem{}, "index")
+	// Hashes become unique per owner, SQLite needs clipboard_items rebuilt
+	// for that and so its triggers recreated. Stats, recopies and devices
+	// get the owner in their primary key.
+	err = tx.Exec(dropClipboardItemTriggersQueryVersion13).Error
 	if err != nil {
 		panic(err)
 	}
-	err = tx.Migrator().RenameColumn(&ClipboardItem{}, "id", "index")
+	err = tx.Exec(renameTablesQueryVersion12).Error
 	if err != nil {
 		panic(err)
 	}
-	err = tx.Save(&Config{Key: "version", Value: "3.0.0"}).Error
+	err = tx.Migrator().CreateTable(&ClipboardItem{}, &ClipboardItemRecopy{}, &Device{}, &User{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(copyTablesQueryVersion12).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().DropTable(&ClipboardItemDailyStat{}, &ClipboardItemHourlyStat{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().CreateTable(&ClipboardItemDailyStat{}, &ClipboardItemHourlyStat{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(insertStatsTableQueryVersion13).Error
+	if err != nil {
+		panic(err)
+	}
+
+	// Tables created by earlier migrations from the current models already
+	// have the owner.
+	owned := []struct {
+		model interface{}
+		field string
+	}{
+		{&ClipboardItemChange{}, "ClipboardItemOwner"},
+		{&Webhook{}, "WebhookOwner"},
+		{&DevicePush{}, "DevicePushOwner"},
+	}
+	for _, o := range owned {
+		if !tx.Migrator().HasColumn(o.model, o.field) {
+			err = tx.Migrator().AddColumn(o.model, o.field)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if !tx.Migrator().HasIndex(o.model, o.field) {
+			err = tx.Migrator().CreateIndex(o.model, o.field)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	for _, query := range []string{
+		createFts5TriggerQueryVersion13,
+		createFts5UpdateTriggerQueryVersion6,
+		createRevisionTriggerQueryVersion6,
+		createStatsTriggerQueryVersion13,
+		createChangeTriggerQueryVersion13,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "13.0.0"}).Error
 	if err != nil {
 		panic(err)
 	}
@@ -103,7 +277,8 @@ func migrateVersion2To3() {
 	tx.Commit()
 }
 
-func migrateVersion1To2() {
+func migrateVersion11To12() {
+	log.Println("Migrating to version 12")
 	tx := Orm.Begin()
 	defer func() {
 		if err := recover(); err != nil {
@@ -112,22 +287,52 @@ func migrateVersion1To2() {
 		}
 	}()
 
-	err := tx.Exec(createFts5TableQuery).Error
+	err = tx.Migrator().CreateTable(&DevicePush{})
 	if err != nil {
 		panic(err)
 	}
-	err = tx.Exec(insertFts5TableQuery).Error
+	err = tx.Save(&Config{Key: "version", Value: "12.0.0"}).Error
 	if err != nil {
 		panic(err)
 	}
-	err = tx.Save(&Config{Key: "version", Value: "2.0.0"}).Error
+
+	tx.Commit()
+}
+
+func migrateVersion10To11() {
+	log.Println("Migrating to version 11")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	for _, field := range []string{"ClipboardItemDevice", "ClipboardItemSourceApp", "ClipboardItemWindowTitle"} {
+		err = tx.Migrator().AddColumn(&ClipboardItem{}, field)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Migrator().CreateIndex(&ClipboardItem{}, "ClipboardItemDevice")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().CreateTable(&Device{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "11.0.0"}).Error
 	if err != nil {
 		panic(err)
 	}
+
 	tx.Commit()
 }
 
-func migrateVersion0To1() {
+func migrateVersion9To10() {
+	log.Println("Migrating to version 10")
 	tx := Orm.Begin()
 	defer func() {
 		if err := recover(); err != nil {
@@ -136,9 +341,749 @@ func migrateVersion0To1() {
 		}
 	}()
 
-	err := tx.Create(&Config{Key: "version", Value: "1.0.0"}).Error
+	err = tx.Exec(createChangeTableQueryVersion10).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(insertChangeTableQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(createChangeTriggerQueryVersion10).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "10.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion8To9() {
+	log.Println("Migrating to version 9")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.Migrator().CreateTable(&Webhook{}, &WebhookDelivery{})
 	if err != nil {
 		panic(err)
 	}
+	err = tx.Save(&Config{Key: "version", Value: "9.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion7To8() {
+	log.Println("Migrating to version 8")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// ClipboardItems may be tagged and pinned by bulk operations.
+	for _, field := range []string{"ClipboardItemTags", "ClipboardItemPinned"} {
+		if !tx.Migrator().HasColumn(&ClipboardItem{}, field) {
+			err = tx.Migrator().AddColumn(&ClipboardItem{}, field)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "8.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion6To7() {
+	log.Println("Migrating to version 7")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.Migrator().AddColumn(&ClipboardItem{}, "ClipboardItemDeletedTime")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().CreateIndex(&ClipboardItem{}, "ClipboardItemDeletedTime")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(dropStatsTriggerQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(createStatsTriggerQueryVersion7).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "7.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion5To6() {
+	log.Println("Migrating to version 6")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.AutoMigrate(&ClipboardItemRevision{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(dropFts5UpdateTriggerQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(createFts5UpdateTriggerQueryVersion6).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(createRevisionTriggerQueryVersion6).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "6.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion4To5() {
+	log.Println("Migrating to version 5")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.Migrator().AddColumn(&ClipboardItem{}, "ClipboardItemRevision")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "5.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion3To4() {
+	log.Println("Migrating to version 4")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.AutoMigrate(
+		&ClipboardItemDailyStat{},
+		&ClipboardItemHourlyStat{},
+		&ClipboardItemRecopy{},
+	)
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(createStatsTriggerQueryVersion4).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(insertStatsTableQueryVersion4).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "4.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
 	tx.Commit()
 }
+
+func migrateVersion2To3() {
+	log.Println("Migrating to version 3")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err = tx.Migrator().DropColumn(&ClipboardItem{}, "index")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().RenameColumn(&ClipboardItem{}, "id", "index")
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "3.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion1To2() {
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err := tx.Exec(createFts5TableQueryVersion2).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(insertFts5TableQueryVersion2).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "2.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+	tx.Commit()
+}
+
+func migrateVersion0To1() {
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	err := tx.Create(&Config{Key: "version", Value: "1.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+	tx.Commit()
+}
+
+func migrateVersion13To14() {
+	log.Println("Migrating to version 14")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Hashes become unique per workspace, the stats and recopies get the
+	// workspace in their primary key. Tables created by earlier migrations
+	// from the current models already have it.
+	err = tx.Exec(dropClipboardItemTriggersQueryVersion13).Error
+	if err != nil {
+		panic(err)
+	}
+	if !tx.Migrator().HasColumn(&ClipboardItem{}, "ClipboardItemWorkspace") {
+		err = tx.Migrator().AddColumn(&ClipboardItem{}, "ClipboardItemWorkspace")
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Exec("DROP INDEX IF EXISTS idx_clipboard_item_owner_hash").Error
+	if err != nil {
+		panic(err)
+	}
+	if !tx.Migrator().HasIndex(&ClipboardItem{}, "idx_clipboard_item_owner_workspace_hash") {
+		err = tx.Migrator().CreateIndex(&ClipboardItem{}, "idx_clipboard_item_owner_workspace_hash")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !tx.Migrator().HasColumn(&ClipboardItemRecopy{}, "Workspace") {
+		err = tx.Exec(renameTablesQueryVersion13).Error
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Migrator().CreateTable(&ClipboardItemRecopy{})
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Exec(copyTablesQueryVersion13).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Migrator().DropTable(&ClipboardItemDailyStat{}, &ClipboardItemHourlyStat{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Migrator().CreateTable(&ClipboardItemDailyStat{}, &ClipboardItemHourlyStat{})
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Exec(insertStatsTableQueryVersion14).Error
+	if err != nil {
+		panic(err)
+	}
+
+	workspaced := []struct {
+		model interface{}
+		field string
+	}{
+		{&ClipboardItemChange{}, "ClipboardItemWorkspace"},
+		{&Webhook{}, "WebhookWorkspace"},
+	}
+	for _, w := range workspaced {
+		if !tx.Migrator().HasColumn(w.model, w.field) {
+			err = tx.Migrator().AddColumn(w.model, w.field)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if !tx.Migrator().HasIndex(w.model, w.field) {
+			err = tx.Migrator().CreateIndex(w.model, w.field)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	if !tx.Migrator().HasTable(&Workspace{}) {
+		err = tx.Migrator().CreateTable(&Workspace{})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for _, query := range []string{
+		createFts5TriggerQueryVersion13,
+		createFts5UpdateTriggerQueryVersion6,
+		createRevisionTriggerQueryVersion6,
+		createStatsTriggerQueryVersion14,
+		createChangeTriggerQueryVersion14,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "14.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion14To15() {
+	log.Println("Migrating to version 15")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Payloads may be encrypted from now on, the triggers learn to take
+	// sizes from the envelope and to keep encrypted text out of the index.
+	// Nothing is encrypted yet, so the index and stats stay as they are.
+	err = tx.Exec(dropClipboardItemTriggersQueryVersion13).Error
+	if err != nil {
+		panic(err)
+	}
+	for _, query := range []string{
+		createFts5TriggerQueryVersion15,
+		createFts5UpdateTriggerQueryVersion15,
+		createRevisionTriggerQueryVersion6,
+		createStatsTriggerQuery,
+		createChangeTriggerQueryVersion14,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "15.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion15To16() {
+	log.Println("Migrating to version 16")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Workspaces may be end-to-end encrypted, their ClipboardItems are
+	// searched by blind token and kept out of the FTS5 index.
+	if !tx.Migrator().HasColumn(&Workspace{}, "WorkspaceEndToEnd") {
+		err = tx.Migrator().AddColumn(&Workspace{}, "WorkspaceEndToEnd")
+		if err != nil {
+			panic(err)
+		}
+	}
+	if !tx.Migrator().HasTable(&ClipboardItemBlindToken{}) {
+		err = tx.Migrator().CreateTable(&ClipboardItemBlindToken{})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = tx.Exec(dropClipboardItemTriggersQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	for _, query := range []string{
+		createFts5TriggerQueryVersion16,
+		createFts5UpdateTriggerQueryVersion16,
+		createRevisionTriggerQueryVersion6,
+		createStatsTriggerQuery,
+		createChangeTriggerQueryVersion14,
+		createBlindTokenTriggerQueryVersion16,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "16.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion16To17() {
+	log.Println("Migrating to version 17")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// ClipboardItems may be flagged by sensitive content detection, secret
+	// ones are kept out of the FTS5 index and expiring ones are deleted.
+	for _, field := range []string{"ClipboardItemSensitive", "ClipboardItemSecret", "ClipboardItemExpiresTime"} {
+		if !tx.Migrator().HasColumn(&ClipboardItem{}, field) {
+			err = tx.Migrator().AddColumn(&ClipboardItem{}, field)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	if !tx.Migrator().HasIndex(&ClipboardItem{}, "ClipboardItemExpiresTime") {
+		err = tx.Migrator().CreateIndex(&ClipboardItem{}, "ClipboardItemExpiresTime")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = tx.Exec(dropClipboardItemTriggersQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	for _, query := range []string{
+		createFts5TriggerQueryVersion17,
+		createFts5UpdateTriggerQueryVersion17,
+		createRevisionTriggerQueryVersion6,
+		createStatsTriggerQuery,
+		createChangeTriggerQueryVersion14,
+		createBlindTokenTriggerQueryVersion16,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "17.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion17To18() {
+	log.Println("Migrating to version 18")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Admins edit the ingest rules every inserted ClipboardItem has to pass.
+	if !tx.Migrator().HasColumn(&User{}, "UserAdmin") {
+		err = tx.Migrator().AddColumn(&User{}, "UserAdmin")
+		if err != nil {
+			panic(err)
+		}
+	}
+	if !tx.Migrator().HasTable(&IngestRule{}) {
+		err = tx.Migrator().CreateTable(&IngestRule{})
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "18.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion18To19() {
+	log.Println("Migrating to version 19")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Every API call is recorded in the append-only audit log.
+	if !tx.Migrator().HasTable(&AuditEntry{}) {
+		err = tx.Migrator().CreateTable(&AuditEntry{})
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Exec(createAuditEntryTriggerQuery).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "19.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion19To20() {
+	log.Println("Migrating to version 20")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// ClipboardItems of different Users or Workspaces may share a time, so
+	// the index, revisions, changes and pushes no longer key them by it
+	// alone, and times become unique per owner and Workspace.
+	err = tx.Exec(dropClipboardItemTriggersQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	added := []struct {
+		model interface{}
+		field string
+	}{
+		{&ClipboardItemRevision{}, "ClipboardItemIndex"},
+		{&DevicePush{}, "DevicePushWorkspace"},
+	}
+	for _, a := range added {
+		if !tx.Migrator().HasColumn(a.model, a.field) {
+			err = tx.Migrator().AddColumn(a.model, a.field)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	err = tx.Exec(rekeyTablesQueryVersion19).Error
+	if err != nil {
+		panic(err)
+	}
+	keepUnattributed(tx, "unattributed_clipboard_item_revisions", "revisions")
+	keepUnattributed(tx, "unattributed_device_pushes", "device pushes")
+	if !tx.Migrator().HasIndex(&ClipboardItemRevision{}, "idx_clipboard_item_index_revision") {
+		err = tx.Migrator().CreateIndex(&ClipboardItemRevision{}, "idx_clipboard_item_index_revision")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// A User could have two ClipboardItems at the same time, the later
+	// ones move to the next free millisecond.
+	clashes := []ClipboardItem{}
+	err = tx.
+		Select("`index`, clipboard_item_time, clipboard_item_owner, clipboard_item_workspace").
+		Where("EXISTS (SELECT 1 FROM clipboard_items AS earlier WHERE earlier.clipboard_item_owner = clipboard_items.clipboard_item_owner AND earlier.clipboard_item_workspace = clipboard_items.clipboard_item_workspace AND earlier.clipboard_item_time = clipboard_items.clipboard_item_time AND earlier.`index` < clipboard_items.`index`)").
+		Order("`index`").
+		Find(&clashes).Error
+	if err != nil {
+		panic(err)
+	}
+	for _, item := range clashes {
+		itemTime := item.ClipboardItemTime
+		for taken := int64(1); taken > 0; {
+			itemTime++
+			err = tx.Model(&ClipboardItem{}).
+				Where("clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_time = ?", item.ClipboardItemOwner, item.ClipboardItemWorkspace, itemTime).
+				Count(&taken).Error
+			if err != nil {
+				panic(err)
+			}
+		}
+		err = tx.Model(&ClipboardItem{}).Where("`index` = ?", item.Index).Update("clipboard_item_time", itemTime).Error
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Model(&ClipboardItemRevision{}).Where("clipboard_item_index = ?", item.Index).Update("clipboard_item_time", itemTime).Error
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Create(&ClipboardItemChange{
+			ClipboardItemTime:       itemTime,
+			ClipboardItemChangeType: "created",
+			ClipboardItemChangeTime: utils.GetUnixMillisTimestamp(),
+			ClipboardItemOwner:      item.ClipboardItemOwner,
+			ClipboardItemWorkspace:  item.ClipboardItemWorkspace,
+		}).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	if !tx.Migrator().HasIndex(&ClipboardItem{}, "idx_clipboard_item_owner_workspace_time") {
+		err = tx.Migrator().CreateIndex(&ClipboardItem{}, "idx_clipboard_item_owner_workspace_time")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for _, query := range []string{
+		createFts5TableQuery,
+		insertFts5TableQuery,
+		createFts5TriggerQuery,
+		createFts5UpdateTriggerQuery,
+		createRevisionTriggerQuery,
+		createStatsTriggerQuery,
+		createChangeTriggerQuery,
+		createBlindTokenTriggerQueryVersion16,
+	} {
+		err = tx.Exec(query).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Save(&Config{Key: "version", Value: "20.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+func migrateVersion20To21() {
+	log.Println("Migrating to version 21")
+	tx := Orm.Begin()
+	defer func() {
+		if err := recover(); err != nil {
+			tx.Rollback()
+			log.Fatal("Migration failed: ", err)
+		}
+	}()
+
+	// Blind tokens belong to the index of their ClipboardItem rather than
+	// its time, which ClipboardItems of other Users or Workspaces may share.
+	// Tables created from the current models already have it.
+	err = tx.Exec(dropBlindTokenTriggerQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	if !tx.Migrator().HasColumn(&ClipboardItemBlindToken{}, "ClipboardItemIndex") {
+		err = tx.Exec(renameTablesQueryVersion20).Error
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Migrator().CreateTable(&ClipboardItemBlindToken{})
+		if err != nil {
+			panic(err)
+		}
+		err = tx.Exec(copyTablesQueryVersion20).Error
+		if err != nil {
+			panic(err)
+		}
+		keepUnattributed(tx, "unattributed_clipboard_item_blind_tokens", "blind tokens")
+	}
+	err = tx.Exec(createBlindTokenTriggerQuery).Error
+	if err != nil {
+		panic(err)
+	}
+	err = tx.Save(&Config{Key: "version", Value: "21.0.0"}).Error
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Commit()
+}
+
+// keepUnattributed leaves table, holding what a migration could not tell
+// which ClipboardItem it belongs to, for the administrator to look into, or
+// drops it when it is empty.
+func keepUnattributed(tx *gorm.DB, table string, what string) {
+	var count int64
+
+	err = tx.Table(table).Count(&count).Error
+	if err != nil {
+		panic(err)
+	}
+	if count == 0 {
+		err = tx.Migrator().DropTable(table)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	log.Printf("%d %s could not be told apart by time, they are kept in %s", count, what, table)
+}
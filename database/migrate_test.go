@@ -13,7 +13,7 @@ func TestMigrateVersion(t *testing.T) {
 	migrateVersion()
 
 	Orm.First(&config, "key = ?", "version")
-	assert.Equal(t, version, config.Value)
+	assert.Equal(t, schemaVersion, config.Value)
 
 	Close()
 }
@@ -26,7 +26,274 @@ func TestMigrateVersion0Database(t *testing.T) {
 	migrateVersion()
 
 	Orm.First(&config, "key = ?", "version")
-	assert.Equal(t, version, config.Value)
+	assert.Equal(t, schemaVersion, config.Value)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseStats(t *testing.T) {
+	var dailyStat ClipboardItemDailyStat
+	var hourlyStat ClipboardItemHourlyStat
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	Orm.First(&dailyStat)
+	assert.Equal(t, int64(1647146952858/86400000), dailyStat.Day)
+	assert.Equal(t, int64(1), dailyStat.ItemCount)
+
+	Orm.First(&hourlyStat)
+	assert.Equal(t, int64(1), hourlyStat.ItemCount)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseRevision(t *testing.T) {
+	var item ClipboardItem
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	Orm.First(&item)
+	assert.Equal(t, int64(1), item.ClipboardItemRevision)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseWebhook(t *testing.T) {
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	assert.True(t, Orm.Migrator().HasTable(&Webhook{}))
+	assert.True(t, Orm.Migrator().HasIndex(&WebhookDelivery{}, "idx_webhook_delivery_due"))
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseChange(t *testing.T) {
+	var changes []ClipboardItemChange
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	Orm.Find(&changes)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, int64(1647146952858), changes[0].ClipboardItemTime)
+	assert.Equal(t, "created", changes[0].ClipboardItemChangeType)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseDevice(t *testing.T) {
+	var item ClipboardItem
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	assert.True(t, Orm.Migrator().HasTable(&Device{}))
+	assert.True(t, Orm.Migrator().HasTable(&DevicePush{}))
+	assert.True(t, Orm.Migrator().HasIndex(&ClipboardItem{}, "ClipboardItemDevice"))
+	Orm.First(&item)
+	assert.Equal(t, "", item.ClipboardItemDevice)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseOwner(t *testing.T) {
+	var count int64
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	assert.True(t, Orm.Migrator().HasTable(&User{}))
+	Orm.Model(&ClipboardItem{}).Where("clipboard_item_owner = 0").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	var item ClipboardItem
+	Orm.First(&item)
+	item.Index = 0
+	item.ClipboardItemTime++
+	item.ClipboardItemOwner = 1
+	assert.NoError(t, Orm.Create(&item).Error)
+
+	Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE rowid = ?", item.Index).Scan(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Model(&ClipboardItemChange{}).Where("clipboard_item_owner = 1").Count(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Model(&ClipboardItemDailyStat{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	Close()
+}
+
+func TestMigrateVersion0DatabaseAudit(t *testing.T) {
+	connectDatabase("file::memory:?cache=shared")
+	createVersion0Database()
+
+	migrateVersion()
+
+	assert.True(t, Orm.Migrator().HasTable(&AuditEntry{}))
+	entry := AuditEntry{AuditEntryTime: 1000}
+	assert.NoError(t, Orm.Create(&entry).Error)
+	assert.Error(t, Orm.Model(&entry).Update("audit_entry_status", 200).Error)
+
+	Close()
+}
+
+func TestMigrateVersion1Database(t *testing.T) {
+	var config Config
+	var count int64
+	connectDatabase("file::memory:?cache=shared")
+	createVersion1Database()
+	assert.Equal(t, uint64(1), getDatabaseVersion())
+
+	migrateVersion()
+
+	Orm.First(&config, "key = ?", "version")
+	assert.Equal(t, schemaVersion, config.Value)
+
+	assert.NoError(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemText: "migrate again", ClipboardItemHash: "other"}).Error)
+	Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ?", "again").Scan(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Model(&ClipboardItemChange{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+	// Nothing had to be set aside
+	for _, table := range []string{"unattributed_clipboard_item_revisions", "unattributed_device_pushes", "unattributed_clipboard_item_blind_tokens"} {
+		assert.False(t, Orm.Migrator().HasTable(table), table)
+	}
+
+	Close()
+}
+
+func TestMigrateVersion10Database(t *testing.T) {
+	var config Config
+	var count int64
+	connectDatabase("file::memory:?cache=shared")
+	createVersion10Database()
+	assert.Equal(t, uint64(10), getDatabaseVersion())
+	assert.False(t, Orm.Migrator().HasTable(&Device{}))
+
+	migrateVersion()
+
+	Orm.First(&config, "key = ?", "version")
+	assert.Equal(t, schemaVersion, config.Value)
+	assert.True(t, Orm.Migrator().HasTable(&Device{}))
+	assert.True(t, Orm.Migrator().HasIndex(&ClipboardItem{}, "ClipboardItemDevice"))
+
+	var item ClipboardItem
+	Orm.First(&item)
+	assert.Equal(t, int64(1), item.ClipboardItemRevision)
+	item.Index = 0
+	item.ClipboardItemTime++
+	item.ClipboardItemText = "migrate again"
+	item.ClipboardItemHash = "other"
+	item.ClipboardItemDevice = "laptop"
+	assert.NoError(t, Orm.Create(&item).Error)
+	Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ?", "again").Scan(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Model(&ClipboardItemChange{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	Close()
+}
+
+func TestMigrateVersion19Database(t *testing.T) {
+	var count int64
+	connectDatabase("file::memory:?cache=shared")
+	createVersion19Database()
+	assert.Equal(t, uint64(19), getDatabaseVersion())
+	// Tables created from the current models have them, an archive left at
+	// version 19 does not
+	Orm.Exec("DROP INDEX idx_clipboard_item_owner_workspace_time")
+	Orm.Exec("DROP INDEX idx_clipboard_item_index_revision")
+
+	// Times shared by Workspaces, and twice in one
+	for _, item := range []ClipboardItem{
+		{ClipboardItemTime: 5, ClipboardItemText: "first", ClipboardItemHash: "a"},
+		{ClipboardItemTime: 5, ClipboardItemText: "second", ClipboardItemHash: "b", ClipboardItemWorkspace: 1},
+		{ClipboardItemTime: 5, ClipboardItemText: "third", ClipboardItemHash: "c"},
+		{ClipboardItemTime: 7, ClipboardItemText: "fourth", ClipboardItemHash: "d"},
+	} {
+		assert.NoError(t, Orm.Create(&item).Error)
+	}
+	for _, hash := range []string{"b", "d"} {
+		Orm.Model(&ClipboardItem{}).Where("clipboard_item_hash = ?", hash).Update("clipboard_item_text", "edited")
+	}
+	Orm.Exec("INSERT INTO device_pushes (device_name, clipboard_item_time, device_push_expires_time) VALUES ('phone', 5, 1), ('phone', 7, 1)")
+
+	migrateVersion()
+
+	times := []int64{}
+	Orm.Model(&ClipboardItem{}).Order("`index`").Pluck("clipboard_item_time", &times)
+	assert.Equal(t, []int64{1647146952858, 5, 5, 6, 7}, times)
+	for _, search := range []string{"first", "third"} {
+		Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ?", search).Scan(&count)
+		assert.Equal(t, int64(1), count, search)
+	}
+	Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ?", "edited").Scan(&count)
+	assert.Equal(t, int64(2), count)
+
+	// The revision and push of a shared time could be either ClipboardItem's
+	revisions := []ClipboardItemRevision{}
+	Orm.Find(&revisions)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, int64(503), revisions[0].ClipboardItemIndex)
+	assert.Equal(t, "fourth", revisions[0].ClipboardItemText)
+	Orm.Table("unattributed_clipboard_item_revisions").Where("clipboard_item_time = 5").Count(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Model(&DevicePush{}).Where("clipboard_item_time = 7").Count(&count)
+	assert.Equal(t, int64(1), count)
+	Orm.Table("unattributed_device_pushes").Where("clipboard_item_time = 5").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	var change ClipboardItemChange
+	assert.NoError(t, Orm.First(&change, "clipboard_item_workspace = 0 AND clipboard_item_time = 6").Error)
+	assert.Error(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 6, ClipboardItemHash: "e"}).Error)
+
+	Close()
+}
+
+func TestMigrateVersion20Database(t *testing.T) {
+	connectDatabase("file::memory:?cache=shared")
+	createVersion20Database()
+	assert.Equal(t, uint64(20), getDatabaseVersion())
+	// Blind tokens of an archive left at version 20 are keyed by time
+	Orm.Exec(dropBlindTokenTriggerQuery)
+	Orm.Exec("DROP TABLE clipboard_item_blind_tokens")
+	Orm.Exec("CREATE TABLE clipboard_item_blind_tokens (clipboard_item_time integer, blind_token text, PRIMARY KEY (clipboard_item_time, blind_token))")
+
+	Orm.Create(&Workspace{Index: 1, WorkspaceName: "one", WorkspaceEndToEnd: true})
+	Orm.Create(&Workspace{Index: 2, WorkspaceName: "two", WorkspaceEndToEnd: true})
+	items := []ClipboardItem{
+		{ClipboardItemTime: 5, ClipboardItemHash: "a", ClipboardItemWorkspace: 1},
+		{ClipboardItemTime: 6, ClipboardItemHash: "b", ClipboardItemWorkspace: 1},
+		{ClipboardItemTime: 6, ClipboardItemHash: "c", ClipboardItemWorkspace: 2},
+	}
+	for i := range items {
+		assert.NoError(t, Orm.Create(&items[i]).Error)
+	}
+	Orm.Exec("INSERT INTO clipboard_item_blind_tokens VALUES (5, 'x'), (6, 'y')")
+
+	migrateVersion()
+
+	// The tokens of the shared time could be either ClipboardItem's
+	tokens, err := BlindTokens(Orm, []int64{items[0].Index, items[1].Index, items[2].Index})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]string{items[0].Index: {"x"}}, tokens)
+	var kept []string
+	Orm.Table("unattributed_clipboard_item_blind_tokens").Pluck("blind_token", &kept)
+	assert.Equal(t, []string{"y"}, kept)
+
+	assert.NoError(t, Orm.Delete(&items[0]).Error)
+	tokens, _ = BlindTokens(Orm, []int64{items[0].Index})
+	assert.Empty(t, tokens)
 
 	Close()
 }
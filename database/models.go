@@ -6,9 +6,162 @@ type Config struct {
 }
 
 type ClipboardItem struct {
-	Index             int64  `gorm:"primaryKey"`
-	ClipboardItemTime int64  `json:"ClipboardItemTime" binding:"required"` // unix milliseconds timestamp
-	ClipboardItemText string `json:"ClipboardItemText"`
-	ClipboardItemHash string `gorm:"unique" json:"ClipboardItemHash"`
-	ClipboardItemData string `json:"ClipboardItemData"`
+	Index                    int64  `gorm:"primaryKey"`
+	ClipboardItemTime        int64  `gorm:"uniqueIndex:idx_clipboard_item_owner_workspace_time,priority:3" json:"ClipboardItemTime" binding:"required"` // unix milliseconds timestamp
+	ClipboardItemText        string `gorm:"serializer:encrypted_text" json:"ClipboardItemText"`
+	ClipboardItemHash        string `gorm:"uniqueIndex:idx_clipboard_item_owner_workspace_hash,priority:3" json:"ClipboardItemHash"`
+	ClipboardItemData        string `gorm:"serializer:encrypted" json:"ClipboardItemData"`
+	ClipboardItemSize        int64  `gorm:"->;-:migration" json:"ClipboardItemSize"`                  // length of ClipboardItemData, computed on read
+	ClipboardItemRevision    int64  `gorm:"not null;default:1" json:"ClipboardItemRevision"`          // incremented on every update
+	ClipboardItemDeletedTime int64  `gorm:"not null;default:0;index" json:"ClipboardItemDeletedTime"` // unix milliseconds timestamp of moving to trash, 0 if not in trash
+	ClipboardItemDevice      string `gorm:"not null;default:'';index" json:"ClipboardItemDevice"`     // name of the device it was copied on
+	ClipboardItemSourceApp   string `gorm:"not null;default:''" json:"ClipboardItemSourceApp"`        // application it was copied from
+	ClipboardItemWindowTitle string `gorm:"not null;default:''" json:"ClipboardItemWindowTitle"`      // title of the window it was copied from
+	ClipboardItemTags        string `gorm:"not null;default:''" json:"ClipboardItemTags"`             // comma separated tags given by bulk tag
+	ClipboardItemPinned      bool   `gorm:"not null;default:false" json:"ClipboardItemPinned"`        // pinned by bulk pin
+	ClipboardItemSensitive   string `gorm:"not null;default:''" json:"ClipboardItemSensitive"`        // comma separated detection rules it matched on insert
+	ClipboardItemSecret      bool   `gorm:"not null;default:false" json:"ClipboardItemSecret"`        // kept out of search
+	ClipboardItemExpiresTime int64  `gorm:"not null;default:0;index" json:"ClipboardItemExpiresTime"` // unix milliseconds timestamp after which it is gone, 0 if it does not expire
+	// Index of the owning User, 0 without accounts
+	ClipboardItemOwner int64 `gorm:"not null;default:0;uniqueIndex:idx_clipboard_item_owner_workspace_hash,priority:1;uniqueIndex:idx_clipboard_item_owner_workspace_time,priority:1" json:"-"`
+	// Index of the Workspace, 0 for the default one
+	ClipboardItemWorkspace int64 `gorm:"not null;default:0;uniqueIndex:idx_clipboard_item_owner_workspace_hash,priority:2;uniqueIndex:idx_clipboard_item_owner_workspace_time,priority:2" json:"-"`
+	// Keyed hashes of the search terms, given by clients of end-to-end
+	// encrypted Workspaces, stored in clipboard_item_blind_tokens
+	ClipboardItemBlindTokens []string `gorm:"-" json:"ClipboardItemBlindTokens,omitempty"`
+	// MIME types of ClipboardItemData declared by the client for ingest
+	// rules, not stored
+	ClipboardItemMimeTypes []string `gorm:"-" json:"ClipboardItemMimeTypes,omitempty"`
+}
+
+type ClipboardItemBlindToken struct {
+	ClipboardItemIndex int64  `gorm:"primaryKey;autoIncrement:false"` // Index of the ClipboardItem
+	BlindToken         string `gorm:"primaryKey;index"`
+}
+
+type Workspace struct {
+	Index                   int64  `gorm:"primaryKey" json:"Index"`
+	WorkspaceOwner          int64  `gorm:"not null;default:0;uniqueIndex:idx_workspace_owner_name,priority:1" json:"-"`
+	WorkspaceName           string `gorm:"not null;uniqueIndex:idx_workspace_owner_name,priority:2" json:"WorkspaceName"`
+	WorkspaceTrashRetention int64  `gorm:"not null;default:0" json:"WorkspaceTrashRetention"` // milliseconds, 0 for the server default, negative to keep trash
+	WorkspaceEndToEnd       bool   `gorm:"not null;default:false" json:"WorkspaceEndToEnd"`   // clients encrypt, the server only sees ciphertext and blind tokens
+	WorkspaceCreatedTime    int64  `json:"WorkspaceCreatedTime"`                              // unix milliseconds timestamp
+	ClipboardItemCount      int64  `gorm:"->;-:migration" json:"ClipboardItemCount"`          // live ClipboardItems in it, computed on read
+}
+
+type User struct {
+	Index           int64  `gorm:"primaryKey" json:"Index"`
+	UserName        string `gorm:"not null;uniqueIndex" json:"UserName"`
+	UserTokenHash   string `gorm:"not null;uniqueIndex" json:"-"` // sha256 of the API token
+	UserDisabled    bool   `gorm:"not null;default:false" json:"UserDisabled"`
+	UserAdmin       bool   `gorm:"not null;default:false" json:"UserAdmin"` // may edit server wide settings such as ingest rules
+	UserCreatedTime int64  `json:"UserCreatedTime"`                         // unix milliseconds timestamp
+}
+
+type IngestRule struct {
+	Index                 int64  `gorm:"primaryKey" json:"Index"`
+	IngestRuleType        string `gorm:"not null" json:"IngestRuleType"`  // max_size, min_length, text_allow, text_deny, mime_allow, mime_deny or source_app_deny
+	IngestRuleValue       string `gorm:"not null" json:"IngestRuleValue"` // bytes, characters, regular expression, MIME type pattern or application name
+	IngestRuleCreatedTime int64  `json:"IngestRuleCreatedTime"`           // unix milliseconds timestamp
+}
+
+type AuditEntry struct {
+	Index                    int64  `gorm:"primaryKey" json:"Index"`
+	AuditEntryTime           int64  `gorm:"not null;index" json:"AuditEntryTime"`                // unix milliseconds timestamp
+	AuditEntryRequestID      string `gorm:"not null;default:''" json:"AuditEntryRequestID"`      // X-Request-ID of the request
+	AuditEntryClientIP       string `gorm:"not null;default:'';index" json:"AuditEntryClientIP"` // behind a trusted proxy, the client it forwarded for
+	AuditEntryUser           int64  `gorm:"not null;default:0;index" json:"AuditEntryUser"`      // Index of the User, 0 without accounts or a valid token
+	AuditEntryUserName       string `gorm:"not null;default:''" json:"AuditEntryUserName"`       // name of the User when the request was made
+	AuditEntryWorkspace      int64  `gorm:"not null;default:0" json:"AuditEntryWorkspace"`       // Index of the Workspace, 0 for the default one
+	AuditEntryMethod         string `gorm:"not null;default:''" json:"AuditEntryMethod"`         // HTTP method
+	AuditEntryRoute          string `gorm:"not null;default:'';index" json:"AuditEntryRoute"`    // matched route, such as /api/v1/ClipboardItem/:id
+	AuditEntryClipboardItems string `gorm:"not null;default:''" json:"AuditEntryClipboardItems"` // comma separated ClipboardItemTimes read or changed
+	AuditEntryStatus         int    `gorm:"not null;default:0" json:"AuditEntryStatus"`          // HTTP status of the response
+}
+
+type Device struct {
+	DeviceOwner         int64  `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	DeviceName          string `gorm:"primaryKey" json:"DeviceName"`
+	DeviceFirstSeenTime int64  `json:"DeviceFirstSeenTime"`                      // unix milliseconds timestamp
+	DeviceLastSeenTime  int64  `json:"DeviceLastSeenTime"`                       // unix milliseconds timestamp
+	ClipboardItemCount  int64  `gorm:"->;-:migration" json:"ClipboardItemCount"` // live ClipboardItems from it, computed on read
+}
+
+type DevicePush struct {
+	Index                 int64  `gorm:"primaryKey" json:"Index"`
+	DeviceName            string `gorm:"not null;index" json:"DeviceName"` // device to put the ClipboardItem on
+	ClipboardItemTime     int64  `gorm:"not null" json:"ClipboardItemTime"`
+	DevicePushCreatedTime int64  `json:"DevicePushCreatedTime"`                         // unix milliseconds timestamp
+	DevicePushExpiresTime int64  `gorm:"not null" json:"DevicePushExpiresTime"`         // unix milliseconds timestamp, not pulled afterwards
+	DevicePushLeaseTime   int64  `gorm:"not null;default:0" json:"DevicePushLeaseTime"` // unix milliseconds timestamp, pulled again afterwards unless acknowledged
+	DevicePushOwner       int64  `gorm:"not null;default:0;index" json:"-"`
+	DevicePushWorkspace   int64  `gorm:"not null;default:0" json:"-"` // Workspace of the ClipboardItem
+}
+
+type ClipboardItemDailyStat struct {
+	Owner     int64 `gorm:"primaryKey;autoIncrement:false"`
+	Workspace int64 `gorm:"primaryKey;autoIncrement:false;not null;default:0"`
+	Day       int64 `gorm:"primaryKey;autoIncrement:false"` // days since unix epoch, UTC
+	ItemCount int64
+	TotalSize int64 // bytes of ClipboardItemData
+}
+
+type ClipboardItemHourlyStat struct {
+	Owner      int64 `gorm:"primaryKey;autoIncrement:false"`
+	Workspace  int64 `gorm:"primaryKey;autoIncrement:false;not null;default:0"`
+	HourOfWeek int64 `gorm:"primaryKey;autoIncrement:false"` // 0 is Monday 00:00 UTC
+	ItemCount  int64
+}
+
+type ClipboardItemRecopy struct {
+	Owner             int64  `gorm:"primaryKey;autoIncrement:false"`
+	Workspace         int64  `gorm:"primaryKey;autoIncrement:false;not null;default:0"`
+	ClipboardItemHash string `gorm:"primaryKey"`
+	CopyCount         int64  `gorm:"index"` // times copied again after the first
+	LastCopyTime      int64  // unix milliseconds timestamp
+}
+
+type ClipboardItemRevision struct {
+	Index                     int64  `gorm:"primaryKey" json:"Index"`
+	ClipboardItemIndex        int64  `gorm:"not null;default:0;uniqueIndex:idx_clipboard_item_index_revision,priority:1" json:"-"` // Index of the ClipboardItem
+	ClipboardItemTime         int64  `json:"ClipboardItemTime"`
+	ClipboardItemRevision     int64  `gorm:"uniqueIndex:idx_clipboard_item_index_revision,priority:2" json:"ClipboardItemRevision"`
+	ClipboardItemText         string `gorm:"serializer:encrypted_text" json:"ClipboardItemText"`
+	ClipboardItemHash         string `json:"ClipboardItemHash"`
+	ClipboardItemData         string `gorm:"serializer:encrypted" json:"ClipboardItemData"`
+	ClipboardItemReplacedTime int64  `json:"ClipboardItemReplacedTime"` // unix milliseconds timestamp
+}
+
+type Webhook struct {
+	Index              int64  `gorm:"primaryKey" json:"Index"`
+	WebhookURL         string `gorm:"not null" json:"WebhookURL"`
+	WebhookSecret      string `gorm:"not null" json:"WebhookSecret,omitempty"` // HMAC-SHA256 key, only shown on creation
+	WebhookEvents      string `json:"WebhookEvents"`                           // comma separated event types, empty for all
+	WebhookSearch      string `json:"WebhookSearch"`                           // FTS5 query the ClipboardItem has to match, empty for all
+	WebhookCreatedTime int64  `json:"WebhookCreatedTime"`                      // unix milliseconds timestamp
+	WebhookOwner       int64  `gorm:"not null;default:0;index" json:"-"`
+	WebhookWorkspace   int64  `gorm:"not null;default:0;index" json:"-"`
+}
+
+type WebhookDelivery struct {
+	Index                          int64  `gorm:"primaryKey" json:"Index"`
+	WebhookIndex                   int64  `gorm:"index" json:"WebhookIndex"`
+	WebhookDeliveryEvent           string `json:"WebhookDeliveryEvent"`
+	WebhookDeliveryPayload         string `json:"WebhookDeliveryPayload"`
+	WebhookDeliveryState           string `gorm:"not null;index:idx_webhook_delivery_due,priority:1" json:"WebhookDeliveryState"`           // pending, delivered or failed
+	WebhookDeliveryNextAttemptTime int64  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"WebhookDeliveryNextAttemptTime"` // unix milliseconds timestamp
+	WebhookDeliveryAttempts        int64  `gorm:"not null;default:0" json:"WebhookDeliveryAttempts"`
+	WebhookDeliveryStatusCode      int    `json:"WebhookDeliveryStatusCode"`    // HTTP status of the last attempt
+	WebhookDeliveryError           string `json:"WebhookDeliveryError"`         // error of the last attempt
+	WebhookDeliveryCreatedTime     int64  `json:"WebhookDeliveryCreatedTime"`   // unix milliseconds timestamp
+	WebhookDeliveryDeliveredTime   int64  `json:"WebhookDeliveryDeliveredTime"` // unix milliseconds timestamp, 0 until delivered
+}
+
+type ClipboardItemChange struct {
+	ClipboardItemChangeSeq  int64  `gorm:"primaryKey" json:"ClipboardItemChangeSeq"` // increases with every change
+	ClipboardItemTime       int64  `json:"ClipboardItemTime"`
+	ClipboardItemChangeType string `json:"ClipboardItemChangeType"` // created, updated, deleted, restored or purged
+	ClipboardItemChangeTime int64  `json:"ClipboardItemChangeTime"` // unix milliseconds timestamp
+	ClipboardItemOwner      int64  `gorm:"not null;default:0;index" json:"-"`
+	ClipboardItemWorkspace  int64  `gorm:"not null;default:0;index" json:"-"`
 }
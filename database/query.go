@@ -1,6 +1,6 @@
 package database
 
-const createFts5TableQuery = `
+const createFts5TableQueryVersion2 = `
 	CREATE VIRTUAL TABLE clipboard_items_fts USING fts5(
 		clipboard_item_time, 
 		clipboard_item_text, 
@@ -54,7 +54,60 @@ const createFts5TableQuery = `
 	END;
 `
 
-const insertFts5TableQuery = `
+const createFts5TriggerQueryVersion13 = `
+	CREATE TRIGGER clipboard_items_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			new.clipboard_item_text
+		);
+	END;
+		
+	CREATE TRIGGER clipboard_items_ad AFTER DELETE ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			old.clipboard_item_text
+		);
+	END;
+`
+
+const dropFts5UpdateTriggerQuery = `
+	DROP TRIGGER IF EXISTS clipboard_items_au;
+`
+
+const createFts5UpdateTriggerQueryVersion6 = `
+	CREATE TRIGGER clipboard_items_au AFTER UPDATE OF clipboard_item_time, clipboard_item_text ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			old.clipboard_item_text
+		);
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			new.clipboard_item_text
+		);
+	END;
+`
+
+const insertFts5TableQueryVersion2 = `
 INSERT INTO clipboard_items_fts (
 	rowid, 
 	clipboard_item_text
@@ -62,3 +115,1215 @@ INSERT INTO clipboard_items_fts (
 SELECT clipboard_items.clipboard_item_time, clipboard_items.clipboard_item_text 
 FROM clipboard_items;
 `
+
+const createStatsTriggerQueryVersion4 = `
+	CREATE TRIGGER clipboard_items_stats_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_item_daily_stats(
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0)
+		)
+		ON CONFLICT(day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_ad AFTER DELETE ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		DELETE FROM clipboard_item_recopies 
+		WHERE clipboard_item_hash = old.clipboard_item_hash;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_au AFTER UPDATE OF clipboard_item_time, clipboard_item_data ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		INSERT INTO clipboard_item_daily_stats(
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0)
+		)
+		ON CONFLICT(day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+`
+
+const insertStatsTableQueryVersion4 = `
+INSERT INTO clipboard_item_daily_stats (
+	day, 
+	item_count, 
+	total_size
+)
+SELECT clipboard_item_time / 86400000, count(*), sum(ifnull(length(clipboard_item_data), 0)) 
+FROM clipboard_items 
+GROUP BY clipboard_item_time / 86400000;
+
+INSERT INTO clipboard_item_hourly_stats (
+	hour_of_week, 
+	item_count
+)
+SELECT (clipboard_item_time / 3600000 + 72) % 168, count(*) 
+FROM clipboard_items 
+GROUP BY (clipboard_item_time / 3600000 + 72) % 168;
+`
+
+const createRevisionTriggerQueryVersion6 = `
+	CREATE TRIGGER clipboard_items_revisions_au AFTER UPDATE OF clipboard_item_text, clipboard_item_data ON clipboard_items 
+	WHEN old.clipboard_item_text IS NOT new.clipboard_item_text OR old.clipboard_item_data IS NOT new.clipboard_item_data 
+	BEGIN
+		INSERT INTO clipboard_item_revisions(
+			clipboard_item_time, 
+			clipboard_item_revision, 
+			clipboard_item_text, 
+			clipboard_item_hash, 
+			clipboard_item_data, 
+			clipboard_item_replaced_time
+		) 
+		VALUES (
+			old.clipboard_item_time, 
+			old.clipboard_item_revision, 
+			old.clipboard_item_text, 
+			old.clipboard_item_hash, 
+			old.clipboard_item_data, 
+			CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)
+		);
+	END;
+
+	CREATE TRIGGER clipboard_items_revisions_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_revisions 
+		WHERE clipboard_item_time = old.clipboard_item_time;
+	END;
+`
+
+const dropStatsTriggerQuery = `
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_au;
+`
+
+const createStatsTriggerQueryVersion7 = `
+	CREATE TRIGGER clipboard_items_stats_ai AFTER INSERT ON clipboard_items 
+	WHEN new.clipboard_item_deleted_time = 0 
+	BEGIN
+		INSERT INTO clipboard_item_daily_stats(
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0)
+		)
+		ON CONFLICT(day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_ad AFTER DELETE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 
+	BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+	END;
+
+	CREATE TRIGGER clipboard_items_recopies_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_recopies 
+		WHERE clipboard_item_hash = old.clipboard_item_hash;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_au AFTER UPDATE OF clipboard_item_time, clipboard_item_data, clipboard_item_deleted_time ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE day = old.clipboard_item_time / 86400000 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		INSERT INTO clipboard_item_daily_stats(
+			day, 
+			item_count, 
+			total_size
+		) 
+		SELECT 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0) 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			hour_of_week, 
+			item_count
+		) 
+		SELECT 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+`
+
+const createChangeTableQueryVersion10 = `
+	CREATE TABLE clipboard_item_changes (
+		clipboard_item_change_seq integer PRIMARY KEY AUTOINCREMENT,
+		clipboard_item_time integer NOT NULL,
+		clipboard_item_change_type text NOT NULL,
+		clipboard_item_change_time integer NOT NULL
+	);
+
+	CREATE UNIQUE INDEX idx_clipboard_item_changes_clipboard_item_time ON clipboard_item_changes(clipboard_item_time);
+`
+
+const createChangeTriggerQueryVersion10 = `
+	CREATE TRIGGER clipboard_items_changes_ai AFTER INSERT ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+		VALUES (new.clipboard_item_time, 'created', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER));
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_au AFTER UPDATE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+		VALUES (new.clipboard_item_time, 'updated', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER));
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_trash AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time != 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+		VALUES (new.clipboard_item_time, 'deleted', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER));
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_restore AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time != 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+		VALUES (new.clipboard_item_time, 'restored', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER));
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = old.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+		VALUES (old.clipboard_item_time, 'purged', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER));
+	END;
+`
+
+const insertChangeTableQuery = `
+	INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time) 
+	SELECT 
+		clipboard_item_time, 
+		CASE WHEN clipboard_item_deleted_time = 0 THEN 'created' ELSE 'deleted' END, 
+		CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER) 
+	FROM clipboard_items 
+	ORDER BY clipboard_item_time;
+`
+
+const dropClipboardItemTriggersQueryVersion13 = `
+	DROP TRIGGER IF EXISTS clipboard_items_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_au;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_au;
+	DROP TRIGGER IF EXISTS clipboard_items_recopies_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_au;
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_au;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_trash;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_restore;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ad;
+`
+
+const dropClipboardItemTriggersQuery = `
+	DROP TRIGGER IF EXISTS clipboard_items_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_au;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_stats_au;
+	DROP TRIGGER IF EXISTS clipboard_items_recopies_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_au;
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_au;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_trash;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_restore;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_blind_tokens_ad;
+`
+
+const insertStatsTableQueryVersion13 = `
+INSERT INTO clipboard_item_daily_stats (
+	owner, 
+	day, 
+	item_count, 
+	total_size
+)
+SELECT clipboard_item_owner, clipboard_item_time / 86400000, count(*), sum(ifnull(length(clipboard_item_data), 0)) 
+FROM clipboard_items 
+WHERE clipboard_item_deleted_time = 0 
+GROUP BY clipboard_item_owner, clipboard_item_time / 86400000;
+
+INSERT INTO clipboard_item_hourly_stats (
+	owner, 
+	hour_of_week, 
+	item_count
+)
+SELECT clipboard_item_owner, (clipboard_item_time / 3600000 + 72) % 168, count(*) 
+FROM clipboard_items 
+WHERE clipboard_item_deleted_time = 0 
+GROUP BY clipboard_item_owner, (clipboard_item_time / 3600000 + 72) % 168;
+`
+
+const createStatsTriggerQueryVersion13 = `
+	CREATE TRIGGER clipboard_items_stats_ai AFTER INSERT ON clipboard_items 
+	WHEN new.clipboard_item_deleted_time = 0 
+	BEGIN
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0)
+		)
+		ON CONFLICT(owner, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(owner, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_ad AFTER DELETE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 
+	BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE owner = old.clipboard_item_owner AND day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+	END;
+
+	CREATE TRIGGER clipboard_items_recopies_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_recopies 
+		WHERE owner = old.clipboard_item_owner AND clipboard_item_hash = old.clipboard_item_hash;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_au AFTER UPDATE OF clipboard_item_time, clipboard_item_data, clipboard_item_deleted_time, clipboard_item_owner ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE owner = old.clipboard_item_owner AND day = old.clipboard_item_time / 86400000 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0) 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			hour_of_week, 
+			item_count
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+`
+
+const createChangeTableQuery = `
+	CREATE TABLE clipboard_item_changes (
+		clipboard_item_change_seq integer PRIMARY KEY AUTOINCREMENT,
+		clipboard_item_time integer NOT NULL,
+		clipboard_item_change_type text NOT NULL,
+		clipboard_item_change_time integer NOT NULL,
+		clipboard_item_owner integer NOT NULL DEFAULT 0,
+		clipboard_item_workspace integer NOT NULL DEFAULT 0
+	);
+
+	CREATE UNIQUE INDEX idx_clipboard_item_changes_owner_workspace_time ON clipboard_item_changes(clipboard_item_owner, clipboard_item_workspace, clipboard_item_time);
+	CREATE INDEX idx_clipboard_item_changes_clipboard_item_owner ON clipboard_item_changes(clipboard_item_owner);
+	CREATE INDEX idx_clipboard_item_changes_clipboard_item_workspace ON clipboard_item_changes(clipboard_item_workspace);
+`
+
+const createChangeTriggerQueryVersion13 = `
+	CREATE TRIGGER clipboard_items_changes_ai AFTER INSERT ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner) 
+		VALUES (new.clipboard_item_time, 'created', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_au AFTER UPDATE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner) 
+		VALUES (new.clipboard_item_time, 'updated', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_trash AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time != 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner) 
+		VALUES (new.clipboard_item_time, 'deleted', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_restore AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time != 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner) 
+		VALUES (new.clipboard_item_time, 'restored', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = old.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner) 
+		VALUES (old.clipboard_item_time, 'purged', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), old.clipboard_item_owner);
+	END;
+`
+
+const renameTablesQueryVersion12 = `
+	DROP INDEX IF EXISTS idx_clipboard_items_clipboard_item_device;
+	DROP INDEX IF EXISTS idx_clipboard_items_clipboard_item_deleted_time;
+	DROP INDEX IF EXISTS idx_clipboard_item_recopies_copy_count;
+	ALTER TABLE clipboard_items RENAME TO clipboard_items_version12;
+	ALTER TABLE clipboard_item_recopies RENAME TO clipboard_item_recopies_version12;
+	ALTER TABLE devices RENAME TO devices_version12;
+`
+
+const copyTablesQueryVersion12 = `
+	INSERT INTO clipboard_items(
+		` + "`index`" + `, 
+		clipboard_item_time, 
+		clipboard_item_text, 
+		clipboard_item_hash, 
+		clipboard_item_data, 
+		clipboard_item_revision, 
+		clipboard_item_deleted_time, 
+		clipboard_item_device, 
+		clipboard_item_source_app, 
+		clipboard_item_window_title, 
+		clipboard_item_tags, 
+		clipboard_item_pinned
+	) 
+	SELECT 
+		` + "`index`" + `, 
+		clipboard_item_time, 
+		clipboard_item_text, 
+		clipboard_item_hash, 
+		clipboard_item_data, 
+		clipboard_item_revision, 
+		clipboard_item_deleted_time, 
+		clipboard_item_device, 
+		clipboard_item_source_app, 
+		clipboard_item_window_title, 
+		clipboard_item_tags, 
+		clipboard_item_pinned 
+	FROM clipboard_items_version12;
+	DROP TABLE clipboard_items_version12;
+
+	INSERT INTO clipboard_item_recopies(owner, clipboard_item_hash, copy_count, last_copy_time) 
+	SELECT 0, clipboard_item_hash, copy_count, last_copy_time FROM clipboard_item_recopies_version12;
+	DROP TABLE clipboard_item_recopies_version12;
+
+	INSERT INTO devices(device_owner, device_name, device_first_seen_time, device_last_seen_time) 
+	SELECT 0, device_name, device_first_seen_time, device_last_seen_time FROM devices_version12;
+	DROP TABLE devices_version12;
+`
+
+const insertStatsTableQueryVersion14 = `
+INSERT INTO clipboard_item_daily_stats (
+	owner, 
+	workspace, 
+	day, 
+	item_count, 
+	total_size
+)
+SELECT clipboard_item_owner, clipboard_item_workspace, clipboard_item_time / 86400000, count(*), sum(ifnull(length(clipboard_item_data), 0)) 
+FROM clipboard_items 
+WHERE clipboard_item_deleted_time = 0 
+GROUP BY clipboard_item_owner, clipboard_item_workspace, clipboard_item_time / 86400000;
+
+INSERT INTO clipboard_item_hourly_stats (
+	owner, 
+	workspace, 
+	hour_of_week, 
+	item_count
+)
+SELECT clipboard_item_owner, clipboard_item_workspace, (clipboard_item_time / 3600000 + 72) % 168, count(*) 
+FROM clipboard_items 
+WHERE clipboard_item_deleted_time = 0 
+GROUP BY clipboard_item_owner, clipboard_item_workspace, (clipboard_item_time / 3600000 + 72) % 168;
+`
+
+const createStatsTriggerQueryVersion14 = `
+	CREATE TRIGGER clipboard_items_stats_ai AFTER INSERT ON clipboard_items 
+	WHEN new.clipboard_item_deleted_time = 0 
+	BEGIN
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			workspace, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0)
+		)
+		ON CONFLICT(owner, workspace, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			workspace, 
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(owner, workspace, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_ad AFTER DELETE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 
+	BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+	END;
+
+	CREATE TRIGGER clipboard_items_recopies_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_recopies 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND clipboard_item_hash = old.clipboard_item_hash;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_au AFTER UPDATE OF clipboard_item_time, clipboard_item_data, clipboard_item_deleted_time, clipboard_item_owner, clipboard_item_workspace ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - ifnull(length(old.clipboard_item_data), 0) 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			workspace, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			ifnull(length(new.clipboard_item_data), 0) 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, workspace, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			workspace, 
+			hour_of_week, 
+			item_count
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, workspace, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+`
+
+const createChangeTriggerQueryVersion14 = `
+	CREATE TRIGGER clipboard_items_changes_ai AFTER INSERT ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'created', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_au AFTER UPDATE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'updated', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_trash AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time != 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'deleted', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_restore AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time != 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'restored', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_time = old.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (old.clipboard_item_time, 'purged', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), old.clipboard_item_owner, old.clipboard_item_workspace);
+	END;
+`
+
+const renameTablesQueryVersion13 = `
+	DROP INDEX IF EXISTS idx_clipboard_item_recopies_copy_count;
+	ALTER TABLE clipboard_item_recopies RENAME TO clipboard_item_recopies_version13;
+`
+
+const copyTablesQueryVersion13 = `
+	INSERT INTO clipboard_item_recopies(owner, workspace, clipboard_item_hash, copy_count, last_copy_time) 
+	SELECT owner, 0, clipboard_item_hash, copy_count, last_copy_time FROM clipboard_item_recopies_version13;
+	DROP TABLE clipboard_item_recopies_version13;
+`
+
+const createFts5TriggerQueryVersion15 = `
+	CREATE TRIGGER clipboard_items_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+		
+	CREATE TRIGGER clipboard_items_ad AFTER DELETE ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' THEN '' ELSE old.clipboard_item_text END
+		);
+	END;
+`
+
+const createFts5UpdateTriggerQueryVersion15 = `
+	CREATE TRIGGER clipboard_items_au AFTER UPDATE OF clipboard_item_time, clipboard_item_text ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' THEN '' ELSE old.clipboard_item_text END
+		);
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+`
+
+const createStatsTriggerQuery = `
+	CREATE TRIGGER clipboard_items_stats_ai AFTER INSERT ON clipboard_items 
+	WHEN new.clipboard_item_deleted_time = 0 
+	BEGIN
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			workspace, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			CASE WHEN substr(new.clipboard_item_data, 1, 5) = 'enc1:' THEN CAST(substr(new.clipboard_item_data, 15) AS INTEGER) ELSE ifnull(length(new.clipboard_item_data), 0) END
+		)
+		ON CONFLICT(owner, workspace, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			workspace, 
+			hour_of_week, 
+			item_count
+		) 
+		VALUES (
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1
+		)
+		ON CONFLICT(owner, workspace, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_ad AFTER DELETE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 
+	BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - CASE WHEN substr(old.clipboard_item_data, 1, 5) = 'enc1:' THEN CAST(substr(old.clipboard_item_data, 15) AS INTEGER) ELSE ifnull(length(old.clipboard_item_data), 0) END 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+	END;
+
+	CREATE TRIGGER clipboard_items_recopies_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_recopies 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND clipboard_item_hash = old.clipboard_item_hash;
+	END;
+
+	CREATE TRIGGER clipboard_items_stats_au AFTER UPDATE OF clipboard_item_time, clipboard_item_data, clipboard_item_deleted_time, clipboard_item_owner, clipboard_item_workspace ON clipboard_items BEGIN
+		UPDATE clipboard_item_daily_stats SET 
+			item_count = item_count - 1, 
+			total_size = total_size - CASE WHEN substr(old.clipboard_item_data, 1, 5) = 'enc1:' THEN CAST(substr(old.clipboard_item_data, 15) AS INTEGER) ELSE ifnull(length(old.clipboard_item_data), 0) END 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_daily_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND day = old.clipboard_item_time / 86400000 AND item_count <= 0;
+		UPDATE clipboard_item_hourly_stats SET 
+			item_count = item_count - 1 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND old.clipboard_item_deleted_time = 0;
+		DELETE FROM clipboard_item_hourly_stats 
+		WHERE owner = old.clipboard_item_owner AND workspace = old.clipboard_item_workspace AND hour_of_week = (old.clipboard_item_time / 3600000 + 72) % 168 AND item_count <= 0;
+		INSERT INTO clipboard_item_daily_stats(
+			owner, 
+			workspace, 
+			day, 
+			item_count, 
+			total_size
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			new.clipboard_item_time / 86400000, 
+			1, 
+			CASE WHEN substr(new.clipboard_item_data, 1, 5) = 'enc1:' THEN CAST(substr(new.clipboard_item_data, 15) AS INTEGER) ELSE ifnull(length(new.clipboard_item_data), 0) END 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, workspace, day) DO UPDATE SET 
+			item_count = item_count + 1, 
+			total_size = total_size + excluded.total_size;
+		INSERT INTO clipboard_item_hourly_stats(
+			owner, 
+			workspace, 
+			hour_of_week, 
+			item_count
+		) 
+		SELECT 
+			new.clipboard_item_owner, 
+			new.clipboard_item_workspace, 
+			(new.clipboard_item_time / 3600000 + 72) % 168, 
+			1 
+		WHERE new.clipboard_item_deleted_time = 0
+		ON CONFLICT(owner, workspace, hour_of_week) DO UPDATE SET 
+			item_count = item_count + 1;
+	END;
+`
+
+const dropReencryptTriggersQuery = `
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_au;
+	DROP TRIGGER IF EXISTS clipboard_items_revisions_ad;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ai;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_au;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_trash;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_restore;
+	DROP TRIGGER IF EXISTS clipboard_items_changes_ad;
+`
+
+const createFts5TriggerQueryVersion16 = `
+	CREATE TRIGGER clipboard_items_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+		
+	CREATE TRIGGER clipboard_items_ad AFTER DELETE ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+	END;
+`
+
+const createFts5UpdateTriggerQueryVersion16 = `
+	CREATE TRIGGER clipboard_items_au AFTER UPDATE OF clipboard_item_time, clipboard_item_text ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+`
+
+const createFts5TriggerQueryVersion17 = `
+	CREATE TRIGGER clipboard_items_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_secret OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+		
+	CREATE TRIGGER clipboard_items_ad AFTER DELETE ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_secret OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+	END;
+`
+
+const createFts5UpdateTriggerQueryVersion17 = `
+	CREATE TRIGGER clipboard_items_au AFTER UPDATE OF clipboard_item_time, clipboard_item_text, clipboard_item_secret ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old.clipboard_item_time, 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_secret OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new.clipboard_item_time, 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_secret OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+`
+
+const createBlindTokenTriggerQueryVersion16 = `
+	CREATE TRIGGER clipboard_items_blind_tokens_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_blind_tokens 
+		WHERE clipboard_item_time = old.clipboard_item_time;
+	END;
+`
+
+// audit_entries is append-only, entries are only ever inserted, and deleted
+// once they are past retention.
+const createAuditEntryTriggerQuery = `
+	CREATE TRIGGER audit_entries_append_only BEFORE UPDATE ON audit_entries
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_entries is append-only');
+	END;
+`
+
+const createFts5TableQuery = `
+	CREATE VIRTUAL TABLE clipboard_items_fts USING fts5(
+		clipboard_item_time, 
+		clipboard_item_text, 
+		content = clipboard_items, 
+		content_rowid = 'index'
+	);
+`
+
+const insertFts5TableQuery = `
+INSERT INTO clipboard_items_fts (
+	rowid, 
+	clipboard_item_text
+)
+SELECT 
+	clipboard_items.` + "`index`" + `, 
+	CASE WHEN substr(clipboard_items.clipboard_item_text, 1, 5) = 'enc1:' OR clipboard_items.clipboard_item_secret OR clipboard_items.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE clipboard_items.clipboard_item_text END 
+FROM clipboard_items;
+`
+
+const createFts5TriggerQuery = `
+	CREATE TRIGGER clipboard_items_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new."index", 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_secret OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+		
+	CREATE TRIGGER clipboard_items_ad AFTER DELETE ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old."index", 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_secret OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+	END;
+`
+
+const createFts5UpdateTriggerQuery = `
+	CREATE TRIGGER clipboard_items_au AFTER UPDATE OF clipboard_item_text, clipboard_item_secret ON clipboard_items BEGIN
+		INSERT INTO clipboard_items_fts(
+			clipboard_items_fts, 
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES(
+			"delete", 
+			old."index", 
+			CASE WHEN substr(old.clipboard_item_text, 1, 5) = 'enc1:' OR old.clipboard_item_secret OR old.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE old.clipboard_item_text END
+		);
+		INSERT INTO clipboard_items_fts(
+			rowid, 
+			clipboard_item_text
+		) 
+		VALUES (
+			new."index", 
+			CASE WHEN substr(new.clipboard_item_text, 1, 5) = 'enc1:' OR new.clipboard_item_secret OR new.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) THEN '' ELSE new.clipboard_item_text END
+		);
+	END;
+`
+
+const createRevisionTriggerQuery = `
+	CREATE TRIGGER clipboard_items_revisions_au AFTER UPDATE OF clipboard_item_text, clipboard_item_data ON clipboard_items 
+	WHEN old.clipboard_item_text IS NOT new.clipboard_item_text OR old.clipboard_item_data IS NOT new.clipboard_item_data 
+	BEGIN
+		INSERT INTO clipboard_item_revisions(
+			clipboard_item_index, 
+			clipboard_item_time, 
+			clipboard_item_revision, 
+			clipboard_item_text, 
+			clipboard_item_hash, 
+			clipboard_item_data, 
+			clipboard_item_replaced_time
+		) 
+		VALUES (
+			old."index", 
+			old.clipboard_item_time, 
+			old.clipboard_item_revision, 
+			old.clipboard_item_text, 
+			old.clipboard_item_hash, 
+			old.clipboard_item_data, 
+			CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)
+		);
+	END;
+
+	CREATE TRIGGER clipboard_items_revisions_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_revisions 
+		WHERE clipboard_item_index = old."index";
+	END;
+`
+
+const createChangeTriggerQuery = `
+	CREATE TRIGGER clipboard_items_changes_ai AFTER INSERT ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_owner = new.clipboard_item_owner AND clipboard_item_workspace = new.clipboard_item_workspace AND clipboard_item_time = new.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'created', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_au AFTER UPDATE ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE (clipboard_item_owner = old.clipboard_item_owner AND clipboard_item_workspace = old.clipboard_item_workspace AND clipboard_item_time = old.clipboard_item_time) OR (clipboard_item_owner = new.clipboard_item_owner AND clipboard_item_workspace = new.clipboard_item_workspace AND clipboard_item_time = new.clipboard_item_time);
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'updated', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_trash AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time = 0 AND new.clipboard_item_deleted_time != 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE (clipboard_item_owner = old.clipboard_item_owner AND clipboard_item_workspace = old.clipboard_item_workspace AND clipboard_item_time = old.clipboard_item_time) OR (clipboard_item_owner = new.clipboard_item_owner AND clipboard_item_workspace = new.clipboard_item_workspace AND clipboard_item_time = new.clipboard_item_time);
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'deleted', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_restore AFTER UPDATE OF clipboard_item_deleted_time ON clipboard_items 
+	WHEN old.clipboard_item_deleted_time != 0 AND new.clipboard_item_deleted_time = 0 
+	BEGIN
+		DELETE FROM clipboard_item_changes WHERE (clipboard_item_owner = old.clipboard_item_owner AND clipboard_item_workspace = old.clipboard_item_workspace AND clipboard_item_time = old.clipboard_item_time) OR (clipboard_item_owner = new.clipboard_item_owner AND clipboard_item_workspace = new.clipboard_item_workspace AND clipboard_item_time = new.clipboard_item_time);
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (new.clipboard_item_time, 'restored', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), new.clipboard_item_owner, new.clipboard_item_workspace);
+	END;
+
+	CREATE TRIGGER clipboard_items_changes_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_changes WHERE clipboard_item_owner = old.clipboard_item_owner AND clipboard_item_workspace = old.clipboard_item_workspace AND clipboard_item_time = old.clipboard_item_time;
+		INSERT INTO clipboard_item_changes(clipboard_item_time, clipboard_item_change_type, clipboard_item_change_time, clipboard_item_owner, clipboard_item_workspace) 
+		VALUES (old.clipboard_item_time, 'purged', CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), old.clipboard_item_owner, old.clipboard_item_workspace);
+	END;
+`
+
+// Changes and revisions were keyed by the time of their ClipboardItem alone,
+// which two Users or Workspaces may share. Revisions take the index of their
+// ClipboardItem and pushes its Workspace, those of a time shared by several
+// ClipboardItems cannot be told apart and are set aside in tables of their
+// own. Change rows become unique per owner and Workspace.
+const rekeyTablesQueryVersion19 = `
+	UPDATE clipboard_item_revisions SET clipboard_item_index = COALESCE((
+		SELECT min(` + "`index`" + `) FROM clipboard_items 
+		WHERE clipboard_items.clipboard_item_time = clipboard_item_revisions.clipboard_item_time 
+		HAVING count(*) = 1
+	), 0);
+	CREATE TABLE unattributed_clipboard_item_revisions AS 
+	SELECT * FROM clipboard_item_revisions WHERE clipboard_item_index = 0;
+	DELETE FROM clipboard_item_revisions WHERE clipboard_item_index = 0;
+	DROP INDEX IF EXISTS idx_clipboard_item_revision;
+
+	CREATE TABLE unattributed_device_pushes AS 
+	SELECT * FROM device_pushes WHERE (
+		SELECT count(*) FROM clipboard_items 
+		WHERE clipboard_items.clipboard_item_owner = device_pushes.device_push_owner AND clipboard_items.clipboard_item_time = device_pushes.clipboard_item_time
+	) != 1;
+	DELETE FROM device_pushes WHERE (
+		SELECT count(*) FROM clipboard_items 
+		WHERE clipboard_items.clipboard_item_owner = device_pushes.device_push_owner AND clipboard_items.clipboard_item_time = device_pushes.clipboard_item_time
+	) != 1;
+	UPDATE device_pushes SET device_push_workspace = (
+		SELECT clipboard_item_workspace FROM clipboard_items 
+		WHERE clipboard_items.clipboard_item_owner = device_pushes.device_push_owner AND clipboard_items.clipboard_item_time = device_pushes.clipboard_item_time
+	);
+
+	DROP INDEX IF EXISTS idx_clipboard_item_changes_clipboard_item_time;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_clipboard_item_changes_owner_workspace_time ON clipboard_item_changes(clipboard_item_owner, clipboard_item_workspace, clipboard_item_time);
+
+	DROP TABLE IF EXISTS clipboard_items_fts;
+`
+
+const dropBlindTokenTriggerQuery = `
+	DROP TRIGGER IF EXISTS clipboard_items_blind_tokens_ad;
+`
+
+const createBlindTokenTriggerQuery = `
+	CREATE TRIGGER clipboard_items_blind_tokens_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_item_blind_tokens 
+		WHERE clipboard_item_index = old."index";
+	END;
+`
+
+// The index SQLite gives the next ClipboardItem, AUTOINCREMENT never reuses
+// the index of a deleted one.
+const nextClipboardItemIndexQuery = `
+SELECT max(
+	ifnull((SELECT seq FROM sqlite_sequence WHERE name = 'clipboard_items'), 0), 
+	ifnull((SELECT max("index") FROM clipboard_items), 0)
+) + 1
+`
+
+// Blind tokens were keyed by the time of their ClipboardItem alone and take
+// its index. Only ClipboardItems of end-to-end encrypted Workspaces have
+// them, those of a time shared by several cannot be told apart and are set
+// aside in a table of their own.
+const renameTablesQueryVersion20 = `
+	DROP INDEX IF EXISTS idx_clipboard_item_blind_tokens_blind_token;
+	ALTER TABLE clipboard_item_blind_tokens RENAME TO clipboard_item_blind_tokens_version20;
+`
+
+const copyTablesQueryVersion20 = `
+	INSERT INTO clipboard_item_blind_tokens(clipboard_item_index, blind_token) 
+	SELECT (
+		SELECT min(` + "`index`" + `) FROM clipboard_items 
+		WHERE clipboard_items.clipboard_item_time = clipboard_item_blind_tokens_version20.clipboard_item_time 
+		AND clipboard_items.clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) 
+		HAVING count(*) = 1
+	) AS clipboard_item_index, blind_token 
+	FROM clipboard_item_blind_tokens_version20 
+	WHERE clipboard_item_index IS NOT NULL;
+	DELETE FROM clipboard_item_blind_tokens_version20 WHERE clipboard_item_time IN (
+		SELECT clipboard_item_time FROM clipboard_items 
+		WHERE clipboard_item_workspace IN (SELECT "index" FROM workspaces WHERE workspace_end_to_end) 
+		GROUP BY clipboard_item_time HAVING count(*) = 1
+	);
+	ALTER TABLE clipboard_item_blind_tokens_version20 RENAME TO unattributed_clipboard_item_blind_tokens;
+`
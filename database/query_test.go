@@ -0,0 +1,117 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisionTrigger(t *testing.T) {
+	var revisions []ClipboardItemRevision
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	item := ClipboardItem{
+		ClipboardItemTime: 1,
+		ClipboardItemText: "before",
+		ClipboardItemHash: "a",
+		ClipboardItemData: "YQ==",
+	}
+	Orm.Create(&item)
+	Orm.Model(&item).Updates(ClipboardItem{ClipboardItemText: "after", ClipboardItemRevision: 2})
+	Orm.Model(&item).Updates(ClipboardItem{ClipboardItemRevision: 3})
+
+	Orm.Find(&revisions)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, int64(1), revisions[0].ClipboardItemTime)
+	assert.Equal(t, int64(1), revisions[0].ClipboardItemRevision)
+	assert.Equal(t, "before", revisions[0].ClipboardItemText)
+	assert.True(t, revisions[0].ClipboardItemReplacedTime > 0)
+
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "before").Count(&count)
+	assert.Equal(t, int64(0), count)
+	Orm.Table("clipboard_items_fts").Where("clipboard_items_fts MATCH ?", "after").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	Orm.Delete(&item)
+	Orm.Model(&ClipboardItemRevision{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	Close()
+}
+
+func TestStatsTriggerTrash(t *testing.T) {
+	var dailyStats []ClipboardItemDailyStat
+	Open("file::memory:?cache=shared")
+
+	item := ClipboardItem{
+		ClipboardItemTime: 1,
+		ClipboardItemHash: "a",
+		ClipboardItemData: "YQ==",
+	}
+	Orm.Create(&item)
+	Orm.Model(&item).Update("clipboard_item_deleted_time", 10)
+
+	Orm.Find(&dailyStats)
+	assert.Len(t, dailyStats, 0)
+
+	Orm.Model(&item).Update("clipboard_item_deleted_time", 0)
+
+	Orm.Find(&dailyStats)
+	assert.Len(t, dailyStats, 1)
+	assert.Equal(t, int64(1), dailyStats[0].ItemCount)
+	assert.Equal(t, int64(4), dailyStats[0].TotalSize)
+
+	Orm.Model(&item).Update("clipboard_item_deleted_time", 10)
+	Orm.Delete(&item)
+
+	Orm.Find(&dailyStats)
+	assert.Len(t, dailyStats, 0)
+
+	Close()
+}
+
+func TestChangeTrigger(t *testing.T) {
+	var changes []ClipboardItemChange
+	Open("file::memory:?cache=shared")
+
+	item := ClipboardItem{
+		ClipboardItemTime: 1,
+		ClipboardItemHash: "a",
+		ClipboardItemData: "YQ==",
+	}
+	Orm.Create(&item)
+	item2 := ClipboardItem{
+		ClipboardItemTime: 2,
+		ClipboardItemHash: "b",
+		ClipboardItemData: "Yg==",
+	}
+	Orm.Create(&item2)
+
+	Orm.Order("clipboard_item_change_seq").Find(&changes)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "created", changes[1].ClipboardItemChangeType)
+	assert.Equal(t, int64(2), changes[1].ClipboardItemChangeSeq)
+
+	Orm.Model(&item).Update("clipboard_item_text", "a")
+	Orm.Order("clipboard_item_change_seq").Find(&changes)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, int64(1), changes[1].ClipboardItemTime)
+	assert.Equal(t, "updated", changes[1].ClipboardItemChangeType)
+	assert.Equal(t, int64(3), changes[1].ClipboardItemChangeSeq)
+
+	Orm.Model(&item).Update("clipboard_item_deleted_time", 10)
+	Orm.Last(&changes)
+	assert.Equal(t, "deleted", changes[0].ClipboardItemChangeType)
+
+	Orm.Model(&item).Update("clipboard_item_deleted_time", 0)
+	Orm.Last(&changes)
+	assert.Equal(t, "restored", changes[0].ClipboardItemChangeType)
+
+	Orm.Delete(&item)
+	Orm.Last(&changes)
+	assert.Equal(t, "purged", changes[0].ClipboardItemChangeType)
+	assert.Equal(t, int64(6), changes[0].ClipboardItemChangeSeq)
+
+	Close()
+}
@@ -1,10 +1,60 @@
 package database
 
+// OpenNoDatabase connects without migrating, only the empty users table is
+// created so requests get past authentication.
 func OpenNoDatabase() {
 	connectDatabase("file::memory:?cache=shared")
+	Orm.Migrator().CreateTable(&User{})
 }
 
 func createVersion0Database() {
 	Orm.Exec(CreateClipboardItemsTableQuery)
 	Orm.Exec(CreateConfigsTableQuery)
 }
+
+// createVersion1Database, createVersion10Database, createVersion19Database and
+// createVersion20Database stop the migrations of a version 0 database there, like an archive left at
+// that version.
+func createVersion1Database() {
+	createVersion0Database()
+	migrateVersion0To1()
+}
+
+func createVersion10Database() {
+	createVersion1Database()
+	for _, migrate := range []func(){
+		migrateVersion1To2,
+		migrateVersion2To3,
+		migrateVersion3To4,
+		migrateVersion4To5,
+		migrateVersion5To6,
+		migrateVersion6To7,
+		migrateVersion7To8,
+		migrateVersion8To9,
+		migrateVersion9To10,
+	} {
+		migrate()
+	}
+}
+
+func createVersion19Database() {
+	createVersion10Database()
+	for _, migrate := range []func(){
+		migrateVersion10To11,
+		migrateVersion11To12,
+		migrateVersion12To13,
+		migrateVersion13To14,
+		migrateVersion14To15,
+		migrateVersion15To16,
+		migrateVersion16To17,
+		migrateVersion17To18,
+		migrateVersion18To19,
+	} {
+		migrate()
+	}
+}
+
+func createVersion20Database() {
+	createVersion19Database()
+	migrateVersion19To20()
+}
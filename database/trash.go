@@ -0,0 +1,43 @@
+package database
+
+import "time"
+
+// PurgeTrash permanently deletes the ClipboardItems that have been in trash
+// for longer than the retention of their Workspace at the unix milliseconds
+// timestamp now. retention applies to Workspaces without their own, 0
+// keeps their trash.
+func PurgeTrash(now int64, retention time.Duration) (int64, error) {
+	var purged int64
+
+	workspaces := []Workspace{}
+	err := Orm.Where("workspace_trash_retention != 0").Find(&workspaces).Error
+	if err != nil {
+		return 0, err
+	}
+
+	own := []int64{}
+	for _, workspace := range workspaces {
+		own = append(own, workspace.Index)
+		if workspace.WorkspaceTrashRetention < 0 {
+			continue
+		}
+		tx := Orm.
+			Where("clipboard_item_workspace = ?", workspace.Index).
+			Where("clipboard_item_deleted_time != 0 AND clipboard_item_deleted_time <= ?", now-workspace.WorkspaceTrashRetention).
+			Delete(&ClipboardItem{})
+		if tx.Error != nil {
+			return purged, tx.Error
+		}
+		purged += tx.RowsAffected
+	}
+
+	if retention <= 0 {
+		return purged, nil
+	}
+	query := Orm.Where("clipboard_item_deleted_time != 0 AND clipboard_item_deleted_time <= ?", now-retention.Milliseconds())
+	if len(own) > 0 {
+		query = query.Where("clipboard_item_workspace NOT IN ?", own)
+	}
+	tx := query.Delete(&ClipboardItem{})
+	return purged + tx.RowsAffected, tx.Error
+}
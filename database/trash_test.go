@@ -0,0 +1,50 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	var count int64
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "b", ClipboardItemDeletedTime: 10})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "c", ClipboardItemDeletedTime: 20})
+
+	purged, err := PurgeTrash(25, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	Orm.Model(&ClipboardItem{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	Close()
+}
+
+func TestPurgeTrashWorkspaceRetention(t *testing.T) {
+	var times []int64
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&Workspace{WorkspaceName: "short", WorkspaceTrashRetention: 5})
+	Orm.Create(&Workspace{WorkspaceName: "keep", WorkspaceTrashRetention: -1})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a", ClipboardItemDeletedTime: 10})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "a", ClipboardItemDeletedTime: 10, ClipboardItemWorkspace: 1})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "a", ClipboardItemDeletedTime: 10, ClipboardItemWorkspace: 2})
+
+	purged, err := PurgeTrash(20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	purged, err = PurgeTrash(20, 5*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	Orm.Model(&ClipboardItem{}).Pluck("clipboard_item_time", &times)
+	assert.Equal(t, []int64{3}, times)
+
+	Close()
+}
@@ -0,0 +1,52 @@
+package database
+
+import "os"
+
+// Usage is what the database holds and how much space it takes.
+type Usage struct {
+	DatabaseBytes         int64 // pages in use times the page size
+	WALBytes              int64 // write-ahead log file, 0 without one
+	ClipboardItems        int64 // not in trash
+	TrashedClipboardItems int64
+}
+
+// GetUsage measures the database. In-memory databases have no WAL file.
+func GetUsage() (Usage, error) {
+	var usage Usage
+	var pageCount, pageSize int64
+	var files []struct {
+		Name string
+		File string
+	}
+
+	err := Orm.Raw("PRAGMA page_count").Scan(&pageCount).Error
+	if err != nil {
+		return usage, err
+	}
+	err = Orm.Raw("PRAGMA page_size").Scan(&pageSize).Error
+	if err != nil {
+		return usage, err
+	}
+	usage.DatabaseBytes = pageCount * pageSize
+
+	err = Orm.Raw("PRAGMA database_list").Scan(&files).Error
+	if err != nil {
+		return usage, err
+	}
+	for _, file := range files {
+		if file.Name != "main" || file.File == "" {
+			continue
+		}
+		info, err := os.Stat(file.File + "-wal")
+		if err == nil {
+			usage.WALBytes = info.Size()
+		}
+	}
+
+	err = Orm.Model(&ClipboardItem{}).Where("clipboard_item_deleted_time = 0").Count(&usage.ClipboardItems).Error
+	if err != nil {
+		return usage, err
+	}
+	err = Orm.Model(&ClipboardItem{}).Where("clipboard_item_deleted_time != 0").Count(&usage.TrashedClipboardItems).Error
+	return usage, err
+}
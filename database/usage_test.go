@@ -0,0 +1,38 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUsage(t *testing.T) {
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "b"})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "c", ClipboardItemDeletedTime: 5})
+
+	usage, err := GetUsage()
+	assert.NoError(t, err)
+	assert.Greater(t, usage.DatabaseBytes, int64(0))
+	assert.Equal(t, int64(0), usage.WALBytes)
+	assert.Equal(t, int64(2), usage.ClipboardItems)
+	assert.Equal(t, int64(1), usage.TrashedClipboardItems)
+
+	Close()
+}
+
+func TestGetUsageWAL(t *testing.T) {
+	Open(filepath.Join(t.TempDir(), "clipboard_archive.db"))
+	Orm.Exec("PRAGMA journal_mode = WAL")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"})
+
+	usage, err := GetUsage()
+	assert.NoError(t, err)
+	assert.Greater(t, usage.WALBytes, int64(0))
+
+	Close()
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// HashUserToken is how API tokens are stored, so a leaked database does
+// not leak them.
+func HashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUser adds a User and returns its API token, which is not stored.
+func CreateUser(name string) (User, string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return User{}, "", err
+	}
+	token := hex.EncodeToString(b)
+
+	user := User{
+		UserName:        name,
+		UserTokenHash:   HashUserToken(token),
+		UserCreatedTime: utils.GetUnixMillisTimestamp(),
+	}
+	err = Orm.Create(&user).Error
+	if err != nil {
+		return User{}, "", err
+	}
+	return user, token, nil
+}
+
+// FindUser looks a User up by name.
+func FindUser(name string) (User, error) {
+	var user User
+	err := Orm.Where("user_name = ?", name).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// SetUserDisabled disables or enables a User, disabled Users are refused.
+func SetUserDisabled(name string, disabled bool) error {
+	tx := Orm.Model(&User{}).Where("user_name = ?", name).Update("user_disabled", disabled)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetUserAdmin grants or revokes the right to edit server wide settings.
+func SetUserAdmin(name string, admin bool) error {
+	tx := Orm.Model(&User{}).Where("user_name = ?", name).Update("user_admin", admin)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// AdoptOwnerless gives everything stored before accounts existed to owner.
+func AdoptOwnerless(owner int64) error {
+	return Orm.Transaction(func(tx *gorm.DB) error {
+		updates := []struct {
+			model  interface{}
+			column string
+		}{
+			{&ClipboardItem{}, "clipboard_item_owner"},
+			{&ClipboardItemRecopy{}, "owner"},
+			{&Webhook{}, "webhook_owner"},
+			{&Device{}, "device_owner"},
+			{&DevicePush{}, "device_push_owner"},
+			{&Workspace{}, "workspace_owner"},
+		}
+		for _, u := range updates {
+			err := tx.Model(u.model).Where(u.column+" = 0").Update(u.column, owner).Error
+			if err != nil {
+				return err
+			}
+		}
+		// Changes of live ClipboardItems were rewritten by the triggers,
+		// this catches the tombstones.
+		return tx.Model(&ClipboardItemChange{}).
+			Where("clipboard_item_owner = 0").
+			Update("clipboard_item_owner", owner).Error
+	})
+}
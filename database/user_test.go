@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateUser(t *testing.T) {
+	var user User
+	Open("file::memory:?cache=shared")
+
+	created, token, err := CreateUser("alice")
+	assert.NoError(t, err)
+	assert.Len(t, token, 64)
+
+	Orm.First(&user, "user_token_hash = ?", HashUserToken(token))
+	assert.Equal(t, created.Index, user.Index)
+	assert.False(t, user.UserDisabled)
+
+	_, _, err = CreateUser("alice")
+	assert.Error(t, err)
+
+	assert.NoError(t, SetUserDisabled("alice", true))
+	Orm.First(&user, created.Index)
+	assert.True(t, user.UserDisabled)
+	assert.ErrorIs(t, SetUserDisabled("bob", true), ErrUserNotFound)
+
+	assert.NoError(t, SetUserAdmin("alice", true))
+	Orm.First(&user, created.Index)
+	assert.True(t, user.UserAdmin)
+	assert.ErrorIs(t, SetUserAdmin("bob", true), ErrUserNotFound)
+
+	Close()
+}
+
+func TestClipboardItemHashUniquePerOwner(t *testing.T) {
+	Open("file::memory:?cache=shared")
+
+	assert.NoError(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"}).Error)
+	assert.NoError(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "a", ClipboardItemOwner: 1}).Error)
+	assert.Error(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "a", ClipboardItemOwner: 1}).Error)
+
+	Close()
+}
+
+func TestAdoptOwnerless(t *testing.T) {
+	var count int64
+	var stat ClipboardItemDailyStat
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "b", ClipboardItemDeletedTime: 10})
+	Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "c"})
+	Orm.Delete(&ClipboardItem{}, "clipboard_item_time = 3")
+	Orm.Create(&Webhook{WebhookURL: "http://127.0.0.1/"})
+
+	assert.NoError(t, AdoptOwnerless(1))
+
+	Orm.Model(&ClipboardItem{}).Where("clipboard_item_owner = 1").Count(&count)
+	assert.Equal(t, int64(2), count)
+	Orm.Model(&ClipboardItemChange{}).Where("clipboard_item_owner = 1").Count(&count)
+	assert.Equal(t, int64(3), count)
+	Orm.Model(&Webhook{}).Where("webhook_owner = 1").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	Orm.Model(&ClipboardItemDailyStat{}).Where("owner = 0").Count(&count)
+	assert.Equal(t, int64(0), count)
+	Orm.First(&stat, "owner = 1")
+	assert.Equal(t, int64(1), stat.ItemCount)
+
+	Close()
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// FindWorkspace looks a Workspace of owner up by name.
+func FindWorkspace(owner int64, name string) (Workspace, error) {
+	var workspace Workspace
+	err := Orm.Where("workspace_owner = ? AND workspace_name = ?", owner, name).First(&workspace).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return workspace, ErrWorkspaceNotFound
+	}
+	return workspace, err
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindWorkspace(t *testing.T) {
+	Open("file::memory:?cache=shared")
+
+	Orm.Create(&Workspace{WorkspaceOwner: 1, WorkspaceName: "work"})
+
+	workspace, err := FindWorkspace(1, "work")
+	assert.NoError(t, err)
+	assert.Equal(t, "work", workspace.WorkspaceName)
+
+	_, err = FindWorkspace(2, "work")
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+
+	Close()
+}
+
+func TestClipboardItemHashUniquePerWorkspace(t *testing.T) {
+	var stats []ClipboardItemDailyStat
+	Open("file::memory:?cache=shared")
+
+	assert.NoError(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 1, ClipboardItemHash: "a"}).Error)
+	assert.NoError(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 2, ClipboardItemHash: "a", ClipboardItemWorkspace: 1}).Error)
+	assert.Error(t, Orm.Create(&ClipboardItem{ClipboardItemTime: 3, ClipboardItemHash: "a", ClipboardItemWorkspace: 1}).Error)
+
+	Orm.Order("workspace").Find(&stats)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, int64(1), stats[1].Workspace)
+
+	Close()
+}
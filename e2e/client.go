@@ -0,0 +1,168 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrExists is returned by Insert for data the Workspace already holds,
+// the server counts it as copied again.
+var ErrExists = errors.New("ClipboardItem already exists")
+
+// Item is a ClipboardItem in plaintext, as only clients see it.
+type Item struct {
+	Time   int64  // unix milliseconds timestamp
+	Text   string // searchable text
+	Data   string // base64 encoded data, the CopyQ pack
+	Device string
+}
+
+type sealedItem struct {
+	ClipboardItemTime        int64    `json:"ClipboardItemTime"`
+	ClipboardItemText        string   `json:"ClipboardItemText"`
+	ClipboardItemHash        string   `json:"ClipboardItemHash,omitempty"`
+	ClipboardItemData        string   `json:"ClipboardItemData"`
+	ClipboardItemDevice      string   `json:"ClipboardItemDevice,omitempty"`
+	ClipboardItemBlindTokens []string `json:"ClipboardItemBlindTokens,omitempty"`
+}
+
+type listResponse struct {
+	ClipboardItem []sealedItem `json:"ClipboardItem"`
+}
+
+// Client talks to an end-to-end encrypted Workspace of an archive. Token is
+// the API token, empty if the archive has no accounts.
+type Client struct {
+	URL       string
+	Token     string
+	Workspace string
+	Keys      *Keys
+	HTTP      *http.Client
+}
+
+func (client *Client) request(method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(client.URL, "/")+"/api/v1/workspaces/"+url.PathEscape(client.Workspace)+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.Token)
+	}
+	httpClient := client.HTTP
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+	return httpClient.Do(req)
+}
+
+// seal encrypts item into what is uploaded.
+func (keys *Keys) seal(item Item) (sealedItem, error) {
+	text, err := keys.Encrypt(item.Text)
+	if err != nil {
+		return sealedItem{}, err
+	}
+	data, err := keys.Encrypt(item.Data)
+	if err != nil {
+		return sealedItem{}, err
+	}
+	return sealedItem{
+		ClipboardItemTime:        item.Time,
+		ClipboardItemText:        text,
+		ClipboardItemHash:        keys.Hash(item.Data),
+		ClipboardItemData:        data,
+		ClipboardItemDevice:      item.Device,
+		ClipboardItemBlindTokens: keys.BlindTokens(item.Text),
+	}, nil
+}
+
+func (keys *Keys) open(sealed sealedItem) (Item, error) {
+	text, err := keys.Decrypt(sealed.ClipboardItemText)
+	if err != nil {
+		return Item{}, err
+	}
+	data, err := keys.Decrypt(sealed.ClipboardItemData)
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{Time: sealed.ClipboardItemTime, Text: text, Data: data, Device: sealed.ClipboardItemDevice}, nil
+}
+
+// Insert encrypts and uploads item.
+func (client *Client) Insert(item Item) error {
+	sealed, err := client.Keys.seal(item)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	resp, err := client.request(http.MethodPost, "/ClipboardItem", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		return ErrExists
+	}
+	return responseError(resp)
+}
+
+// Search returns the newest ClipboardItems containing every term of query,
+// decrypted. An empty query matches every ClipboardItem, limit 0 leaves the
+// server default.
+func (client *Client) Search(query string, limit int) ([]Item, error) {
+	values := url.Values{}
+	if query != "" {
+		search := client.Keys.SearchQuery(query)
+		if search == "" {
+			return []Item{}, nil
+		}
+		values.Set("search", search)
+	}
+	if limit > 0 {
+		values.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := client.request(http.MethodGet, "/ClipboardItem?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var list listResponse
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	for _, sealed := range list.ClipboardItem {
+		item, err := client.Keys.open(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("ClipboardItem %d: %w", sealed.ClipboardItemTime, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	return fmt.Errorf("%s: %s", resp.Status, body)
+}
@@ -0,0 +1,142 @@
+// Package e2e encrypts ClipboardItems on the client for end-to-end
+// encrypted Workspaces. The server only ever sees ciphertext, a keyed hash
+// of the data to deduplicate by and keyed hashes of the search terms, the
+// blind tokens.
+package e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxBlindTokens is the most blind tokens the server takes per
+// ClipboardItem, terms past it are not searchable.
+const MaxBlindTokens = 1024
+
+// Keys are derived from one key, which never leaves the clients.
+type Keys struct {
+	aead  cipher.AEAD
+	index []byte
+}
+
+// GenerateKey returns a new base64 encoded key.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// NewKeys derives the encryption and the index key from a base64 encoded
+// 32 byte key.
+func NewKeys(key string) (*Keys, error) {
+	master, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(master) != 32 {
+		return nil, fmt.Errorf("key is %d bytes, want 32", len(master))
+	}
+
+	block, err := aes.NewCipher(derive(master, "clipboard_archive e2e encryption"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{aead: aead, index: derive(master, "clipboard_archive e2e index")}, nil
+}
+
+func derive(key []byte, label string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func (keys *Keys) mac(kind string, s string) []byte {
+	mac := hmac.New(sha256.New, keys.index)
+	mac.Write([]byte(kind + "\x00" + s))
+	return mac.Sum(nil)
+}
+
+// Encrypt seals s with AES-256-GCM, base64 encoded so it passes for
+// ClipboardItemData.
+func (keys *Keys) Encrypt(s string) (string, error) {
+	nonce := make([]byte, keys.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(keys.aead.Seal(nonce, nonce, []byte(s), nil)), nil
+}
+
+// Decrypt opens what Encrypt sealed.
+func (keys *Keys) Decrypt(s string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < keys.aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plaintext, err := keys.aead.Open(nil, sealed[:keys.aead.NonceSize()], sealed[keys.aead.NonceSize():], nil)
+	return string(plaintext), err
+}
+
+// Hash is the ClipboardItemHash of data, equal data hashes equally so the
+// server can still tell copies apart from new ClipboardItems.
+func (keys *Keys) Hash(data string) string {
+	return hex.EncodeToString(keys.mac("hash", data))
+}
+
+// BlindToken is the blind token of a search term.
+func (keys *Keys) BlindToken(term string) string {
+	return base64.RawURLEncoding.EncodeToString(keys.mac("term", strings.ToLower(term))[:16])
+}
+
+// BlindTokens are the blind tokens of every term in text.
+func (keys *Keys) BlindTokens(text string) []string {
+	tokens := []string{}
+	for _, term := range Terms(text) {
+		if len(tokens) == MaxBlindTokens {
+			break
+		}
+		tokens = append(tokens, keys.BlindToken(term))
+	}
+	return tokens
+}
+
+// SearchQuery turns a query into the search the server understands,
+// ClipboardItems have to contain every term.
+func (keys *Keys) SearchQuery(query string) string {
+	return strings.Join(keys.BlindTokens(query), " ")
+}
+
+// Terms splits text into lower case words, each once.
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/route"
+)
+
+func testKeys(t *testing.T) *Keys {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	keys, err := NewKeys(key)
+	assert.NoError(t, err)
+	return keys
+}
+
+func TestNewKeys(t *testing.T) {
+	_, err := NewKeys("YQ==")
+	assert.Error(t, err)
+	_, err = NewKeys("!")
+	assert.Error(t, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	keys := testKeys(t)
+
+	sealed, err := keys.Encrypt("secret")
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "secret")
+	other, _ := keys.Encrypt("secret")
+	assert.NotEqual(t, sealed, other)
+
+	plaintext, err := keys.Decrypt(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", plaintext)
+
+	_, err = testKeys(t).Decrypt(sealed)
+	assert.Error(t, err)
+}
+
+func TestBlindTokens(t *testing.T) {
+	keys := testKeys(t)
+
+	assert.Equal(t, []string{"hello", "world"}, Terms("Hello, world! hello"))
+	assert.Equal(t, keys.BlindToken("hello"), keys.BlindToken("HELLO"))
+	assert.NotEqual(t, keys.BlindToken("hello"), testKeys(t).BlindToken("hello"))
+	assert.Len(t, keys.BlindTokens("Hello, world! hello"), 2)
+	assert.Equal(t, keys.Hash("a"), keys.Hash("a"))
+	assert.NotEqual(t, keys.Hash("a"), keys.Hash("b"))
+}
+
+func TestClient(t *testing.T) {
+	var raw string
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	server := httptest.NewServer(route.SetupRouter())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/workspaces", "application/json", strings.NewReader(`{"WorkspaceName": "secret", "WorkspaceEndToEnd": true}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	client := &Client{URL: server.URL, Workspace: "secret", Keys: testKeys(t)}
+	data := base64.StdEncoding.EncodeToString([]byte("the launch code"))
+	assert.NoError(t, client.Insert(Item{Time: 1, Text: "The launch code", Data: data}))
+	assert.NoError(t, client.Insert(Item{Time: 2, Text: "lunch menu", Data: base64.StdEncoding.EncodeToString([]byte("lunch menu"))}))
+	assert.ErrorIs(t, client.Insert(Item{Time: 3, Text: "The launch code", Data: data}), ErrExists)
+
+	items, err := client.Search("LAUNCH code", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{Time: 1, Text: "The launch code", Data: data}}, items)
+
+	items, err = client.Search("launch menu", 0)
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+
+	items, err = client.Search("", 10)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	database.Orm.Raw("SELECT clipboard_item_text || clipboard_item_data FROM clipboard_items WHERE clipboard_item_time = 1").Row().Scan(&raw)
+	assert.NotEmpty(t, raw)
+	assert.NotContains(t, raw, "launch")
+	assert.NotContains(t, raw, data)
+
+	other := &Client{URL: server.URL, Workspace: "secret", Keys: testKeys(t)}
+	items, err = other.Search("launch", 0)
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+
+	database.Close()
+}
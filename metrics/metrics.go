@@ -0,0 +1,200 @@
+// Package metrics keeps counters, gauges and histograms and writes them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are upper bounds in seconds suiting request latencies.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	bounds []float64 // histograms
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values  []string
+	value   float64   // counters and gauges
+	buckets []float64 // histograms, cumulative counts per bound
+	count   float64
+}
+
+func newMetric(name string, help string, kind string, labels []string) *metric {
+	return &metric{name: name, help: help, kind: kind, labels: labels, series: map[string]*series{}}
+}
+
+// get returns the series of values, the caller holds mu.
+func (m *metric) get(values []string, buckets int) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", m.name, len(m.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string{}, values...), buckets: make([]float64, buckets)}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Counter only goes up.
+type Counter struct{ m *metric }
+
+// Gauge is set to what it measures.
+type Gauge struct{ m *metric }
+
+// Histogram counts observations by the upper bounds they fall below.
+type Histogram struct{ m *metric }
+
+func (c Counter) Add(delta float64, values ...string) {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.get(values, 0).value += delta
+}
+
+func (c Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (g Gauge) Set(value float64, values ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.get(values, 0).value = value
+}
+
+func (h Histogram) Observe(value float64, values ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.get(values, len(h.m.bounds))
+	for i, bound := range h.m.bounds {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.value += value
+	s.count++
+}
+
+// Registry holds metrics in the order they were created.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) add(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+func (r *Registry) NewCounter(name string, help string, labels ...string) Counter {
+	return Counter{r.add(newMetric(name, help, "counter", labels))}
+}
+
+func (r *Registry) NewGauge(name string, help string, labels ...string) Gauge {
+	return Gauge{r.add(newMetric(name, help, "gauge", labels))}
+}
+
+// NewHistogram counts observations below bounds, which are sorted
+// ascending, and below +Inf.
+func (r *Registry) NewHistogram(name string, help string, bounds []float64, labels ...string) Histogram {
+	m := newMetric(name, help, "histogram", labels)
+	m.bounds = bounds
+	return Histogram{r.add(m)}
+}
+
+// WriteText writes every metric in the Prometheus text exposition format,
+// series sorted by their label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	r.mu.Lock()
+	metrics := append([]*metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		fmt.Fprintf(b, "# HELP %s %s\n", m.name, escape(m.help, false))
+		fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.kind)
+
+		m.mu.Lock()
+		keys := make([]string, 0, len(m.series))
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := m.series[key]
+			labels := labelPairs(m.labels, s.values)
+			if m.kind != "histogram" {
+				fmt.Fprintf(b, "%s%s %s\n", m.name, braced(labels), formatValue(s.value))
+				continue
+			}
+			for i, bound := range m.bounds {
+				le := append(append([]string{}, labels...), `le="`+formatValue(bound)+`"`)
+				fmt.Fprintf(b, "%s_bucket%s %s\n", m.name, braced(le), formatValue(s.buckets[i]))
+			}
+			le := append(append([]string{}, labels...), `le="+Inf"`)
+			fmt.Fprintf(b, "%s_bucket%s %s\n", m.name, braced(le), formatValue(s.count))
+			fmt.Fprintf(b, "%s_sum%s %s\n", m.name, braced(labels), formatValue(s.value))
+			fmt.Fprintf(b, "%s_count%s %s\n", m.name, braced(labels), formatValue(s.count))
+		}
+		m.mu.Unlock()
+	}
+	return b.Flush()
+}
+
+func labelPairs(names []string, values []string) []string {
+	pairs := []string{}
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escape(values[i], true)+`"`)
+	}
+	return pairs
+}
+
+func braced(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape escapes backslashes and newlines, and in label values quotes.
+// Invalid UTF-8, which the format does not allow, is replaced.
+func escape(s string, quotes bool) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
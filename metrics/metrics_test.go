@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests served.", "route", "status")
+	size := r.NewGauge("size_bytes", "Size of the database.")
+	latency := r.NewHistogram("latency_seconds", "Time taken.", []float64{0.1, 1}, "route")
+
+	requests.Inc("/b", "200")
+	requests.Add(2, "/a", "200")
+	requests.Inc("/a \"quoted\"\n", "500")
+	size.Set(4096)
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+
+	var b strings.Builder
+	assert.NoError(t, r.WriteText(&b))
+	assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a \"quoted\"\n",status="500"} 1
+requests_total{route="/a",status="200"} 2
+requests_total{route="/b",status="200"} 1
+# HELP size_bytes Size of the database.
+# TYPE size_bytes gauge
+size_bytes 4096
+# HELP latency_seconds Time taken.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 2
+latency_seconds_sum{route="/a"} 0.55
+latency_seconds_count{route="/a"} 2
+`, b.String())
+}
+
+func TestLabelValues(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests served.", "route")
+
+	assert.Panics(t, func() { requests.Inc() })
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\nd`, escape("a\"b\\c\nd", true))
+	assert.Equal(t, `\\n is not \n`, escape(`\n is not `+"\n", true))
+	// Help text keeps its quotes.
+	assert.Equal(t, `say "hi"\\\n`, escape("say \"hi\"\\\n", false))
+	assert.Equal(t, "a\uFFFDb", escape("a\xffb", true))
+}
+
+func TestWriteTextParses(t *testing.T) {
+	values := []string{`"`, `\`, "\n", `\n`, `\"`, "a\"b\\c\nd", "} 1\n# TYPE x gauge", "caf\xe9", ""}
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests \"served\",\nby \\route.", "route")
+	latency := r.NewHistogram("latency_seconds", "Time taken.", []float64{0.1, 1}, "route", "status")
+	for i, value := range values {
+		requests.Add(float64(i+1), value)
+		latency.Observe(float64(i), value, "200")
+	}
+
+	var b strings.Builder
+	assert.NoError(t, r.WriteText(&b))
+	help, types, samples, err := parseText(b.String())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Requests \"served\",\nby \\route.", help["requests_total"])
+	assert.Equal(t, map[string]string{"requests_total": "counter", "latency_seconds": "histogram"}, types)
+	counts := map[string]float64{}
+	buckets := map[string][]float64{}
+	for _, s := range samples {
+		switch s.name {
+		case "requests_total":
+			counts[s.labels["route"]] = s.value
+		case "latency_seconds_bucket":
+			buckets[s.labels["route"]] = append(buckets[s.labels["route"]], s.value)
+		case "latency_seconds_count":
+			assert.Equal(t, s.value, buckets[s.labels["route"]][2])
+		}
+	}
+	for i, value := range values {
+		value = strings.ToValidUTF8(value, "\uFFFD")
+		assert.Equal(t, float64(i+1), counts[value], value)
+		assert.Len(t, buckets[value], 3, value)
+	}
+	assert.Len(t, counts, len(values))
+}
+
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*`)
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// parseText reads the text exposition format as strictly as Prometheus
+// does, without timestamps, and fails on anything it would not take.
+func parseText(text string) (map[string]string, map[string]string, []sample, error) {
+	help := map[string]string{}
+	types := map[string]string{}
+	samples := []sample{}
+	if !strings.HasSuffix(text, "\n") {
+		return nil, nil, nil, errors.New("no newline at the end")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if !utf8.ValidString(line) {
+			return nil, nil, nil, errors.New("invalid UTF-8: " + line)
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			parts := strings.SplitN(strings.TrimPrefix(line, "# HELP "), " ", 2)
+			text, err := unescape(parts[1], false)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			help[parts[0]] = text
+			continue
+		}
+		if strings.HasPrefix(line, "# TYPE ") {
+			parts := strings.Split(strings.TrimPrefix(line, "# TYPE "), " ")
+			if len(parts) != 2 || types[parts[0]] != "" {
+				return nil, nil, nil, errors.New("bad TYPE: " + line)
+			}
+			types[parts[0]] = parts[1]
+			continue
+		}
+
+		s := sample{name: metricName.FindString(line), labels: map[string]string{}}
+		if s.name == "" {
+			return nil, nil, nil, errors.New("no metric name: " + line)
+		}
+		family := s.name
+		for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+			if types[strings.TrimSuffix(s.name, suffix)] == "histogram" {
+				family = strings.TrimSuffix(s.name, suffix)
+			}
+		}
+		if types[family] == "" {
+			return nil, nil, nil, errors.New("sample before its TYPE: " + line)
+		}
+		rest := line[len(s.name):]
+		if strings.HasPrefix(rest, "{") {
+			rest = rest[1:]
+			for !strings.HasPrefix(rest, "}") {
+				name := labelName.FindString(rest)
+				rest = rest[len(name):]
+				if name == "" || !strings.HasPrefix(rest, `="`) {
+					return nil, nil, nil, errors.New("bad label: " + line)
+				}
+				rest = rest[2:]
+				end := 0
+				for end < len(rest) && rest[end] != '"' {
+					if rest[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(rest) {
+					return nil, nil, nil, errors.New("unterminated label value: " + line)
+				}
+				value, err := unescape(rest[:end], true)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if _, ok := s.labels[name]; ok {
+					return nil, nil, nil, errors.New("label given twice: " + line)
+				}
+				s.labels[name] = value
+				rest = strings.TrimPrefix(rest[end+1:], ",")
+			}
+			rest = rest[1:]
+		}
+		if !strings.HasPrefix(rest, " ") {
+			return nil, nil, nil, errors.New("no value: " + line)
+		}
+		value, err := strconv.ParseFloat(rest[1:], 64)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		s.value = value
+		samples = append(samples, s)
+	}
+	return help, types, samples, nil
+}
+
+// unescape undoes escape, other escapes are errors as they are to
+// Prometheus.
+func unescape(s string, quotes bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' && quotes {
+			return "", errors.New("unescaped quote: " + s)
+		}
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch {
+		case i < len(s) && s[i] == '\\':
+			b.WriteByte('\\')
+		case i < len(s) && s[i] == 'n':
+			b.WriteByte('\n')
+		case i < len(s) && s[i] == '"' && quotes:
+			b.WriteByte('"')
+		default:
+			return "", errors.New("bad escape: " + s)
+		}
+	}
+	return b.String(), nil
+}
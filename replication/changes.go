@@ -0,0 +1,82 @@
+package replication
+
+import (
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+// Changes reads up to limit changes in the Workspace of owner after since
+// from db, oldest first, with the current ClipboardItem of live ones.
+// Expired ClipboardItems are left out as if already purged. next is the new
+// high-water mark.
+func Changes(db *gorm.DB, owner int64, workspace int64, since int64, limit int) (changes []Change, next int64, more bool, err error) {
+	rows := []database.ClipboardItemChange{}
+	err = db.
+		Where("clipboard_item_owner = ? AND clipboard_item_workspace = ?", owner, workspace).
+		Where("clipboard_item_change_seq > ?", since).
+		Order("clipboard_item_change_seq").
+		Limit(limit + 1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, since, false, err
+	}
+
+	more = len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	var times []int64
+	for _, row := range rows {
+		switch row.ClipboardItemChangeType {
+		case ChangeCreated, ChangeUpdated, ChangeRestored:
+			times = append(times, row.ClipboardItemTime)
+		}
+	}
+
+	items := []database.ClipboardItem{}
+	if len(times) > 0 {
+		err = db.
+			Select("clipboard_items.*", database.ClipboardItemSizeQuery+" AS clipboard_item_size").
+			Where("clipboard_item_owner = ? AND clipboard_item_workspace = ?", owner, workspace).
+			Where("clipboard_item_deleted_time = 0 AND clipboard_item_time IN ?", times).
+			Where("(clipboard_item_expires_time = 0 OR clipboard_item_expires_time > ?)", utils.GetUnixMillisTimestamp()).
+			Find(&items).Error
+		if err != nil {
+			return nil, since, false, err
+		}
+	}
+	indexes := []int64{}
+	for _, item := range items {
+		indexes = append(indexes, item.Index)
+	}
+	tokens, err := database.BlindTokens(db, indexes)
+	if err != nil {
+		return nil, since, false, err
+	}
+	itemsByTime := map[int64]*database.ClipboardItem{}
+	for i := range items {
+		items[i].ClipboardItemBlindTokens = tokens[items[i].Index]
+		itemsByTime[items[i].ClipboardItemTime] = &items[i]
+	}
+
+	next = since
+	changes = []Change{}
+	for _, row := range rows {
+		next = row.ClipboardItemChangeSeq
+		item := itemsByTime[row.ClipboardItemTime]
+		if item == nil && row.ClipboardItemChangeType != ChangeDeleted && row.ClipboardItemChangeType != ChangePurged {
+			// Changed again since the rows were read, a later change follows.
+			continue
+		}
+		changes = append(changes, Change{
+			ClipboardItemChangeSeq:  row.ClipboardItemChangeSeq,
+			ClipboardItemTime:       row.ClipboardItemTime,
+			ClipboardItemChangeType: row.ClipboardItemChangeType,
+			ClipboardItemChangeTime: row.ClipboardItemChangeTime,
+			ClipboardItem:           item,
+		})
+	}
+	return changes, next, more, nil
+}
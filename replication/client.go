@@ -0,0 +1,219 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// BatchSize is how many changes are fetched or sent per request.
+var BatchSize = 100
+
+var client = &http.Client{Timeout: time.Minute}
+
+type changesResponse struct {
+	Next                int64    `json:"next"`
+	More                bool     `json:"more"`
+	ClipboardItemChange []Change `json:"ClipboardItemChange"`
+}
+
+type applyRequest struct {
+	ClipboardItemChange []Change `json:"ClipboardItemChange"`
+}
+
+type applyResponse struct {
+	Applied int `json:"applied"`
+}
+
+// Peer is an archive to sync with. Token is the API token of the User to
+// sync as there, empty if the peer has no accounts, and Workspace the name
+// of the Workspace to sync with there, empty for the default one.
+type Peer struct {
+	URL       string
+	Token     string
+	Workspace string
+}
+
+func (peer Peer) request(method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(peer.URL, "/")+"/api/v1"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if peer.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+peer.Token)
+	}
+	if peer.Workspace != "" {
+		req.Header.Set("X-Clipboard-Archive-Workspace", peer.Workspace)
+	}
+	return client.Do(req)
+}
+
+// cursorKey is the Config key remembering how far the Workspace of owner
+// has been synced with a peer.
+func cursorKey(direction string, owner int64, workspace int64, peer Peer) string {
+	key := "sync_" + direction + ":"
+	if owner != 0 {
+		key += strconv.FormatInt(owner, 10) + ":"
+	}
+	if workspace != 0 || peer.Workspace != "" {
+		key += strconv.FormatInt(workspace, 10) + ":" + peer.Workspace + ":"
+	}
+	return key + strings.TrimSuffix(peer.URL, "/")
+}
+
+func loadCursor(db *gorm.DB, key string) (int64, error) {
+	var config database.Config
+	err := db.Where("key = ?", key).Limit(1).Find(&config).Error
+	if err != nil || config.Key == "" {
+		return 0, err
+	}
+	return strconv.ParseInt(config.Value, 10, 64)
+}
+
+func saveCursor(db *gorm.DB, key string, cursor int64) error {
+	return db.Save(&database.Config{Key: key, Value: strconv.FormatInt(cursor, 10)}).Error
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Admit vets the ClipboardItem of a created, updated or restored change
+// pulled from a peer into the Workspace of owner and returns the error code
+// it fails with, empty if it may be applied. It may change the ClipboardItem,
+// such as recomputing its hash. Changes it fails are left out.
+var Admit func(db *gorm.DB, owner int64, workspace int64, item *database.ClipboardItem) (string, error)
+
+func admit(db *gorm.DB, owner int64, workspace int64, changes []Change) ([]Change, error) {
+	if Admit == nil {
+		return changes, nil
+	}
+	admitted := []Change{}
+	for _, change := range changes {
+		switch change.ClipboardItemChangeType {
+		case ChangeCreated, ChangeUpdated, ChangeRestored:
+			if change.ClipboardItem == nil {
+				break
+			}
+			code, err := Admit(db, owner, workspace, change.ClipboardItem)
+			if err != nil {
+				return nil, err
+			}
+			if code != "" {
+				continue
+			}
+		}
+		admitted = append(admitted, change)
+	}
+	return admitted, nil
+}
+
+// Pull applies the changes of peer since the last pull to the Workspace of
+// owner in db and returns how many changed something.
+func Pull(db *gorm.DB, owner int64, workspace int64, peer Peer) (int, error) {
+	key := cursorKey("pull", owner, workspace, peer)
+	since, err := loadCursor(db, key)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for {
+		query := url.Values{}
+		query.Set("since", strconv.FormatInt(since, 10))
+		query.Set("limit", strconv.Itoa(BatchSize))
+		resp, err := peer.request(http.MethodGet, "/changes?"+query.Encode(), nil)
+		if err != nil {
+			return count, err
+		}
+		var changes changesResponse
+		err = decodeResponse(resp, &changes)
+		if err != nil {
+			return count, err
+		}
+
+		admitted, err := admit(db, owner, workspace, changes.ClipboardItemChange)
+		if err != nil {
+			return count, err
+		}
+		applied, err := Apply(db, owner, workspace, admitted)
+		if err != nil {
+			return count, err
+		}
+		count += len(applied)
+
+		since = changes.Next
+		err = saveCursor(db, key, since)
+		if err != nil || !changes.More {
+			return count, err
+		}
+	}
+}
+
+// Push sends the changes of the Workspace of owner in db since the last push
+// to peer and returns how many changed something there.
+func Push(db *gorm.DB, owner int64, workspace int64, peer Peer) (int, error) {
+	key := cursorKey("push", owner, workspace, peer)
+	since, err := loadCursor(db, key)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for {
+		changes, next, more, err := Changes(db, owner, workspace, since, BatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		if len(changes) > 0 {
+			body, err := json.Marshal(applyRequest{ClipboardItemChange: changes})
+			if err != nil {
+				return count, err
+			}
+			resp, err := peer.request(http.MethodPost, "/sync/changes", bytes.NewReader(body))
+			if err != nil {
+				return count, err
+			}
+			var applied applyResponse
+			err = decodeResponse(resp, &applied)
+			if err != nil {
+				return count, err
+			}
+			count += applied.Applied
+		}
+
+		since = next
+		err = saveCursor(db, key, since)
+		if err != nil || !more {
+			return count, err
+		}
+	}
+}
+
+// Sync pulls from and then pushes to peer, so both converge.
+func Sync(db *gorm.DB, owner int64, workspace int64, peer Peer) (pulled int, pushed int, err error) {
+	pulled, err = Pull(db, owner, workspace, peer)
+	if err != nil {
+		return pulled, 0, err
+	}
+	pushed, err = Push(db, owner, workspace, peer)
+	return pulled, pushed, err
+}
@@ -0,0 +1,218 @@
+package replication
+
+import (
+	"errors"
+
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	ChangeCreated  = "created"
+	ChangeUpdated  = "updated"
+	ChangeDeleted  = "deleted"
+	ChangeRestored = "restored"
+	ChangePurged   = "purged"
+)
+
+// Change is one entry of the change feed, ClipboardItem is nil for
+// tombstones.
+type Change struct {
+	ClipboardItemChangeSeq  int64                   `json:"ClipboardItemChangeSeq"`
+	ClipboardItemTime       int64                   `json:"ClipboardItemTime"`
+	ClipboardItemChangeType string                  `json:"ClipboardItemChangeType"`
+	ClipboardItemChangeTime int64                   `json:"ClipboardItemChangeTime"`
+	ClipboardItem           *database.ClipboardItem `json:"ClipboardItem,omitempty"`
+}
+
+// Validate reports whether a change received from a peer can be applied.
+func (change Change) Validate() error {
+	switch change.ClipboardItemChangeType {
+	case ChangeCreated, ChangeUpdated, ChangeRestored:
+		if change.ClipboardItem == nil {
+			return errors.New(change.ClipboardItemChangeType + " change without ClipboardItem")
+		}
+		if change.ClipboardItem.ClipboardItemTime != change.ClipboardItemTime {
+			return errors.New("ClipboardItemTime does not match the change")
+		}
+	case ChangeDeleted, ChangePurged:
+	default:
+		return errors.New("unknown change type: " + change.ClipboardItemChangeType)
+	}
+	return nil
+}
+
+// wins decides an edit conflict the same way on every peer: the higher
+// revision wins, then the greater data, then the greater text. Hashes are
+// keyed differently on every peer, so they cannot decide it.
+func wins(item database.ClipboardItem, other database.ClipboardItem) bool {
+	if item.ClipboardItemRevision != other.ClipboardItemRevision {
+		return item.ClipboardItemRevision > other.ClipboardItemRevision
+	}
+	if item.ClipboardItemData != other.ClipboardItemData {
+		return item.ClipboardItemData > other.ClipboardItemData
+	}
+	return item.ClipboardItemText > other.ClipboardItemText
+}
+
+// Apply merges changes from a peer into the Workspace of owner in db in one
+// transaction and returns the ones that changed something.
+// ClipboardItems are matched by time within the Workspace and merged by
+// hash, a delete wins over a concurrent edit.
+func Apply(db *gorm.DB, owner int64, workspace int64, changes []Change) ([]Change, error) {
+	applied := []Change{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			err := change.Validate()
+			if err != nil {
+				return err
+			}
+			ok, err := applyChange(tx, owner, workspace, change)
+			if err != nil {
+				return err
+			}
+			if ok {
+				applied = append(applied, change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func applyChange(tx *gorm.DB, owner int64, workspace int64, change Change) (bool, error) {
+	var local database.ClipboardItem
+
+	err := tx.
+		Where("clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_time = ?", owner, workspace, change.ClipboardItemTime).
+		First(&local).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	switch change.ClipboardItemChangeType {
+	case ChangePurged:
+		if !found {
+			return false, nil
+		}
+		return true, tx.Delete(&local).Error
+
+	case ChangeDeleted:
+		if !found || local.ClipboardItemDeletedTime != 0 {
+			return false, nil
+		}
+		deletedTime := change.ClipboardItemChangeTime
+		if deletedTime == 0 {
+			deletedTime = utils.GetUnixMillisTimestamp()
+		}
+		return true, tx.Model(&local).Updates(map[string]interface{}{
+			"clipboard_item_deleted_time": deletedTime,
+			"clipboard_item_revision":     local.ClipboardItemRevision + 1,
+		}).Error
+	}
+
+	remote := *change.ClipboardItem
+	if remote.ClipboardItemExpiresTime != 0 && remote.ClipboardItemExpiresTime <= utils.GetUnixMillisTimestamp() {
+		// Gone on the peer as well once its sweeper runs.
+		return false, nil
+	}
+	if !found {
+		var count int64
+		err = tx.Model(&database.ClipboardItem{}).Where("clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_hash = ?", owner, workspace, remote.ClipboardItemHash).Count(&count).Error
+		if err != nil || count > 0 {
+			return false, err
+		}
+		if remote.ClipboardItemRevision == 0 {
+			remote.ClipboardItemRevision = 1
+		}
+		created := database.ClipboardItem{
+			ClipboardItemTime:        remote.ClipboardItemTime,
+			ClipboardItemText:        remote.ClipboardItemText,
+			ClipboardItemHash:        remote.ClipboardItemHash,
+			ClipboardItemData:        remote.ClipboardItemData,
+			ClipboardItemRevision:    remote.ClipboardItemRevision,
+			ClipboardItemDevice:      remote.ClipboardItemDevice,
+			ClipboardItemSourceApp:   remote.ClipboardItemSourceApp,
+			ClipboardItemWindowTitle: remote.ClipboardItemWindowTitle,
+			ClipboardItemSensitive:   remote.ClipboardItemSensitive,
+			ClipboardItemSecret:      remote.ClipboardItemSecret,
+			ClipboardItemExpiresTime: remote.ClipboardItemExpiresTime,
+			ClipboardItemTags:        remote.ClipboardItemTags,
+			ClipboardItemPinned:      remote.ClipboardItemPinned,
+			ClipboardItemOwner:       owner,
+			ClipboardItemWorkspace:   workspace,
+		}
+		err = tx.Create(&created).Error
+		if err != nil {
+			return false, err
+		}
+		err = database.SetBlindTokens(tx, created.Index, remote.ClipboardItemBlindTokens)
+		if err != nil {
+			return false, err
+		}
+		return true, database.TouchDevice(tx, owner, remote.ClipboardItemDevice, remote.ClipboardItemTime)
+	}
+
+	changed := false
+	if local.ClipboardItemDeletedTime != 0 {
+		if change.ClipboardItemChangeType != ChangeRestored {
+			return false, nil
+		}
+		err = tx.Model(&local).Update("clipboard_item_deleted_time", 0).Error
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	if local.ClipboardItemHash == remote.ClipboardItemHash && local.ClipboardItemText == remote.ClipboardItemText {
+		// Tagged or pinned on the peer
+		if remote.ClipboardItemRevision <= local.ClipboardItemRevision ||
+			(local.ClipboardItemTags == remote.ClipboardItemTags && local.ClipboardItemPinned == remote.ClipboardItemPinned) {
+			return changed, nil
+		}
+		return true, tx.Model(&local).Updates(map[string]interface{}{
+			"clipboard_item_tags":     remote.ClipboardItemTags,
+			"clipboard_item_pinned":   remote.ClipboardItemPinned,
+			"clipboard_item_revision": remote.ClipboardItemRevision,
+		}).Error
+	}
+	if !wins(remote, local) {
+		return changed, nil
+	}
+
+	var count int64
+	err = tx.Model(&database.ClipboardItem{}).
+		Where("clipboard_item_owner = ? AND clipboard_item_workspace = ?", owner, workspace).
+		Where("clipboard_item_hash = ? AND clipboard_item_time != ?", remote.ClipboardItemHash, local.ClipboardItemTime).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return changed, err
+	}
+	// A struct rather than a map, so the payload is encrypted on the way in.
+	err = tx.Model(&local).
+		Select("clipboard_item_text", "clipboard_item_hash", "clipboard_item_data", "clipboard_item_revision",
+			"clipboard_item_sensitive", "clipboard_item_secret", "clipboard_item_expires_time",
+			"clipboard_item_tags", "clipboard_item_pinned").
+		Updates(&database.ClipboardItem{
+			Index:                    local.Index,
+			ClipboardItemText:        remote.ClipboardItemText,
+			ClipboardItemHash:        remote.ClipboardItemHash,
+			ClipboardItemData:        remote.ClipboardItemData,
+			ClipboardItemRevision:    remote.ClipboardItemRevision,
+			ClipboardItemSensitive:   remote.ClipboardItemSensitive,
+			ClipboardItemSecret:      remote.ClipboardItemSecret,
+			ClipboardItemExpiresTime: remote.ClipboardItemExpiresTime,
+			ClipboardItemTags:        remote.ClipboardItemTags,
+			ClipboardItemPinned:      remote.ClipboardItemPinned,
+		}).Error
+	if err != nil {
+		return changed, err
+	}
+	return true, database.SetBlindTokens(tx, local.Index, remote.ClipboardItemBlindTokens)
+}
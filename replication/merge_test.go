@@ -0,0 +1,145 @@
+package replication
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+func newItem(time int64, text string) *database.ClipboardItem {
+	data := base64.StdEncoding.EncodeToString([]byte(text))
+	return &database.ClipboardItem{
+		ClipboardItemTime: time,
+		ClipboardItemText: text,
+		ClipboardItemData: data,
+		ClipboardItemHash: fmt.Sprintf("%x", sha256.Sum256([]byte(data))),
+	}
+}
+
+func TestWins(t *testing.T) {
+	older := database.ClipboardItem{ClipboardItemRevision: 1, ClipboardItemData: "b"}
+	newer := database.ClipboardItem{ClipboardItemRevision: 2, ClipboardItemData: "a"}
+	assert.True(t, wins(newer, older))
+	assert.False(t, wins(older, newer))
+
+	newer.ClipboardItemRevision = 1
+	assert.True(t, wins(older, newer))
+	assert.False(t, wins(newer, older))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Change{ClipboardItemChangeType: ChangePurged}.Validate())
+	assert.Error(t, Change{ClipboardItemChangeType: ChangeCreated}.Validate())
+	assert.Error(t, Change{ClipboardItemChangeType: "a"}.Validate())
+	assert.Error(t, Change{
+		ClipboardItemTime:       1,
+		ClipboardItemChangeType: ChangeUpdated,
+		ClipboardItem:           newItem(2, "a"),
+	}.Validate())
+}
+
+func TestApplyTimeOfOtherWorkspace(t *testing.T) {
+	database.Open("file:merge?mode=memory&cache=shared")
+	theirs := newItem(5, "theirs")
+	theirs.ClipboardItemWorkspace = 1
+	assert.NoError(t, database.Orm.Create(theirs).Error)
+
+	applied, err := Apply(database.Orm, 0, 0, []Change{
+		{ClipboardItemTime: 5, ClipboardItemChangeType: ChangeCreated, ClipboardItem: newItem(5, "ours")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	applied, err = Apply(database.Orm, 0, 0, []Change{
+		{ClipboardItemTime: 5, ClipboardItemChangeType: ChangePurged},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	var item database.ClipboardItem
+	assert.NoError(t, database.Orm.First(&item, "clipboard_item_time = 5").Error)
+	assert.Equal(t, "theirs", item.ClipboardItemText)
+
+	database.Close()
+}
+
+func TestApplyExpiry(t *testing.T) {
+	database.Open("file:expiry?mode=memory&cache=shared")
+	now := utils.GetUnixMillisTimestamp()
+
+	expiring := newItem(5, "expiring")
+	expiring.ClipboardItemExpiresTime = now + 60000
+	expired := newItem(6, "expired")
+	expired.ClipboardItemExpiresTime = now - 1
+	applied, err := Apply(database.Orm, 0, 0, []Change{
+		{ClipboardItemTime: 5, ClipboardItemChangeType: ChangeCreated, ClipboardItem: expiring},
+		{ClipboardItemTime: 6, ClipboardItemChangeType: ChangeCreated, ClipboardItem: expired},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	var item database.ClipboardItem
+	assert.NoError(t, database.Orm.First(&item, "clipboard_item_time = 5").Error)
+	assert.Equal(t, now+60000, item.ClipboardItemExpiresTime)
+	assert.Error(t, database.Orm.First(&item, "clipboard_item_time = 6").Error)
+
+	assert.NoError(t, database.Orm.Model(&item).Update("clipboard_item_expires_time", now-1).Error)
+	changes, _, _, err := Changes(database.Orm, 0, 0, 0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	database.Close()
+}
+
+func TestApplySecret(t *testing.T) {
+	database.Open("file:secret?mode=memory&cache=shared")
+	secret := newItem(5, "password: hunter2")
+	secret.ClipboardItemSecret = true
+	secret.ClipboardItemSensitive = "password"
+	applied, err := Apply(database.Orm, 0, 0, []Change{
+		{ClipboardItemTime: 5, ClipboardItemChangeType: ChangeCreated, ClipboardItem: secret},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	var item database.ClipboardItem
+	assert.NoError(t, database.Orm.First(&item, "clipboard_item_time = 5").Error)
+	assert.True(t, item.ClipboardItemSecret)
+	assert.Equal(t, "password", item.ClipboardItemSensitive)
+
+	var count int64
+	assert.NoError(t, database.Orm.Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH 'hunter2'").Scan(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	database.Close()
+}
+
+func TestApplyTags(t *testing.T) {
+	database.Open("file:tags?mode=memory&cache=shared")
+	assert.NoError(t, database.Orm.Create(newItem(5, "tagged")).Error)
+
+	tagged := newItem(5, "tagged")
+	tagged.ClipboardItemRevision = 2
+	tagged.ClipboardItemTags = "work"
+	tagged.ClipboardItemPinned = true
+	change := Change{ClipboardItemTime: 5, ClipboardItemChangeType: ChangeUpdated, ClipboardItem: tagged}
+	applied, err := Apply(database.Orm, 0, 0, []Change{change})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	applied, err = Apply(database.Orm, 0, 0, []Change{change})
+	assert.NoError(t, err)
+	assert.Len(t, applied, 0)
+
+	var item database.ClipboardItem
+	assert.NoError(t, database.Orm.First(&item, "clipboard_item_time = 5").Error)
+	assert.Equal(t, "work", item.ClipboardItemTags)
+	assert.True(t, item.ClipboardItemPinned)
+	assert.Equal(t, int64(2), item.ClipboardItemRevision)
+
+	database.Close()
+}
@@ -0,0 +1,190 @@
+package replication_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/replication"
+	"github.com/used255/clipboard_archive/v3/route"
+	"gorm.io/gorm"
+)
+
+// servedDatabase serialises requests so each in-process server sees its
+// own database through the database.Orm global.
+var servedDatabase sync.Mutex
+
+func serve(db *gorm.DB) *httptest.Server {
+	r := route.SetupRouter()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		servedDatabase.Lock()
+		defer servedDatabase.Unlock()
+		database.Orm = db
+		r.ServeHTTP(w, req)
+	}))
+}
+
+func openDatabase(name string) *gorm.DB {
+	database.Orm = nil
+	database.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	return database.Orm
+}
+
+func newItem(time int64, text string) *database.ClipboardItem {
+	data := base64.StdEncoding.EncodeToString([]byte(text))
+	return &database.ClipboardItem{
+		ClipboardItemTime: time,
+		ClipboardItemText: text,
+		ClipboardItemData: data,
+		ClipboardItemHash: fmt.Sprintf("%x", sha256.Sum256([]byte(data))),
+	}
+}
+
+func liveTexts(db *gorm.DB) map[int64]string {
+	items := []database.ClipboardItem{}
+	db.Where("clipboard_item_deleted_time = 0").Find(&items)
+	texts := map[int64]string{}
+	for _, item := range items {
+		texts[item.ClipboardItemTime] = item.ClipboardItemText
+	}
+	return texts
+}
+
+func TestSync(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	home := openDatabase("home")
+	office := openDatabase("office")
+	homeServer := serve(home)
+	defer homeServer.Close()
+	officeServer := serve(office)
+	defer officeServer.Close()
+
+	home.Create(newItem(1, "one"))
+	office.Create(newItem(2, "two"))
+	// Copied on both machines, merged by hash.
+	home.Create(newItem(3, "same"))
+	office.Create(newItem(4, "same"))
+
+	pulled, pushed, err := replication.Sync(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pulled)
+	assert.Equal(t, 1, pushed)
+	assert.Equal(t, map[int64]string{1: "one", 2: "two", 3: "same"}, liveTexts(home))
+	assert.Equal(t, map[int64]string{1: "one", 2: "two", 4: "same"}, liveTexts(office))
+
+	// Concurrent edits, the higher revision wins on both sides.
+	home.Model(&database.ClipboardItem{}).Where("clipboard_item_time = 1").
+		Updates(map[string]interface{}{"clipboard_item_text": "home", "clipboard_item_revision": 2})
+	office.Model(&database.ClipboardItem{}).Where("clipboard_item_time = 1").
+		Updates(map[string]interface{}{"clipboard_item_text": "office", "clipboard_item_revision": 3})
+
+	// A delete wins over a concurrent edit.
+	home.Model(&database.ClipboardItem{}).Where("clipboard_item_time = 2").
+		Updates(map[string]interface{}{"clipboard_item_text": "edited", "clipboard_item_revision": 5})
+	office.Model(&database.ClipboardItem{}).Where("clipboard_item_time = 2").
+		Update("clipboard_item_deleted_time", 10)
+
+	_, _, err = replication.Sync(office, 0, 0, replication.Peer{URL: homeServer.URL})
+	assert.NoError(t, err)
+	_, _, err = replication.Sync(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[int64]string{1: "office", 3: "same"}, liveTexts(home))
+	assert.Equal(t, map[int64]string{1: "office", 4: "same"}, liveTexts(office))
+
+	// Purges are carried as tombstones.
+	home.Delete(&database.ClipboardItem{}, "clipboard_item_time = ?", 1)
+	pushed, err = replication.Push(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pushed)
+	assert.Equal(t, map[int64]string{4: "same"}, liveTexts(office))
+
+	// Nothing left to do once converged.
+	pulled, pushed, err = replication.Sync(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pulled)
+	assert.Equal(t, 0, pushed)
+
+	database.Orm = home
+	database.Close()
+	database.Orm = office
+	database.Close()
+}
+
+func TestPullSensitive(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	home := openDatabase("home_sensitive")
+	office := openDatabase("office_sensitive")
+	officeServer := serve(office)
+	defer officeServer.Close()
+	defer func() { _ = route.SetDetectionRules(route.DefaultDetectionRules()) }()
+	assert.NoError(t, route.SetDetectionRules([]route.DetectionRule{
+		{Name: "password", Action: route.DetectionActionReject},
+		{Name: "api_key", Action: route.DetectionActionSecret},
+	}))
+
+	office.Create(newItem(1, "password: hunter2"))
+	office.Create(newItem(2, "sk-0123456789abcdefghijklmnop"))
+	// Flagged more strictly on the peer.
+	flagged := newItem(3, "three")
+	flagged.ClipboardItemSensitive = "custom"
+	flagged.ClipboardItemSecret = true
+	office.Create(flagged)
+
+	pulled, err := replication.Pull(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pulled)
+
+	items := []database.ClipboardItem{}
+	home.Order("clipboard_item_time").Find(&items)
+	assert.Len(t, items, 2)
+	assert.Equal(t, int64(2), items[0].ClipboardItemTime)
+	assert.Equal(t, "api_key", items[0].ClipboardItemSensitive)
+	assert.True(t, items[0].ClipboardItemSecret)
+	assert.Equal(t, "custom", items[1].ClipboardItemSensitive)
+	assert.True(t, items[1].ClipboardItemSecret)
+
+	database.Orm = home
+	database.Close()
+	database.Orm = office
+	database.Close()
+}
+
+func TestSyncAsUser(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	home := openDatabase("home_user")
+	office := openDatabase("office_user")
+	officeServer := serve(office)
+	defer officeServer.Close()
+
+	user, token, err := database.CreateUser("alice")
+	assert.NoError(t, err)
+
+	home.Create(newItem(1, "one"))
+	office.Create(newItem(2, "someone else's"))
+
+	_, _, err = replication.Sync(home, 0, 0, replication.Peer{URL: officeServer.URL})
+	assert.Error(t, err)
+
+	pulled, pushed, err := replication.Sync(home, 0, 0, replication.Peer{URL: officeServer.URL, Token: token})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pulled)
+	assert.Equal(t, 1, pushed)
+
+	var item database.ClipboardItem
+	office.First(&item, "clipboard_item_time = 1")
+	assert.Equal(t, user.Index, item.ClipboardItemOwner)
+	assert.Equal(t, map[int64]string{1: "one"}, liveTexts(home))
+
+	database.Orm = home
+	database.Close()
+	database.Orm = office
+	database.Close()
+}
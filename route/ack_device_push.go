@@ -0,0 +1,38 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// ackDevicePush removes a pulled push once the device has put it on its
+// clipboard.
+func ackDevicePush(c *gin.Context) {
+	device := c.Params.ByName("device")
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	tx := database.Orm.Where("device_push_owner = ? AND device_name = ?", ownerOf(c), device).Delete(&database.DevicePush{}, id)
+	if tx.Error != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error acknowledging DevicePush", tx.Error)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		abortWithError(c, http.StatusNotFound, codeDevicePushNotFound, "DevicePush not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"message": "DevicePush acknowledged successfully",
+		"Index":   id,
+	})
+}
@@ -0,0 +1,50 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestAckDevicePush(t *testing.T) {
+	var count int64
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1, DevicePushExpiresTime: 1 << 62})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/devices/laptop/push/1/ack", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, codeDevicePushNotFound, loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/devices/desktop/push/1/ack", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	expected := gin.H{
+		"status":  http.StatusOK,
+		"message": "DevicePush acknowledged successfully",
+		"Index":   1,
+	}
+	assert.Equal(t, reloadJSON(expected), loadJSON(w.Body.String()))
+
+	database.Orm.Model(&database.DevicePush{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/devices/desktop/push/a/ack", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	database.Close()
+}
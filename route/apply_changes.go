@@ -0,0 +1,141 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/replication"
+	"gorm.io/gorm"
+)
+
+type applyChangesRequest struct {
+	ClipboardItemChange []replication.Change `json:"ClipboardItemChange" binding:"required"`
+}
+
+// applyChanges merges changes pushed by a peer, in the change feed format.
+// ClipboardItems failing the checks of an insert are left out rather than
+// failing the batch, so the peer can move on.
+func applyChanges(c *gin.Context) {
+	var request applyChangesRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	for _, change := range request.ClipboardItemChange {
+		err = change.Validate()
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidChange, "Invalid change", err)
+			return
+		}
+	}
+
+	rules, err := currentIngestRules()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error applying changes", err)
+		return
+	}
+	admitted := []replication.Change{}
+	for _, change := range request.ClipboardItemChange {
+		switch change.ClipboardItemChangeType {
+		case replication.ChangeCreated, replication.ChangeUpdated, replication.ChangeRestored:
+			code := admitClipboardItem(rules, (*ClipboardItem)(change.ClipboardItem), endToEndOf(c))
+			if code != "" {
+				rejectedTotal.Inc(code)
+				continue
+			}
+		}
+		admitted = append(admitted, change)
+	}
+
+	var applied []replication.Change
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		var err error
+		applied, err = replication.Apply(tx, ownerOf(c), workspaceOf(c), admitted)
+		if err != nil {
+			return err
+		}
+		for _, change := range applied {
+			switch change.ClipboardItemChangeType {
+			case replication.ChangeDeleted, replication.ChangePurged:
+				err = publishClipboardItemEvent(tx, eventClipboardItemDeleted, ownerOf(c), workspaceOf(c), change.ClipboardItemTime, nil)
+			default:
+				item := ClipboardItem(*change.ClipboardItem)
+				err = publishClipboardItemEvent(tx, "ClipboardItem."+change.ClipboardItemChangeType, ownerOf(c), workspaceOf(c), item.ClipboardItemTime, &item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error applying changes", err)
+		return
+	}
+
+	for _, change := range applied {
+		auditClipboardItems(c, change.ClipboardItemTime)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   http.StatusOK,
+		"message":  "Changes applied successfully",
+		"count":    len(request.ClipboardItemChange),
+		"applied":  len(applied),
+		"rejected": len(request.ClipboardItemChange) - len(admitted),
+	})
+}
+
+func init() {
+	replication.Admit = admitPulledClipboardItem
+}
+
+// admitClipboardItem runs item, received from a peer, through the checks and
+// detection rules of an insert and recomputes its ClipboardItemHash, which
+// the server cannot do for end-to-end encrypted Workspaces. It returns the
+// error code of the check item fails, empty if it passes them all.
+func admitClipboardItem(rules []ingestRule, item *ClipboardItem, endToEnd bool) string {
+	if validateBlindTokens(item.ClipboardItemBlindTokens, endToEnd) != nil {
+		return codeInvalidBlindTokens
+	}
+	rule, _ := violatedIngestRule(rules, item, item.ClipboardItemMimeTypes, endToEnd)
+	if rule != nil {
+		return codeIngestRejected
+	}
+	if endToEnd {
+		return ""
+	}
+	// The peer may flag it more strictly than detection here, never less.
+	if len(detectSensitiveContent(item)) > 0 {
+		return codeSensitiveContent
+	}
+	item.ClipboardItemHash = hashClipboardItemData(item.ClipboardItemData)
+	return ""
+}
+
+// admitPulledClipboardItem is admitClipboardItem for ClipboardItems pulled
+// from a peer into the Workspace of owner.
+func admitPulledClipboardItem(db *gorm.DB, owner int64, workspace int64, item *database.ClipboardItem) (string, error) {
+	rules, err := currentIngestRules()
+	if err != nil {
+		return "", err
+	}
+	endToEnd := false
+	if workspace != 0 {
+		var w database.Workspace
+		err = db.Where("workspace_owner = ?", owner).First(&w, workspace).Error
+		if err != nil {
+			return "", err
+		}
+		endToEnd = w.WorkspaceEndToEnd
+	}
+	code := admitClipboardItem(rules, (*ClipboardItem)(item), endToEnd)
+	if code != "" {
+		rejectedTotal.Inc(code)
+	}
+	return code, nil
+}
@@ -0,0 +1,137 @@
+package route
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+// AuditLog records every API call in the audit log. Entries are written in
+// the background, a handler never waits for them.
+var AuditLog = false
+
+const (
+	userNameKey            = "user_name"
+	auditClipboardItemsKey = "audit_clipboard_items"
+)
+
+// auditEntries queues entries for writeAuditLog. When it is full the
+// database cannot keep up, and entries are dropped rather than holding up
+// requests.
+var (
+	auditEntries     = make(chan database.AuditEntry, 4096)
+	auditFlushes     = make(chan chan struct{})
+	startAuditWriter sync.Once
+)
+
+// auditClipboardItems notes ClipboardItems a request read or changed besides
+// the one in its path.
+func auditClipboardItems(c *gin.Context, times ...int64) {
+	if !AuditLog {
+		return
+	}
+	seen := c.GetStringSlice(auditClipboardItemsKey)
+	for _, time := range times {
+		seen = append(seen, strconv.FormatInt(time, 10))
+	}
+	c.Set(auditClipboardItemsKey, seen)
+}
+
+// audit records the request once it is answered. It runs before
+// errorHandler to see the status errors are rendered with.
+func audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		// Scrapes are not API calls, and would fill the log.
+		if !AuditLog || c.FullPath() == "/metrics" {
+			return
+		}
+
+		items := c.GetStringSlice(auditClipboardItemsKey)
+		route := c.FullPath()
+		if strings.Contains(route+"/", "/ClipboardItem/:id/") || strings.Contains(route+"/", "/trash/:id/") {
+			_, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err == nil {
+				items = append([]string{c.Param("id")}, items...)
+			}
+		}
+		entry := database.AuditEntry{
+			AuditEntryTime:           utils.GetUnixMillisTimestamp(),
+			AuditEntryRequestID:      c.GetString(requestIDKey),
+			AuditEntryClientIP:       c.ClientIP(),
+			AuditEntryUser:           ownerOf(c),
+			AuditEntryUserName:       c.GetString(userNameKey),
+			AuditEntryWorkspace:      workspaceOf(c),
+			AuditEntryMethod:         c.Request.Method,
+			AuditEntryRoute:          route,
+			AuditEntryClipboardItems: strings.Join(items, ","),
+			AuditEntryStatus:         c.Writer.Status(),
+		}
+
+		startAuditWriter.Do(func() {
+			go writeAuditLog()
+		})
+		select {
+		case auditEntries <- entry:
+		default:
+			log.Printf("Audit log is falling behind, dropped the entry of request %s", entry.AuditEntryRequestID)
+		}
+	}
+}
+
+// FlushAuditLog writes the queued entries before it returns, so none are
+// lost when the server exits.
+func FlushAuditLog() {
+	startAuditWriter.Do(func() {
+		go writeAuditLog()
+	})
+	flushed := make(chan struct{})
+	auditFlushes <- flushed
+	<-flushed
+}
+
+// writeAuditLog inserts queued entries, and empties the queue when
+// FlushAuditLog asks it to.
+func writeAuditLog() {
+	for {
+		select {
+		case entry := <-auditEntries:
+			writeAuditBatch(entry)
+		case flushed := <-auditFlushes:
+			for empty := false; !empty; {
+				select {
+				case entry := <-auditEntries:
+					writeAuditBatch(entry)
+				default:
+					empty = true
+				}
+			}
+			close(flushed)
+		}
+	}
+}
+
+// writeAuditBatch inserts entry with as many queued entries as are waiting.
+func writeAuditBatch(entry database.AuditEntry) {
+	batch := []database.AuditEntry{entry}
+	for waiting := true; waiting && len(batch) < 100; {
+		select {
+		case entry := <-auditEntries:
+			batch = append(batch, entry)
+		default:
+			waiting = false
+		}
+	}
+	if database.Orm == nil {
+		return
+	}
+	err := database.Orm.Create(&batch).Error
+	if err != nil {
+		log.Println("Error writing audit log: ", err)
+	}
+}
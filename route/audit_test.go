@@ -0,0 +1,88 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestAuditLog(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	defer func() { AuditLog = false }()
+	AuditLog = true
+
+	alice, token, _ := database.CreateUser("alice")
+	item := preparationClipboardItem()
+	id := fmt.Sprintf("%d", item.ClipboardItemTime)
+
+	serve := func(method string, url string, body string, token string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+	}
+	serve("POST", "/api/v1/ClipboardItem", dumpJSON(clipboardItemToGinH(item)), token)
+	serve("GET", "/api/v1/ClipboardItem/"+id, "", token)
+	serve("GET", "/api/v1/ClipboardItem?limit=10", "", token)
+	serve("GET", "/api/v1/ClipboardItem/"+id, "", "")
+
+	entries := []database.AuditEntry{}
+	assert.Eventually(t, func() bool {
+		database.Orm.Order("`index`").Find(&entries)
+		return len(entries) == 4
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "POST", entries[0].AuditEntryMethod)
+	assert.Equal(t, "/api/v1/ClipboardItem", entries[0].AuditEntryRoute)
+	assert.Equal(t, http.StatusCreated, entries[0].AuditEntryStatus)
+	assert.Equal(t, id, entries[0].AuditEntryClipboardItems)
+	assert.Equal(t, alice.Index, entries[0].AuditEntryUser)
+	assert.Equal(t, "alice", entries[0].AuditEntryUserName)
+	// The private network proxy is trusted to name the client
+	assert.Equal(t, "203.0.113.7", entries[0].AuditEntryClientIP)
+	assert.NotEmpty(t, entries[0].AuditEntryRequestID)
+
+	assert.Equal(t, "/api/v1/ClipboardItem/:id", entries[1].AuditEntryRoute)
+	assert.Equal(t, http.StatusOK, entries[1].AuditEntryStatus)
+	assert.Equal(t, id, entries[1].AuditEntryClipboardItems)
+	assert.Equal(t, id, entries[2].AuditEntryClipboardItems)
+
+	assert.Equal(t, http.StatusUnauthorized, entries[3].AuditEntryStatus)
+	assert.Equal(t, int64(0), entries[3].AuditEntryUser)
+
+	database.Close()
+}
+
+func TestFlushAuditLog(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	defer func() { AuditLog = false }()
+	AuditLog = true
+
+	for i := 0; i < 200; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/1", nil)
+		r.ServeHTTP(w, req)
+	}
+	FlushAuditLog()
+
+	var count int64
+	database.Orm.Model(&database.AuditEntry{}).Count(&count)
+	assert.Equal(t, int64(200), count)
+
+	database.Close()
+}
@@ -0,0 +1,122 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+const (
+	ownerKey       = "owner"
+	adminKey       = "admin"
+	accessTokenKey = "access_token"
+)
+
+// authenticate resolves the API token of a request to its User. Without
+// any Users the archive stays open and everything belongs to owner 0.
+func authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var count int64
+		err := database.Orm.Model(&database.User{}).Limit(1).Count(&count).Error
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error authenticating", err)
+			return
+		}
+		if count == 0 {
+			c.Set(ownerKey, int64(0))
+			c.Set(adminKey, true)
+			c.Next()
+			return
+		}
+
+		token := bearerToken(c)
+		if token == "" {
+			unauthorized(c, "Missing API token", nil)
+			return
+		}
+		var user database.User
+		err = database.Orm.Where("user_token_hash = ?", database.HashUserToken(token)).First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				unauthorized(c, "Invalid API token", nil)
+				return
+			}
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error authenticating", err)
+			return
+		}
+		if user.UserDisabled {
+			abortWithError(c, http.StatusForbidden, codeUserDisabled, "User is disabled", nil)
+			return
+		}
+
+		c.Set(ownerKey, user.Index)
+		c.Set(userNameKey, user.UserName)
+		c.Set(adminKey, user.UserAdmin)
+		c.Next()
+	}
+}
+
+// requireAdmin refuses Users who are not admins. Without any Users the
+// archive is open, and so are its settings.
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(adminKey) {
+			abortWithError(c, http.StatusForbidden, codeAdminRequired, "Admin required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// takeAccessToken moves the access_token query parameter out of the URL
+// before the request is logged. Only the event streams accept it, browsers
+// cannot set headers on EventSource and WebSocket requests.
+func takeAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if query.Has(accessTokenKey) {
+			route := c.FullPath()
+			if strings.HasSuffix(route, "/events") || strings.HasSuffix(route, "/events/ws") {
+				c.Set(accessTokenKey, query.Get(accessTokenKey))
+			}
+			query.Del(accessTokenKey)
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
+}
+
+// bearerToken reads the Authorization header, or the access_token taken
+// from the URL of an event stream.
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return c.GetString(accessTokenKey)
+}
+
+func unauthorized(c *gin.Context, message string, err error) {
+	chargeClientIP(c)
+	c.Header("WWW-Authenticate", `Bearer realm="clipboard_archive"`)
+	abortWithError(c, http.StatusUnauthorized, codeUnauthorized, message, err)
+}
+
+// ownerOf is the Index of the User making the request, 0 without accounts.
+func ownerOf(c *gin.Context) int64 {
+	return c.GetInt64(ownerKey)
+}
+
+// ownedClipboardItems restricts a query to the ClipboardItems of the User
+// making the request, in the Workspace it addresses.
+func ownedClipboardItems(c *gin.Context) func(tx *gorm.DB) *gorm.DB {
+	owner := ownerOf(c)
+	workspace := workspaceOf(c)
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("clipboard_items.clipboard_item_owner = ? AND clipboard_items.clipboard_item_workspace = ?", owner, workspace)
+	}
+}
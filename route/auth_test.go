@@ -0,0 +1,126 @@
+package route
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"golang.org/x/net/websocket"
+)
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	// Open until the first User is created.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/count", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, token, _ := database.CreateUser("alice")
+
+	for _, path := range []string{"/api/v1/ClipboardItem/count", "/api/v1/ClipboardItem/count?access_token=a"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, codeUnauthorized, loadJSON(w.Body.String())["code"])
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ping", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem/count", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The query parameter is only for event streams, and never logged.
+	var logged bytes.Buffer
+	defer func(writer io.Writer) { gin.DefaultWriter = writer }(gin.DefaultWriter)
+	gin.DefaultWriter = &logged
+	r = SetupRouter()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem/count?access_token="+token+"&limit=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, logged.String(), "/api/v1/ClipboardItem/count?limit=1")
+	assert.NotContains(t, logged.String(), token)
+
+	server := httptest.NewServer(r)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/events/ws"
+	_, err := websocket.Dial(url, "", server.URL)
+	assert.Error(t, err)
+	ws, err := websocket.Dial(url+"?access_token="+token, "", server.URL)
+	assert.NoError(t, err)
+	if err == nil {
+		ws.Close()
+	}
+	server.Close()
+
+	database.SetUserDisabled("alice", true)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem/count", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, codeUserDisabled, loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
+
+func TestOwnedClipboardItems(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	_, alice, _ := database.CreateUser("alice")
+	_, bob, _ := database.CreateUser("bob")
+	do := func(token string, method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The same ClipboardItem may be copied by both.
+	item := preparationClipboardItem()
+	w := do(alice, "POST", "/api/v1/ClipboardItem", dumpJSON(clipboardItemToGinH(item)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	item.ClipboardItemTime++
+	w = do(bob, "POST", "/api/v1/ClipboardItem", dumpJSON(clipboardItemToGinH(item)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = do(bob, "POST", "/api/v1/ClipboardItem", dumpJSON(clipboardItemToGinH(item)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(alice, "GET", "/api/v1/ClipboardItem?search="+item.ClipboardItemText, "")
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["count"])
+	w = do(alice, "GET", "/api/v1/ClipboardItem/count", "")
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["count"])
+	w = do(alice, "GET", "/api/v1/stats", "")
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["total_count"])
+
+	w = do(alice, "GET", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = do(alice, "DELETE", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(bob, "GET", "/api/v1/changes", "")
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["count"])
+
+	database.Close()
+}
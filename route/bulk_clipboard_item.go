@@ -0,0 +1,154 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+// BulkConfirmThreshold is the number of ClipboardItems a bulk operation may
+// touch before it has to be confirmed with the affected count, 0 disables it.
+var BulkConfirmThreshold int64 = 100
+
+var errBulkConfirmationRequired = errors.New("confirmation required")
+
+type bulkClipboardItemRequest struct {
+	clipboardItemFilter
+	Action  string `json:"action"`
+	AddTag  string `json:"addTag"` // the tag action adds
+	DryRun  bool   `json:"dryRun"`
+	Confirm int64  `json:"confirm"`
+}
+
+// bulkClipboardItem applies one action to every live ClipboardItem matching
+// the filter, in a single transaction. The filter may not be empty, acting
+// on the whole archive takes an explicit time range.
+func bulkClipboardItem(c *gin.Context) {
+	var request bulkClipboardItemRequest
+	var count int64
+	var times []int64
+	var err error
+
+	err = c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+	request.EndToEnd = endToEndOf(c)
+
+	switch request.Action {
+	case "delete", "trash", "pin":
+	case "tag":
+		if request.AddTag == "" || strings.Contains(request.AddTag, ",") {
+			abortWithError(c, http.StatusBadRequest, codeInvalidTag, "Invalid tag", errors.New("addTag must be a non-empty string without commas"))
+			return
+		}
+	default:
+		abortWithError(c, http.StatusBadRequest, codeInvalidAction, "Invalid action", errors.New("action must be one of delete, trash, tag, pin"))
+		return
+	}
+	if request.empty() {
+		abortWithError(c, http.StatusBadRequest, codeFilterRequired, "Filter required", errors.New("give ids or at least one filter"))
+		return
+	}
+
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		err := tx.Model(&ClipboardItem{}).Scopes(ownedClipboardItems(c), liveClipboardItems, request.scope).Pluck("clipboard_item_time", &times).Error
+		if err != nil {
+			return err
+		}
+		count = int64(len(times))
+		if request.DryRun {
+			return nil
+		}
+		if BulkConfirmThreshold > 0 && count > BulkConfirmThreshold && request.Confirm != count {
+			return errBulkConfirmationRequired
+		}
+
+		query := tx.Model(&ClipboardItem{}).Scopes(ownedClipboardItems(c), liveClipboardItems, request.scope)
+		switch request.Action {
+		case "trash":
+			err = query.Updates(map[string]interface{}{
+				"clipboard_item_deleted_time": utils.GetUnixMillisTimestamp(),
+				"clipboard_item_revision":     gorm.Expr("clipboard_item_revision + 1"),
+			}).Error
+		case "tag":
+			err = query.Updates(map[string]interface{}{
+				"clipboard_item_tags": gorm.Expr(
+					"CASE WHEN clipboard_item_tags = '' THEN ? WHEN instr(',' || clipboard_item_tags || ',', ',' || ? || ',') > 0 THEN clipboard_item_tags ELSE clipboard_item_tags || ',' || ? END",
+					request.AddTag, request.AddTag, request.AddTag,
+				),
+				"clipboard_item_revision": gorm.Expr("clipboard_item_revision + 1"),
+			}).Error
+		case "pin":
+			err = query.Updates(map[string]interface{}{
+				"clipboard_item_pinned":   true,
+				"clipboard_item_revision": gorm.Expr("clipboard_item_revision + 1"),
+			}).Error
+		default:
+			err = query.Delete(&ClipboardItem{}).Error
+		}
+		if err != nil {
+			return err
+		}
+		return publishBulkEvents(tx, c, request.Action, times)
+	})
+	if err != nil {
+		if errors.Is(err, errBulkConfirmationRequired) {
+			abortWithDetails(c, http.StatusPreconditionRequired, codeConfirmationRequired, "Confirmation required", gin.H{
+				"count": count,
+			})
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error running bulk "+request.Action, err)
+		return
+	}
+
+	message := "Bulk " + request.Action + " completed successfully"
+	if request.DryRun {
+		message = "Bulk " + request.Action + " dry run completed successfully"
+	} else {
+		auditClipboardItems(c, times...)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":               http.StatusOK,
+		"message":              message,
+		"action":               request.Action,
+		"count":                count,
+		"dryRun":               request.DryRun,
+		"confirmationRequired": BulkConfirmThreshold > 0 && count > BulkConfirmThreshold,
+	})
+}
+
+// publishBulkEvents publishes the events of a bulk action on the
+// ClipboardItems at times, with the updated ClipboardItem when it is kept.
+func publishBulkEvents(tx *gorm.DB, c *gin.Context, action string, times []int64) error {
+	if action == "delete" || action == "trash" {
+		for _, time := range times {
+			err := publishClipboardItemEvent(tx, eventClipboardItemDeleted, ownerOf(c), workspaceOf(c), time, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	items := []ClipboardItem{}
+	if len(times) > 0 {
+		err := tx.Scopes(ownedClipboardItems(c)).Where("clipboard_item_time IN ?", times).Find(&items).Error
+		if err != nil {
+			return err
+		}
+	}
+	for i := range items {
+		err := publishClipboardItemEvent(tx, eventClipboardItemUpdated, ownerOf(c), workspaceOf(c), items[i].ClipboardItemTime, &items[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
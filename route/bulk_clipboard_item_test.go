@@ -0,0 +1,195 @@
+package route
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestBulkClipboardItem(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for i := int64(1); i <= 3; i++ {
+		item := preparationClipboardItem()
+		item.ClipboardItemTime = i
+		database.Orm.Create(&item)
+	}
+
+	w := httptest.NewRecorder()
+	body := `{"action": "trash", "startTimestamp": 2, "dryRun": true}`
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	expected := gin.H{
+		"status":               http.StatusOK,
+		"message":              "Bulk trash dry run completed successfully",
+		"action":               "trash",
+		"count":                2,
+		"dryRun":               true,
+		"confirmationRequired": false,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	var count int64
+	database.Orm.Model(&ClipboardItem{}).Scopes(liveClipboardItems).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "trash", "ids": [1, 3]}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(2), loadJSON(w.Body.String())["count"])
+
+	database.Orm.Model(&ClipboardItem{}).Scopes(trashedClipboardItems).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// Acting on everything takes an explicit filter.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "delete"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "filter_required", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "delete", "startTimestamp": 0}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["count"])
+
+	database.Orm.Model(&ClipboardItem{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	database.Close()
+}
+
+func TestBulkClipboardItemConfirmation(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	threshold := BulkConfirmThreshold
+	BulkConfirmThreshold = 1
+	defer func() { BulkConfirmThreshold = threshold }()
+
+	for i := int64(1); i <= 2; i++ {
+		item := preparationClipboardItem()
+		item.ClipboardItemTime = i
+		database.Orm.Create(&item)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "delete", "startTimestamp": 0}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	expected := gin.H{
+		"status":  http.StatusPreconditionRequired,
+		"code":    "confirmation_required",
+		"message": "Confirmation required",
+		"details": gin.H{
+			"count": 2,
+		},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "delete", "startTimestamp": 0, "confirm": 2}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var count int64
+	database.Orm.Model(&ClipboardItem{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	database.Close()
+}
+
+func TestBulkClipboardItemInvalidAction(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "tag", "addTag": "a,b", "ids": [1]}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_tag", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(`{"action": "nope"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid action", loadJSON(w.Body.String())["message"])
+
+	database.Close()
+}
+
+func TestBulkClipboardItemTagPin(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for i := int64(1); i <= 3; i++ {
+		item := preparationClipboardItem()
+		item.ClipboardItemTime = i
+		item.ClipboardItemData = toBase64(strconv.FormatInt(i, 10))
+		item.ClipboardItemHash = toSha256(item.ClipboardItemData)
+		database.Orm.Create(&item)
+	}
+	bulk := func(body string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem/bulk", bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	list := func(query string) []int64 {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem?"+query, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		times := []int64{}
+		for _, item := range loadJSON(w.Body.String())["ClipboardItem"].([]interface{}) {
+			times = append(times, int64(item.(map[string]interface{})["ClipboardItemTime"].(float64)))
+		}
+		return times
+	}
+
+	bulk(`{"action": "tag", "addTag": "work", "ids": [1, 2]}`)
+	bulk(`{"action": "tag", "addTag": "todo", "startTimestamp": 2}`)
+	bulk(`{"action": "tag", "addTag": "work", "ids": [2]}`)
+	bulk(`{"action": "pin", "tag": "todo", "endTimestamp": 2}`)
+
+	var item ClipboardItem
+	database.Orm.First(&item, "clipboard_item_time = 2")
+	assert.Equal(t, "work,todo", item.ClipboardItemTags)
+	assert.True(t, item.ClipboardItemPinned)
+	assert.Equal(t, int64(5), item.ClipboardItemRevision)
+
+	assert.Equal(t, []int64{2, 1}, list("tag=work"))
+	assert.Equal(t, []int64{3, 2}, list("tag=todo"))
+	assert.Equal(t, []int64{}, list("tag=wor"))
+	assert.Equal(t, []int64{2}, list("pinned=true"))
+	assert.Equal(t, []int64{3, 1}, list("pinned=false"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem?pinned=maybe", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_pinned", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
@@ -0,0 +1,46 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+// unexpiredClipboardItems excludes expired ClipboardItems, which are as
+// good as gone until the sweeper purges them.
+func unexpiredClipboardItems(tx *gorm.DB) *gorm.DB {
+	return tx.Where("(clipboard_items.clipboard_item_expires_time = 0 OR clipboard_items.clipboard_item_expires_time > ?)", utils.GetUnixMillisTimestamp())
+}
+
+// expiredClipboardItems selects only expired ClipboardItems.
+func expiredClipboardItems(tx *gorm.DB) *gorm.DB {
+	return tx.Where("clipboard_items.clipboard_item_expires_time != 0 AND clipboard_items.clipboard_item_expires_time <= ?", utils.GetUnixMillisTimestamp())
+}
+
+// applyClipboardItemTTL lets item expire after the ttl query parameter, a
+// Go duration, unless detection rules let it expire sooner. It reports
+// whether the ttl was valid, reporting errors itself.
+func applyClipboardItemTTL(c *gin.Context, item *ClipboardItem) bool {
+	_ttl := c.Query("ttl")
+	if _ttl == "" {
+		return true
+	}
+	ttl, err := time.ParseDuration(_ttl)
+	if err == nil && ttl <= 0 {
+		err = errors.New("must be positive")
+	}
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidTTL, "Invalid ttl", err)
+		return false
+	}
+
+	expires := utils.GetUnixMillisTimestamp() + ttl.Milliseconds()
+	if item.ClipboardItemExpiresTime == 0 || expires < item.ClipboardItemExpiresTime {
+		item.ClipboardItemExpiresTime = expires
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+func TestInsertClipboardItemTTL(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem?ttl=10m", strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	got := loadJSON(w.Body.String())["ClipboardItem"].(map[string]interface{})
+	assert.InDelta(t, utils.GetUnixMillisTimestamp()+600000, got["ClipboardItemExpiresTime"], 60000)
+
+	for _, ttl := range []string{"a", "-1m", "0"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem?ttl="+ttl, strings.NewReader(dumpJSON(clipboardItemToGinH(preparationClipboardItem()))))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, ttl)
+		assert.Equal(t, "invalid_ttl", loadJSON(w.Body.String())["code"])
+	}
+
+	database.Close()
+}
+
+func TestExpiredClipboardItemNotFound(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	expired := preparationClipboardItem()
+	expired.ClipboardItemExpiresTime = utils.GetUnixMillisTimestamp() - 1
+	database.Orm.Create(&expired)
+	trashed := preparationClipboardItem()
+	trashed.ClipboardItemTime++
+	trashed.ClipboardItemHash = "b"
+	trashed.ClipboardItemDeletedTime = 1
+	trashed.ClipboardItemExpiresTime = 1
+	database.Orm.Create(&trashed)
+	id := fmt.Sprintf("%d", expired.ClipboardItemTime)
+
+	for _, url := range []string{
+		"/api/v1/ClipboardItem/" + id,
+		"/api/v1/ClipboardItem?search=" + expired.ClipboardItemText,
+		"/api/v1/ClipboardItem/count",
+		"/api/v1/trash",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		r.ServeHTTP(w, req)
+		got := loadJSON(w.Body.String())
+		switch {
+		case strings.HasSuffix(url, id):
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		case strings.HasSuffix(url, "count"):
+			assert.Equal(t, float64(0), got["count"])
+		default:
+			assert.Len(t, got["ClipboardItem"], 0, url)
+		}
+	}
+
+	// Copying it again is not a duplicate.
+	again := expired
+	again.ClipboardItemTime = trashed.ClipboardItemTime + 1
+	again.ClipboardItemExpiresTime = 0
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(again))))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	database.Close()
+}
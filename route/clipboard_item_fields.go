@@ -0,0 +1,217 @@
+package route
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+var clipboardItemColumns = map[string]string{
+	"Index":                    "clipboard_items.`index`",
+	"ClipboardItemTime":        "clipboard_items.clipboard_item_time",
+	"ClipboardItemText":        "clipboard_items.clipboard_item_text",
+	"ClipboardItemHash":        "clipboard_items.clipboard_item_hash",
+	"ClipboardItemData":        "clipboard_items.clipboard_item_data",
+	"ClipboardItemSize":        database.ClipboardItemSizeQuery + " AS clipboard_item_size",
+	"ClipboardItemRevision":    "clipboard_items.clipboard_item_revision",
+	"ClipboardItemDeletedTime": "clipboard_items.clipboard_item_deleted_time",
+	"ClipboardItemDevice":      "clipboard_items.clipboard_item_device",
+	"ClipboardItemSourceApp":   "clipboard_items.clipboard_item_source_app",
+	"ClipboardItemWindowTitle": "clipboard_items.clipboard_item_window_title",
+	"ClipboardItemTags":        "clipboard_items.clipboard_item_tags",
+	"ClipboardItemPinned":      "clipboard_items.clipboard_item_pinned",
+	"ClipboardItemSensitive":   "clipboard_items.clipboard_item_sensitive",
+	"ClipboardItemSecret":      "clipboard_items.clipboard_item_secret",
+	"ClipboardItemExpiresTime": "clipboard_items.clipboard_item_expires_time",
+}
+
+var editableClipboardItemFields = []string{
+	"ClipboardItemText",
+	"ClipboardItemData",
+}
+
+func canonicalClipboardItemField(key string) string {
+	for field := range clipboardItemColumns {
+		if strings.EqualFold(field, key) {
+			return field
+		}
+	}
+	return ""
+}
+
+func isEditableClipboardItemField(field string) bool {
+	for _, editable := range editableClipboardItemFields {
+		if field == editable {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeClipboardItemFields validates a JSON object against the editable
+// fields. Keys match case-insensitively like encoding/json, null resets a
+// field. Read-only fields are rejected when partial, and ignored otherwise so
+// a fetched ClipboardItem can be sent back as is.
+func decodeClipboardItemFields(body map[string]json.RawMessage, partial bool) (map[string]string, gin.H) {
+	values := map[string]string{}
+	fieldErrors := gin.H{}
+
+	for key, raw := range body {
+		field := canonicalClipboardItemField(key)
+		if field == "" {
+			fieldErrors[key] = "unknown field"
+			continue
+		}
+		if !isEditableClipboardItemField(field) {
+			if partial {
+				fieldErrors[field] = "field is not editable"
+			}
+			continue
+		}
+
+		var value *string
+		err := json.Unmarshal(raw, &value)
+		if err != nil {
+			fieldErrors[field] = "must be a string"
+			continue
+		}
+		if value == nil {
+			values[field] = ""
+			continue
+		}
+		if field == "ClipboardItemData" {
+			_, err = base64.StdEncoding.DecodeString(*value)
+			if err != nil {
+				fieldErrors[field] = "must be base64"
+				continue
+			}
+		}
+		values[field] = *value
+	}
+
+	if !partial {
+		for _, field := range editableClipboardItemFields {
+			_, ok := values[field]
+			_, failed := fieldErrors[field]
+			if ok || failed {
+				continue
+			}
+			if field == "ClipboardItemData" {
+				fieldErrors[field] = "required"
+				continue
+			}
+			values[field] = ""
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+	return values, nil
+}
+
+// applyClipboardItemFields sets decoded fields on item, ClipboardItemHash
+// follows ClipboardItemData.
+func applyClipboardItemFields(item *ClipboardItem, values map[string]string) {
+	if text, ok := values["ClipboardItemText"]; ok {
+		item.ClipboardItemText = text
+	}
+	if data, ok := values["ClipboardItemData"]; ok && data != item.ClipboardItemData {
+		item.ClipboardItemData = data
+		item.ClipboardItemHash = hashClipboardItemData(data)
+	}
+}
+
+// parseClipboardItemFields parses a comma separated list of ClipboardItem
+// JSON field names, an empty string selects every field.
+func parseClipboardItemFields(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := clipboardItemColumns[field]; !ok {
+			return nil, errors.New("unknown field: " + field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func selectClipboardItemFields(fields []string) []string {
+	if fields == nil {
+		return []string{"clipboard_items.*", clipboardItemColumns["ClipboardItemSize"]}
+	}
+
+	// Encrypted fields are bound to the index, it is read before them.
+	columns := []string{clipboardItemColumns["Index"]}
+	for _, field := range fields {
+		if field != "Index" {
+			columns = append(columns, clipboardItemColumns[field])
+		}
+	}
+	return columns
+}
+
+func projectClipboardItem(item ClipboardItem, fields []string) interface{} {
+	if fields == nil {
+		return item
+	}
+
+	projected := gin.H{}
+	for _, field := range fields {
+		switch field {
+		case "Index":
+			projected[field] = item.Index
+		case "ClipboardItemTime":
+			projected[field] = item.ClipboardItemTime
+		case "ClipboardItemText":
+			projected[field] = item.ClipboardItemText
+		case "ClipboardItemHash":
+			projected[field] = item.ClipboardItemHash
+		case "ClipboardItemData":
+			projected[field] = item.ClipboardItemData
+		case "ClipboardItemSize":
+			projected[field] = item.ClipboardItemSize
+		case "ClipboardItemRevision":
+			projected[field] = item.ClipboardItemRevision
+		case "ClipboardItemDeletedTime":
+			projected[field] = item.ClipboardItemDeletedTime
+		case "ClipboardItemDevice":
+			projected[field] = item.ClipboardItemDevice
+		case "ClipboardItemSourceApp":
+			projected[field] = item.ClipboardItemSourceApp
+		case "ClipboardItemWindowTitle":
+			projected[field] = item.ClipboardItemWindowTitle
+		case "ClipboardItemTags":
+			projected[field] = item.ClipboardItemTags
+		case "ClipboardItemPinned":
+			projected[field] = item.ClipboardItemPinned
+		case "ClipboardItemSensitive":
+			projected[field] = item.ClipboardItemSensitive
+		case "ClipboardItemSecret":
+			projected[field] = item.ClipboardItemSecret
+		case "ClipboardItemExpiresTime":
+			projected[field] = item.ClipboardItemExpiresTime
+		}
+	}
+	return projected
+}
+
+func projectClipboardItems(items []ClipboardItem, fields []string) interface{} {
+	if fields == nil {
+		return items
+	}
+
+	projected := []interface{}{}
+	for _, item := range items {
+		projected = append(projected, projectClipboardItem(item, fields))
+	}
+	return projected
+}
@@ -0,0 +1,37 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClipboardItemFields(t *testing.T) {
+	fields, err := parseClipboardItemFields("")
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+
+	fields, err = parseClipboardItemFields("ClipboardItemTime, ClipboardItemText")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ClipboardItemTime", "ClipboardItemText"}, fields)
+
+	_, err = parseClipboardItemFields("ClipboardItemTime,")
+	assert.Error(t, err)
+
+	_, err = parseClipboardItemFields("1; DROP TABLE clipboard_items")
+	assert.Error(t, err)
+}
+
+func TestSelectClipboardItemFields(t *testing.T) {
+	assert.Equal(t, []string{"clipboard_items.*", clipboardItemColumns["ClipboardItemSize"]}, selectClipboardItemFields(nil))
+	assert.Equal(t, []string{clipboardItemColumns["Index"], clipboardItemColumns["ClipboardItemText"]}, selectClipboardItemFields([]string{"ClipboardItemText"}))
+	assert.Equal(t, []string{clipboardItemColumns["Index"]}, selectClipboardItemFields([]string{"Index"}))
+}
+
+func TestProjectClipboardItem(t *testing.T) {
+	item := preparationClipboardItem()
+
+	assert.Equal(t, item, projectClipboardItem(item, nil))
+	assert.Equal(t, gin.H{"ClipboardItemText": item.ClipboardItemText}, projectClipboardItem(item, []string{"ClipboardItemText"}))
+}
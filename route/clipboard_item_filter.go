@@ -0,0 +1,71 @@
+package route
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// clipboardItemFilter is the filter set shared by listing and bulk operations.
+// Device and SourceApp match exactly, WindowTitle matches a substring and
+// Tag one of the tags. Search is an FTS5 query, or blind tokens that all
+// have to match in end-to-end encrypted Workspaces.
+type clipboardItemFilter struct {
+	StartTimestamp *int64  `json:"startTimestamp"`
+	EndTimestamp   *int64  `json:"endTimestamp"`
+	Search         string  `json:"search"`
+	IDs            []int64 `json:"ids"`
+	Device         string  `json:"device"`
+	SourceApp      string  `json:"sourceApp"`
+	WindowTitle    string  `json:"windowTitle"`
+	Tag            string  `json:"tag"`
+	Pinned         *bool   `json:"pinned"`
+	EndToEnd       bool    `json:"-"`
+}
+
+// empty reports whether the filter matches every ClipboardItem.
+func (filter clipboardItemFilter) empty() bool {
+	return filter.StartTimestamp == nil && filter.EndTimestamp == nil && filter.Search == "" && filter.IDs == nil &&
+		filter.Device == "" && filter.SourceApp == "" && filter.WindowTitle == "" && filter.Tag == "" && filter.Pinned == nil
+}
+
+func (filter clipboardItemFilter) scope(tx *gorm.DB) *gorm.DB {
+	if filter.StartTimestamp != nil {
+		tx = tx.Where("clipboard_items.clipboard_item_time >= ?", *filter.StartTimestamp)
+	}
+	if filter.EndTimestamp != nil {
+		tx = tx.Where("clipboard_items.clipboard_item_time <= ?", *filter.EndTimestamp)
+	}
+	if filter.Search != "" && filter.EndToEnd {
+		for _, token := range strings.Fields(filter.Search) {
+			tx = tx.Where(
+				"clipboard_items.`index` IN (SELECT clipboard_item_index FROM clipboard_item_blind_tokens WHERE blind_token = ?)",
+				token,
+			)
+		}
+	} else if filter.Search != "" {
+		tx = tx.Where(
+			"clipboard_items.`index` IN (SELECT rowid FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ?)",
+			filter.Search,
+		)
+	}
+	if filter.IDs != nil {
+		tx = tx.Where("clipboard_items.clipboard_item_time IN ?", filter.IDs)
+	}
+	if filter.Device != "" {
+		tx = tx.Where("clipboard_items.clipboard_item_device = ?", filter.Device)
+	}
+	if filter.SourceApp != "" {
+		tx = tx.Where("clipboard_items.clipboard_item_source_app = ?", filter.SourceApp)
+	}
+	if filter.WindowTitle != "" {
+		tx = tx.Where("instr(clipboard_items.clipboard_item_window_title, ?) > 0", filter.WindowTitle)
+	}
+	if filter.Tag != "" {
+		tx = tx.Where("instr(',' || clipboard_items.clipboard_item_tags || ',', ',' || ? || ',') > 0", filter.Tag)
+	}
+	if filter.Pinned != nil {
+		tx = tx.Where("clipboard_items.clipboard_item_pinned = ?", *filter.Pinned)
+	}
+	return tx
+}
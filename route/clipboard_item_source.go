@@ -0,0 +1,25 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerDevice      = "X-Clipboard-Archive-Device"
+	headerSourceApp   = "X-Clipboard-Archive-Source-App"
+	headerWindowTitle = "X-Clipboard-Archive-Window-Title"
+)
+
+// fillClipboardItemSource fills where a ClipboardItem was copied from when
+// the body left it out, from the request headers.
+func fillClipboardItemSource(c *gin.Context, item *ClipboardItem) {
+	if item.ClipboardItemDevice == "" {
+		item.ClipboardItemDevice = c.GetHeader(headerDevice)
+	}
+	if item.ClipboardItemSourceApp == "" {
+		item.ClipboardItemSourceApp = c.GetHeader(headerSourceApp)
+	}
+	if item.ClipboardItemWindowTitle == "" {
+		item.ClipboardItemWindowTitle = c.GetHeader(headerWindowTitle)
+	}
+}
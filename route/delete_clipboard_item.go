@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
 	"gorm.io/gorm"
 )
 
@@ -16,45 +17,52 @@ func deleteClipboardItem(c *gin.Context) {
 	_id := c.Params.ByName("id")
 	id, err := strconv.ParseInt(_id, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  http.StatusBadRequest,
-			"message": "Invalid ID",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
 		return
 	}
 
-	err = database.Orm.Where("clipboard_item_time = ?", id).First(&item).Error
+	err = database.Orm.Scopes(ownedClipboardItems(c), liveClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  http.StatusNotFound,
-				"message": "ClipboardItem not found",
-			})
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error deleting ClipboardItem",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting ClipboardItem", err)
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" && !matchETag(ifMatch, clipboardItemETag(item), false) {
+		abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
 		return
 	}
 
-	err = database.Orm.Delete(&item, item.Index).Error
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&item).
+			Where("clipboard_item_revision = ?", item.ClipboardItemRevision).
+			Updates(map[string]interface{}{
+				"clipboard_item_deleted_time": utils.GetUnixMillisTimestamp(),
+				"clipboard_item_revision":     item.ClipboardItemRevision + 1,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClipboardItemModified
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemDeleted, ownerOf(c), workspaceOf(c), id, nil)
+	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  http.StatusNotFound,
-				"message": "ClipboardItem not found",
-			})
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
+			return
+		}
+		if errors.Is(err, errClipboardItemModified) {
+			abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error deleting ClipboardItem",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting ClipboardItem", err)
 		return
 	}
 
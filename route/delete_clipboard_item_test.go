@@ -34,9 +34,44 @@ func TestDeleteClipboardItem(t *testing.T) {
 	got := loadJSON(w.Body.String())
 	assert.Equal(t, expected, got)
 
-	err := database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
+	err := database.Orm.Scopes(liveClipboardItems).Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
 	assert.Error(t, err)
 
+	err = database.Orm.Scopes(trashedClipboardItems).Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), item.ClipboardItemRevision)
+
+	database.Close()
+}
+
+func TestDeleteClipboardItemIfMatchError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	req.Header.Set("If-Match", `"2"`)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusPreconditionFailed,
+		"code":    "clipboard_item_modified",
+		"message": "ClipboardItem has been modified",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	err := database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
+	assert.NoError(t, err)
+
 	database.Close()
 }
 
@@ -53,10 +88,12 @@ func TestDeleteClipboardItemParamsError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_id",
 		"message": "Invalid ID",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -76,10 +113,12 @@ func TestDeleteClipboardItemNotFoundError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_not_found",
 		"message": "ClipboardItem not found",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	assert.Equal(t, expected, got)
 
 	database.Close()
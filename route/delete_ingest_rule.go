@@ -0,0 +1,36 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func deleteIngestRule(c *gin.Context) {
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	result := database.Orm.Delete(&database.IngestRule{}, id)
+	if result.Error != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting IngestRule", result.Error)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		abortWithError(c, http.StatusNotFound, codeIngestRuleNotFound, "IngestRule not found", nil)
+		return
+	}
+
+	reloadIngestRules()
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"message": "IngestRule deleted successfully",
+		"Index":   id,
+	})
+}
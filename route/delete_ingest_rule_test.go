@@ -0,0 +1,37 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestDeleteIngestRule(t *testing.T) {
+	var count int64
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.IngestRule{IngestRuleType: "max_size", IngestRuleValue: "1024"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/api/v1/admin/ingest-rules/1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(1), loadJSON(w.Body.String())["Index"])
+
+	database.Orm.Model(&database.IngestRule{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/api/v1/admin/ingest-rules/1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "ingest_rule_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
@@ -0,0 +1,46 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// deleteWebhook removes a webhook together with its delivery log.
+func deleteWebhook(c *gin.Context) {
+	var deleted int64
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	err = database.Orm.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("webhook_owner = ? AND webhook_workspace = ?", ownerOf(c), workspaceOf(c)).Delete(&database.Webhook{}, id)
+		deleted = result.RowsAffected
+		if result.Error != nil || deleted == 0 {
+			return result.Error
+		}
+		return tx.Where("webhook_index = ?", id).Delete(&database.WebhookDelivery{}).Error
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting Webhook", err)
+		return
+	}
+
+	if deleted == 0 {
+		abortWithError(c, http.StatusNotFound, codeWebhookNotFound, "Webhook not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"message": "Webhook deleted successfully",
+		"Index":   id,
+	})
+}
@@ -0,0 +1,41 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestDeleteWebhook(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	webhook := database.Webhook{WebhookURL: "http://127.0.0.1/hook", WebhookSecret: "secret"}
+	database.Orm.Create(&webhook)
+	database.Orm.Create(&database.WebhookDelivery{WebhookIndex: webhook.Index, WebhookDeliveryState: webhookDeliveryPending})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/webhooks/%d", webhook.Index), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var count int64
+	database.Orm.Model(&database.WebhookDelivery{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/v1/webhooks/%d", webhook.Index), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "webhook_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// deleteWorkspace removes an empty Workspace together with its webhooks,
+// change feed and device pushes. ClipboardItems, trash included, have to
+// be purged first.
+func deleteWorkspace(c *gin.Context) {
+	var count int64
+
+	workspace, err := database.FindWorkspace(ownerOf(c), c.Params.ByName("workspace"))
+	if err != nil {
+		if errors.Is(err, database.ErrWorkspaceNotFound) {
+			abortWithError(c, http.StatusNotFound, codeWorkspaceNotFound, "Workspace not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting Workspace", err)
+		return
+	}
+
+	err = database.Orm.Model(&ClipboardItem{}).Where("clipboard_item_workspace = ?", workspace.Index).Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting Workspace", err)
+		return
+	}
+	if count > 0 {
+		abortWithDetails(c, http.StatusConflict, codeWorkspaceNotEmpty, "Workspace is not empty", gin.H{
+			"count": count,
+		})
+		return
+	}
+
+	err = database.Orm.Transaction(func(tx *gorm.DB) error {
+		webhooks := tx.Model(&database.Webhook{}).Select("`index`").Where("webhook_workspace = ?", workspace.Index)
+		err := tx.Where("webhook_index IN (?)", webhooks).Delete(&database.WebhookDelivery{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("webhook_workspace = ?", workspace.Index).Delete(&database.Webhook{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("clipboard_item_workspace = ?", workspace.Index).Delete(&database.ClipboardItemChange{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("device_push_workspace = ?", workspace.Index).Delete(&database.DevicePush{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&workspace).Error
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error deleting Workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        http.StatusOK,
+		"message":       "Workspace deleted successfully",
+		"WorkspaceName": workspace.WorkspaceName,
+	})
+}
@@ -0,0 +1,69 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestDeleteWorkspace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	work := database.Workspace{WorkspaceName: "work"}
+	database.Orm.Create(&work)
+	webhook := database.Webhook{WebhookURL: "http://127.0.0.1/hook", WebhookWorkspace: work.Index}
+	database.Orm.Create(&webhook)
+	database.Orm.Create(&database.WebhookDelivery{WebhookIndex: webhook.Index, WebhookDeliveryState: webhookDeliveryPending})
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1, DevicePushWorkspace: work.Index})
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1})
+
+	item := preparationClipboardItem()
+	item.ClipboardItemWorkspace = work.Index
+	item.ClipboardItemDeletedTime = 1
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/api/v1/workspaces/work", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	response := loadJSON(w.Body.String())
+	assert.Equal(t, "workspace_not_empty", response["code"])
+	assert.Equal(t, float64(1), response["details"].(map[string]interface{})["count"])
+
+	database.Orm.Unscoped().Where("1 = 1").Delete(&ClipboardItem{})
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/api/v1/workspaces/work", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "work", loadJSON(w.Body.String())["WorkspaceName"])
+
+	var count int64
+	database.Orm.Model(&database.Workspace{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Orm.Model(&database.Webhook{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Orm.Model(&database.WebhookDelivery{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Orm.Model(&database.DevicePush{}).Where("device_push_workspace = ?", work.Index).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Orm.Model(&database.DevicePush{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/api/v1/workspaces/work", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "workspace_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
@@ -0,0 +1,217 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+// What happens to a ClipboardItem a detection rule matches. Reject refuses
+// it, redact replaces the matches in ClipboardItemText before it is stored
+// and indexed, secret keeps it out of search and expire deletes it after
+// ExpireMinutes. ClipboardItemData is kept as copied, so it still pastes.
+const (
+	DetectionActionReject = "reject"
+	DetectionActionRedact = "redact"
+	DetectionActionSecret = "secret"
+	DetectionActionExpire = "expire"
+)
+
+// DetectionRule flags ClipboardItemText matching Pattern. Rules without a
+// Pattern use the built-in one of the same Name.
+type DetectionRule struct {
+	Name          string `json:"name"`
+	Pattern       string `json:"pattern"`        // regular expression
+	Luhn          bool   `json:"luhn"`           // the digits of a match have to pass the Luhn check
+	Action        string `json:"action"`         // reject, redact, secret or expire
+	ExpireMinutes int64  `json:"expire_minutes"` // for expire
+}
+
+var builtinDetectionPatterns = []struct {
+	name    string
+	pattern string
+	luhn    bool
+}{
+	{"private_key", `-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`, false},
+	{"aws_access_key", `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`, false},
+	{"github_token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,251}|github_pat_[A-Za-z0-9_]{82})\b`, false},
+	{"slack_token", `\bxox[abprs]-[A-Za-z0-9-]{10,}`, false},
+	{"api_key", `\bsk-[A-Za-z0-9_-]{20,}`, false},
+	{"jwt", `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, false},
+	{"password", `(?i)\b(?:password|passwd|pwd)\s*[:=]\s*\S+`, false},
+	{"card_number", `\b\d(?:[ -]?\d){12,18}\b`, true},
+}
+
+type detector struct {
+	DetectionRule
+	re *regexp.Regexp
+}
+
+var detectors []detector
+
+func init() {
+	err := SetDetectionRules(DefaultDetectionRules())
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DefaultDetectionRules are the built-in rules, marking what they match as
+// secret.
+func DefaultDetectionRules() []DetectionRule {
+	rules := []DetectionRule{}
+	for _, builtin := range builtinDetectionPatterns {
+		rules = append(rules, DetectionRule{Name: builtin.name, Action: DetectionActionSecret})
+	}
+	return rules
+}
+
+// SetDetectionRules replaces the rules every inserted ClipboardItem is
+// checked against, no rules turn detection off.
+func SetDetectionRules(rules []DetectionRule) error {
+	compiled := []detector{}
+	for _, rule := range rules {
+		if rule.Name == "" || strings.Contains(rule.Name, ",") {
+			return fmt.Errorf("detection rule name %q must be non-empty and without commas", rule.Name)
+		}
+		if rule.Pattern == "" {
+			for _, builtin := range builtinDetectionPatterns {
+				if builtin.name == rule.Name {
+					rule.Pattern = builtin.pattern
+					rule.Luhn = rule.Luhn || builtin.luhn
+				}
+			}
+			if rule.Pattern == "" {
+				return fmt.Errorf("detection rule %s: no pattern and no built-in of that name", rule.Name)
+			}
+		}
+		switch rule.Action {
+		case DetectionActionReject, DetectionActionRedact, DetectionActionSecret:
+		case DetectionActionExpire:
+			if rule.ExpireMinutes <= 0 {
+				return fmt.Errorf("detection rule %s: expire needs expire_minutes", rule.Name)
+			}
+		default:
+			return fmt.Errorf("detection rule %s: unknown action %q", rule.Name, rule.Action)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("detection rule %s: %w", rule.Name, err)
+		}
+		compiled = append(compiled, detector{DetectionRule: rule, re: re})
+	}
+	detectors = compiled
+	return nil
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		digit := int(s[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// matches returns the spans of text the detector flags.
+func (d detector) matches(text string) [][]int {
+	spans := [][]int{}
+	for _, span := range d.re.FindAllStringIndex(text, -1) {
+		if d.Luhn && !luhn(text[span[0]:span[1]]) {
+			continue
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// redact replaces spans of text, in order and not overlapping, with a
+// placeholder naming the rule.
+func redact(text string, spans [][]int, name string) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		b.WriteString(text[last:span[0]])
+		b.WriteString("[REDACTED:" + name + "]")
+		last = span[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// checkSensitiveContent runs the detection rules on item, applying their
+// actions, and reports whether it may be stored, reporting errors itself.
+// Rules only ever shorten the expiry item already has. The server cannot read ClipboardItems of end-to-end encrypted Workspaces,
+// so they are not checked.
+func checkSensitiveContent(c *gin.Context, item *ClipboardItem) bool {
+	item.ClipboardItemSensitive = ""
+	item.ClipboardItemSecret = false
+	if endToEndOf(c) {
+		return true
+	}
+
+	rejected := detectSensitiveContent(item)
+	if len(rejected) > 0 {
+		rejectedTotal.Inc(codeSensitiveContent)
+		abortWithDetails(c, http.StatusUnprocessableEntity, codeSensitiveContent, "ClipboardItem looks sensitive", gin.H{"rules": rejected})
+		return false
+	}
+	return true
+}
+
+// detectSensitiveContent applies the actions of the detection rules
+// matching item and returns the names of those rejecting it. Flags and
+// expiry item already has are only ever made stricter.
+func detectSensitiveContent(item *ClipboardItem) []string {
+	flagged := []string{}
+	seen := map[string]bool{}
+	if item.ClipboardItemSensitive != "" {
+		flagged = strings.Split(item.ClipboardItemSensitive, ",")
+	}
+	for _, name := range flagged {
+		seen[name] = true
+	}
+	rejected := []string{}
+	for _, d := range detectors {
+		spans := d.matches(item.ClipboardItemText)
+		if len(spans) == 0 {
+			continue
+		}
+		if !seen[d.Name] {
+			seen[d.Name] = true
+			flagged = append(flagged, d.Name)
+		}
+		switch d.Action {
+		case DetectionActionReject:
+			rejected = append(rejected, d.Name)
+		case DetectionActionRedact:
+			item.ClipboardItemText = redact(item.ClipboardItemText, spans, d.Name)
+		case DetectionActionSecret:
+			item.ClipboardItemSecret = true
+		case DetectionActionExpire:
+			expires := utils.GetUnixMillisTimestamp() + (time.Duration(d.ExpireMinutes) * time.Minute).Milliseconds()
+			if item.ClipboardItemExpiresTime == 0 || expires < item.ClipboardItemExpiresTime {
+				item.ClipboardItemExpiresTime = expires
+			}
+		}
+	}
+	item.ClipboardItemSensitive = strings.Join(flagged, ",")
+	return rejected
+}
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"sync"
+	"time"
+)
+
+// DevicePushTTL is how long a pushed ClipboardItem waits for its device by
+// default, and the longest a push may ask for.
+var DevicePushTTL = 24 * time.Hour
+
+// devicePushLease is how long a pulled push is hidden before it is pulled
+// again, unless it is acknowledged.
+const devicePushLease = time.Minute
+
+// devicePullMaxWait caps the wait of a long-polling pull.
+const devicePullMaxWait = time.Minute
+
+// deviceKey names a device, device names are only unique per owner.
+type deviceKey struct {
+	owner  int64
+	device string
+}
+
+// devicePushSignals wakes up pulls waiting on a device.
+type devicePushSignals struct {
+	mu      sync.Mutex
+	waiting map[deviceKey]chan struct{}
+}
+
+var devicePushes = &devicePushSignals{waiting: map[deviceKey]chan struct{}{}}
+
+// wait returns a channel that is closed on the next push to device. Get it
+// before looking for pushes so one arriving in between is not missed.
+func (signals *devicePushSignals) wait(owner int64, device string) <-chan struct{} {
+	signals.mu.Lock()
+	defer signals.mu.Unlock()
+
+	key := deviceKey{owner, device}
+	ch, ok := signals.waiting[key]
+	if !ok {
+		ch = make(chan struct{})
+		signals.waiting[key] = ch
+	}
+	return ch
+}
+
+func (signals *devicePushSignals) notify(owner int64, device string) {
+	signals.mu.Lock()
+	defer signals.mu.Unlock()
+
+	key := deviceKey{owner, device}
+	if ch, ok := signals.waiting[key]; ok {
+		close(ch)
+		delete(signals.waiting, key)
+	}
+}
@@ -0,0 +1,171 @@
+package route
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes are stable, clients should match on them instead of messages.
+const (
+	codeInternalError              = "internal_error"
+	codeRouteNotFound              = "route_not_found"
+	codeInvalidJSON                = "invalid_json"
+	codeInvalidID                  = "invalid_id"
+	codeInvalidLimit               = "invalid_limit"
+	codeInvalidStartTimestamp      = "invalid_start_timestamp"
+	codeInvalidEndTimestamp        = "invalid_end_timestamp"
+	codeInvalidTop                 = "invalid_top"
+	codeInvalidFields              = "invalid_fields"
+	codeInvalidClipboardItemFields = "invalid_clipboard_item_fields"
+	codeInvalidRevision            = "invalid_revision"
+	codeInvalidAction              = "invalid_action"
+	codeInvalidTag                 = "invalid_tag"
+	codeInvalidPinned              = "invalid_pinned"
+	codeFilterRequired             = "filter_required"
+	codeClipboardItemNotFound      = "clipboard_item_not_found"
+	codeRevisionNotFound           = "clipboard_item_revision_not_found"
+	codeTrashItemNotFound          = "trash_item_not_found"
+	codeClipboardItemExists        = "clipboard_item_exists"
+	codeClipboardItemModified      = "clipboard_item_modified"
+	codeConfirmationRequired       = "confirmation_required"
+	codeInvalidTypes               = "invalid_types"
+	codeInvalidLastEventID         = "invalid_last_event_id"
+	codeInvalidWebhookURL          = "invalid_webhook_url"
+	codeInvalidWebhookEvents       = "invalid_webhook_events"
+	codeInvalidWebhookSearch       = "invalid_webhook_search"
+	codeWebhookNotFound            = "webhook_not_found"
+	codeInvalidSince               = "invalid_since"
+	codeInvalidChange              = "invalid_change"
+	codeInvalidTTL                 = "invalid_ttl"
+	codeInvalidWait                = "invalid_wait"
+	codeDevicePushNotFound         = "device_push_not_found"
+	codeUnauthorized               = "unauthorized"
+	codeUserDisabled               = "user_disabled"
+	codeInvalidWorkspaceName       = "invalid_workspace_name"
+	codeWorkspaceNotFound          = "workspace_not_found"
+	codeWorkspaceExists            = "workspace_exists"
+	codeWorkspaceNotEmpty          = "workspace_not_empty"
+	codeInvalidBlindTokens         = "invalid_blind_tokens"
+	codeEndToEndEncrypted          = "end_to_end_encrypted"
+	codeSensitiveContent           = "sensitive_content"
+	codeAdminRequired              = "admin_required"
+	codeInvalidIngestRule          = "invalid_ingest_rule"
+	codeIngestRuleNotFound         = "ingest_rule_not_found"
+	codeIngestRejected             = "ingest_rejected"
+	codeRateLimited                = "rate_limited"
+	codeRequestTooLarge            = "request_too_large"
+	codeInvalidStatus              = "invalid_status"
+)
+
+const requestIDKey = "request_id"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// apiError is the error every route reports, errorHandler renders it.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Details gin.H
+	Err     error
+}
+
+func (e *apiError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *apiError) Unwrap() error {
+	return e.Err
+}
+
+// abortWithError stops the handler chain with an apiError. err is shown to
+// clients only for 4xx responses.
+func abortWithError(c *gin.Context, status int, code string, message string, err error) {
+	c.Abort()
+	// Public keeps gin's logger quiet, errorHandler logs what matters.
+	_ = c.Error(&apiError{Status: status, Code: code, Message: message, Err: err}).SetType(gin.ErrorTypePublic)
+}
+
+// abortWithDetails is abortWithError with a details object in the response.
+func abortWithDetails(c *gin.Context, status int, code string, message string, details gin.H) {
+	c.Abort()
+	_ = c.Error(&apiError{Status: status, Code: code, Message: message, Details: details}).SetType(gin.ErrorTypePublic)
+}
+
+// requestID tags each request with an ID, reusing a sane X-Request-ID.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			b := make([]byte, 8)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// errorHandler renders the last error of a request as the error envelope.
+// Internal errors are logged and redacted.
+func errorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		var e *apiError
+		var tooLarge *http.MaxBytesError
+		last := c.Errors.Last().Err
+		if errors.As(last, &tooLarge) {
+			e = &apiError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Code:    codeRequestTooLarge,
+				Message: "Request body too large",
+				Details: gin.H{"limit": tooLarge.Limit},
+			}
+		} else if !errors.As(last, &e) {
+			e = &apiError{
+				Status:  http.StatusInternalServerError,
+				Code:    codeInternalError,
+				Message: "Internal server error",
+				Err:     last,
+			}
+		}
+
+		id := c.GetString(requestIDKey)
+		response := gin.H{
+			"status":     e.Status,
+			"code":       e.Code,
+			"message":    e.Message,
+			"request_id": id,
+		}
+		if e.Details != nil {
+			response["details"] = e.Details
+		}
+		if e.Status >= http.StatusInternalServerError {
+			if e.Err != nil {
+				log.Printf("request %s: %s: %v", id, e.Message, e.Err)
+			}
+		} else if e.Err != nil {
+			response["error"] = e.Err.Error()
+		}
+		c.JSON(e.Status, response)
+	}
+}
+
+func routeNotFound(c *gin.Context) {
+	abortWithError(c, http.StatusNotFound, codeRouteNotFound, "Route not found", nil)
+}
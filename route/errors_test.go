@@ -0,0 +1,80 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestErrorHandlerRedactsInternalError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := SetupRouter()
+
+	database.OpenNoDatabase()
+	defer database.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/1", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	got := loadJSON(w.Body.String())
+	assert.NotContains(t, got, "error")
+	assert.Equal(t, "internal_error", got["code"])
+	assert.Equal(t, w.Header().Get("X-Request-ID"), got["request_id"])
+	assert.NotEmpty(t, got["request_id"])
+}
+
+func TestErrorHandlerRequestID(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/a", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, "abc-123", got["request_id"])
+	assert.Contains(t, got, "error")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ping", nil)
+	req.Header.Set("X-Request-ID", "not a valid id")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Header().Get("X-Request-ID"), 16)
+
+	database.Close()
+}
+
+func TestRouteNotFound(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/nope", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusNotFound,
+		"code":    "route_not_found",
+		"message": "Route not found",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+}
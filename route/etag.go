@@ -0,0 +1,44 @@
+package route
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func clipboardItemETag(item ClipboardItem) string {
+	return fmt.Sprintf(`"%d"`, item.ClipboardItemRevision)
+}
+
+// collectionETag is a weak ETag over the JSON encoding of v.
+func collectionETag(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(`W/"%x"`, sha256.Sum256(b))
+}
+
+// matchETag reports whether an If-Match or If-None-Match header value
+// lists etag. With weak, as for If-None-Match, W/ is ignored on both sides;
+// otherwise, as for If-Match, only equal strong ETags match.
+func matchETag(header string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipboardItemETag(t *testing.T) {
+	item := preparationClipboardItem()
+	item.ClipboardItemRevision = 3
+	assert.Equal(t, `"3"`, clipboardItemETag(item))
+}
+
+func TestCollectionETag(t *testing.T) {
+	a := collectionETag([]int{1})
+	assert.Equal(t, a, collectionETag([]int{1}))
+	assert.NotEqual(t, a, collectionETag([]int{2}))
+}
+
+func TestMatchETag(t *testing.T) {
+	assert.True(t, matchETag(`"1"`, `"1"`, true))
+	assert.True(t, matchETag(`"2", "1"`, `"1"`, true))
+	assert.True(t, matchETag(`*`, `"1"`, true))
+	assert.True(t, matchETag(`W/"1"`, `"1"`, true))
+	assert.True(t, matchETag(`"1"`, `W/"1"`, true))
+	assert.False(t, matchETag(`"2"`, `"1"`, true))
+	assert.False(t, matchETag(``, `"1"`, true))
+	assert.False(t, matchETag(`*`, ``, true))
+
+	assert.True(t, matchETag(`"1"`, `"1"`, false))
+	assert.True(t, matchETag(`"2", "1"`, `"1"`, false))
+	assert.True(t, matchETag(`*`, `"1"`, false))
+	assert.False(t, matchETag(`W/"1"`, `"1"`, false))
+	assert.False(t, matchETag(`"1"`, `W/"1"`, false))
+	assert.False(t, matchETag(`"2"`, `"1"`, false))
+}
@@ -0,0 +1,134 @@
+package route
+
+import (
+	"sync"
+
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+const (
+	eventClipboardItemCreated  = "ClipboardItem.created"
+	eventClipboardItemUpdated  = "ClipboardItem.updated"
+	eventClipboardItemDeleted  = "ClipboardItem.deleted"
+	eventClipboardItemRestored = "ClipboardItem.restored"
+	// eventReset tells a resuming client that events were lost and it
+	// has to fetch ClipboardItems again.
+	eventReset = "reset"
+)
+
+// eventBacklog is how many events are kept for Last-Event-ID resume.
+const eventBacklog = 1024
+
+// eventSubscriberBuffer is how many events a subscriber may fall behind
+// before it is dropped.
+const eventSubscriberBuffer = 64
+
+type event struct {
+	ID                uint64         `json:"id"`
+	Type              string         `json:"type"`
+	Time              int64          `json:"time"` // unix milliseconds timestamp
+	ClipboardItemTime int64          `json:"ClipboardItemTime"`
+	ClipboardItem     *ClipboardItem `json:"ClipboardItem,omitempty"`
+	Owner             int64          `json:"-"` // Index of the User it belongs to
+	Workspace         int64          `json:"-"` // Index of the Workspace it belongs to
+}
+
+// eventBus fans ClipboardItem changes out to live subscribers and keeps a
+// backlog so they can resume after reconnecting.
+type eventBus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	backlog     []event
+	subscribers map[chan event]struct{}
+}
+
+var clipboardItemEvents = newEventBus()
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: map[chan event]struct{}{}}
+}
+
+// publish records an event, item is nil for events without a ClipboardItem body.
+func (bus *eventBus) publish(typ string, owner int64, workspace int64, itemTime int64, item *ClipboardItem) event {
+	e := bus.next(typ, owner, workspace, itemTime, item, 0)
+	bus.broadcast(e)
+	return e
+}
+
+// next makes the event that follows the last one broadcast and pending
+// more, which are yet to be broadcast.
+func (bus *eventBus) next(typ string, owner int64, workspace int64, itemTime int64, item *ClipboardItem, pending int) event {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if item != nil {
+		copied := *item
+		item = &copied
+	}
+	return event{
+		ID:                bus.lastID + uint64(pending) + 1,
+		Type:              typ,
+		Time:              utils.GetUnixMillisTimestamp(),
+		ClipboardItemTime: itemTime,
+		ClipboardItem:     item,
+		Owner:             owner,
+		Workspace:         workspace,
+	}
+}
+
+// broadcast keeps e in the backlog and hands it to the subscribers.
+func (bus *eventBus) broadcast(e event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.lastID = e.ID
+	bus.backlog = append(bus.backlog, e)
+	if len(bus.backlog) > eventBacklog {
+		bus.backlog = bus.backlog[len(bus.backlog)-eventBacklog:]
+	}
+
+	for ch := range bus.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// A subscriber this far behind resumes with Last-Event-ID.
+			delete(bus.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the events after lastID and a channel of the events
+// that follow. ok is false when events after lastID are no longer known.
+func (bus *eventBus) subscribe(lastID uint64, resume bool) (missed []event, ch chan event, ok bool) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	ok = true
+	if resume {
+		switch {
+		case lastID > bus.lastID:
+			ok = false
+		case lastID < bus.lastID:
+			if len(bus.backlog) == 0 || bus.backlog[0].ID > lastID+1 {
+				ok = false
+			} else {
+				missed = append(missed, bus.backlog[len(bus.backlog)-int(bus.lastID-lastID):]...)
+			}
+		}
+	}
+
+	ch = make(chan event, eventSubscriberBuffer)
+	bus.subscribers[ch] = struct{}{}
+	return missed, ch, ok
+}
+
+func (bus *eventBus) unsubscribe(ch chan event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if _, ok := bus.subscribers[ch]; ok {
+		delete(bus.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,88 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusResume(t *testing.T) {
+	bus := newEventBus()
+	for i := int64(1); i <= 3; i++ {
+		bus.publish(eventClipboardItemCreated, 0, 0, i, nil)
+	}
+
+	missed, ch, ok := bus.subscribe(1, true)
+	assert.True(t, ok)
+	assert.Len(t, missed, 2)
+	assert.Equal(t, uint64(2), missed[0].ID)
+	assert.Equal(t, int64(3), missed[1].ClipboardItemTime)
+	bus.unsubscribe(ch)
+
+	missed, ch, ok = bus.subscribe(3, true)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+	bus.unsubscribe(ch)
+
+	_, ch, ok = bus.subscribe(4, true)
+	assert.False(t, ok)
+	bus.unsubscribe(ch)
+
+	missed, ch, ok = bus.subscribe(0, false)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+
+	e := bus.publish(eventClipboardItemDeleted, 0, 0, 1, nil)
+	assert.Equal(t, e, <-ch)
+	bus.unsubscribe(ch)
+}
+
+func TestEventBusBacklog(t *testing.T) {
+	bus := newEventBus()
+	for i := 0; i < eventBacklog+2; i++ {
+		bus.publish(eventClipboardItemCreated, 0, 0, int64(i), nil)
+	}
+
+	_, ch, ok := bus.subscribe(0, true)
+	assert.False(t, ok)
+	bus.unsubscribe(ch)
+
+	missed, ch, ok := bus.subscribe(2, true)
+	assert.True(t, ok)
+	assert.Len(t, missed, eventBacklog)
+	bus.unsubscribe(ch)
+}
+
+func TestEventBusNext(t *testing.T) {
+	bus := newEventBus()
+	bus.publish(eventClipboardItemCreated, 0, 0, 1, nil)
+	_, ch, _ := bus.subscribe(0, false)
+
+	first := bus.next(eventClipboardItemUpdated, 0, 0, 1, nil, 0)
+	second := bus.next(eventClipboardItemDeleted, 0, 0, 1, nil, 1)
+	assert.Equal(t, uint64(2), first.ID)
+	assert.Equal(t, uint64(3), second.ID)
+	assert.Empty(t, ch)
+
+	bus.broadcast(first)
+	bus.broadcast(second)
+	assert.Equal(t, first, <-ch)
+	assert.Equal(t, second, <-ch)
+	bus.unsubscribe(ch)
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	bus := newEventBus()
+	_, ch, _ := bus.subscribe(0, false)
+
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		bus.publish(eventClipboardItemCreated, 0, 0, int64(i), nil)
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	assert.Equal(t, eventSubscriberBuffer, received)
+	bus.unsubscribe(ch)
+}
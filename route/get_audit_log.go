@@ -0,0 +1,90 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// getAuditLog lists the audit log, newest first, filtered by User name,
+// client IP, route, ClipboardItem, status and time.
+func getAuditLog(c *gin.Context) {
+	var limit int
+	var count int64
+	var err error
+
+	_limit := c.Query("limit")
+	if _limit == "" {
+		limit = 100
+	} else {
+		limit, err = strconv.Atoi(_limit)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
+			return
+		}
+	}
+
+	tx := database.Orm.Model(&database.AuditEntry{})
+	if user := c.Query("user"); user != "" {
+		tx = tx.Where("audit_entry_user_name = ?", user)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		tx = tx.Where("audit_entry_client_ip = ?", ip)
+	}
+	if route := c.Query("route"); route != "" {
+		tx = tx.Where("audit_entry_route = ?", route)
+	}
+	if _id := c.Query("ClipboardItem"); _id != "" {
+		id, err := strconv.ParseInt(_id, 10, 64)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+			return
+		}
+		tx = tx.Where("',' || audit_entry_clipboard_items || ',' LIKE ?", "%,"+strconv.FormatInt(id, 10)+",%")
+	}
+	if _status := c.Query("status"); _status != "" {
+		status, err := strconv.Atoi(_status)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidStatus, "Invalid status", err)
+			return
+		}
+		tx = tx.Where("audit_entry_status = ?", status)
+	}
+	if _startTimestamp := c.Query("startTimestamp"); _startTimestamp != "" {
+		startTimestamp, err := strconv.ParseInt(_startTimestamp, 10, 64)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidStartTimestamp, "Invalid startTimestamp", err)
+			return
+		}
+		tx = tx.Where("audit_entry_time >= ?", startTimestamp)
+	}
+	if _endTimestamp := c.Query("endTimestamp"); _endTimestamp != "" {
+		endTimestamp, err := strconv.ParseInt(_endTimestamp, 10, 64)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidEndTimestamp, "Invalid endTimestamp", err)
+			return
+		}
+		tx = tx.Where("audit_entry_time <= ?", endTimestamp)
+	}
+
+	err = tx.Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting AuditEntries", err)
+		return
+	}
+	entries := []database.AuditEntry{}
+	err = tx.Order("`index` desc").Limit(limit).Find(&entries).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting AuditEntries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     http.StatusOK,
+		"count":      count,
+		"message":    "AuditEntries found successfully",
+		"AuditEntry": entries,
+	})
+}
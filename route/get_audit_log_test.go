@@ -0,0 +1,61 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetAuditLog(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.AuditEntry{AuditEntryTime: 1000, AuditEntryUserName: "alice", AuditEntryClientIP: "203.0.113.7", AuditEntryRoute: "/api/v1/ClipboardItem/:id", AuditEntryClipboardItems: "12", AuditEntryStatus: 200})
+	database.Orm.Create(&database.AuditEntry{AuditEntryTime: 2000, AuditEntryUserName: "bob", AuditEntryClientIP: "203.0.113.8", AuditEntryRoute: "/api/v1/ClipboardItem", AuditEntryClipboardItems: "11,123", AuditEntryStatus: 200})
+	database.Orm.Create(&database.AuditEntry{AuditEntryTime: 3000, AuditEntryClientIP: "203.0.113.9", AuditEntryRoute: "/api/v1/ClipboardItem/:id", AuditEntryStatus: 401})
+
+	for query, times := range map[string][]float64{
+		"":                                       {3000, 2000, 1000},
+		"?limit=1":                               {3000},
+		"?user=alice":                            {1000},
+		"?ip=203.0.113.8":                        {2000},
+		"?route=/api/v1/ClipboardItem/:id":       {3000, 1000},
+		"?ClipboardItem=12":                      {1000},
+		"?ClipboardItem=123":                     {2000},
+		"?status=401":                            {3000},
+		"?startTimestamp=1500&endTimestamp=2500": {2000},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/admin/audit-log"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		got := []float64{}
+		for _, entry := range loadJSON(w.Body.String())["AuditEntry"].([]interface{}) {
+			got = append(got, entry.(map[string]interface{})["AuditEntryTime"].(float64))
+		}
+		assert.Equal(t, times, got, query)
+	}
+
+	for query, code := range map[string]string{
+		"?limit=a":          "invalid_limit",
+		"?ClipboardItem=a":  "invalid_id",
+		"?status=a":         "invalid_status",
+		"?startTimestamp=a": "invalid_start_timestamp",
+		"?endTimestamp=a":   "invalid_end_timestamp",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/admin/audit-log"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Equal(t, code, loadJSON(w.Body.String())["code"], query)
+	}
+
+	database.Close()
+}
@@ -0,0 +1,77 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/replication"
+)
+
+// getChanges lists the changes after the since sequence, oldest first. Only
+// the latest change of every ClipboardItem is kept, deleted and purged ones
+// stay as tombstones without a ClipboardItem.
+func getChanges(c *gin.Context) {
+	var since int64
+	var limit int
+	var err error
+
+	_since := c.Query("since")
+	_limit := c.Query("limit")
+
+	if _since != "" {
+		since, err = strconv.ParseInt(_since, 10, 64)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidSince, "Invalid since", err)
+			return
+		}
+	}
+
+	if _limit == "" {
+		limit = 100
+	} else {
+		limit, err = strconv.Atoi(_limit)
+		if err != nil || limit <= 0 {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
+			return
+		}
+	}
+
+	fields, err := parseClipboardItemFields(c.Query("fields"))
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidFields, "Invalid fields", err)
+		return
+	}
+
+	changes, next, more, err := replication.Changes(database.Orm, ownerOf(c), workspaceOf(c), since, limit)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting changes", err)
+		return
+	}
+
+	rendered := []gin.H{}
+	for _, change := range changes {
+		r := gin.H{
+			"ClipboardItemChangeSeq":  change.ClipboardItemChangeSeq,
+			"ClipboardItemTime":       change.ClipboardItemTime,
+			"ClipboardItemChangeType": change.ClipboardItemChangeType,
+			"ClipboardItemChangeTime": change.ClipboardItemChangeTime,
+		}
+		if change.ClipboardItem != nil {
+			auditClipboardItems(c, change.ClipboardItemTime)
+			r["ClipboardItem"] = projectClipboardItem(ClipboardItem(*change.ClipboardItem), fields)
+		}
+		rendered = append(rendered, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":              http.StatusOK,
+		"message":             "Changes found successfully",
+		"since":               since,
+		"next":                next,
+		"more":                more,
+		"count":               len(rendered),
+		"ClipboardItemChange": rendered,
+	})
+}
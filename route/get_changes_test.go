@@ -0,0 +1,97 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetChanges(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	database.Orm.Create(&item)
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 2
+	database.Orm.Create(&item2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/api/v1/ClipboardItem/1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/changes?limit=1&fields=ClipboardItemText", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusOK,
+		"message": "Changes found successfully",
+		"since":   0,
+		"next":    2,
+		"more":    true,
+		"count":   1,
+		"ClipboardItemChange": []gin.H{{
+			"ClipboardItemChangeSeq":  2,
+			"ClipboardItemTime":       2,
+			"ClipboardItemChangeType": "created",
+			"ClipboardItem":           gin.H{"ClipboardItemText": item2.ClipboardItemText},
+		}},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got["ClipboardItemChange"].([]interface{})[0].(map[string]interface{}), "ClipboardItemChangeTime")
+	assert.Equal(t, expected, got)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/changes?since=%d", 2), nil)
+	r.ServeHTTP(w, req)
+
+	got = loadJSON(w.Body.String())
+	change := got["ClipboardItemChange"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(3), got["next"])
+	assert.Equal(t, false, got["more"])
+	assert.Equal(t, "deleted", change["ClipboardItemChangeType"])
+	assert.NotContains(t, change, "ClipboardItem")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/changes?since=3", nil)
+	r.ServeHTTP(w, req)
+
+	got = loadJSON(w.Body.String())
+	assert.Equal(t, float64(3), got["next"])
+	assert.Equal(t, float64(0), got["count"])
+
+	database.Close()
+}
+
+func TestGetChangesQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for query, code := range map[string]string{
+		"since=a":  "invalid_since",
+		"limit=0":  "invalid_limit",
+		"fields=a": "invalid_fields",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/changes?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, code, loadJSON(w.Body.String())["code"])
+	}
+
+	database.Close()
+}
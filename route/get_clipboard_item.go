@@ -3,6 +3,7 @@ package route
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/used255/clipboard_archive/v3/database"
@@ -19,12 +20,24 @@ func getClipboardItem(c *gin.Context) {
 	_endTimestamp := c.Query("endTimestamp")
 	_limit := c.Query("limit")
 	search := c.Query("search")
+	_fields := c.Query("fields")
+	device := c.Query("device")
+	sourceApp := c.Query("sourceApp")
+	windowTitle := c.Query("windowTitle")
+	tag := c.Query("tag")
+	_pinned := c.Query("pinned")
 
 	requestedForm := gin.H{
 		"startTimestamp": _startTimestamp,
 		"endTimestamp":   _endTimestamp,
 		"limit":          _limit,
 		"search":         search,
+		"fields":         _fields,
+		"device":         device,
+		"sourceApp":      sourceApp,
+		"windowTitle":    windowTitle,
+		"tag":            tag,
+		"pinned":         _pinned,
 	}
 
 	items := []ClipboardItem{}
@@ -36,79 +49,82 @@ func getClipboardItem(c *gin.Context) {
 	} else {
 		limit, err = strconv.Atoi(_limit)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  http.StatusBadRequest,
-				"message": "Invalid limit",
-				"error":   err.Error(),
-			})
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
 			return
 		}
 	}
 
-	tx := database.Orm.Order("clipboard_item_time desc")
+	fields, err := parseClipboardItemFields(_fields)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidFields, "Invalid fields", err)
+		return
+	}
+
+	filter := clipboardItemFilter{
+		Search:      search,
+		Device:      device,
+		SourceApp:   sourceApp,
+		WindowTitle: windowTitle,
+		Tag:         tag,
+		EndToEnd:    endToEndOf(c),
+	}
+
+	if _pinned != "" {
+		pinned, err := strconv.ParseBool(_pinned)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidPinned, "Invalid pinned", err)
+			return
+		}
+		filter.Pinned = &pinned
+	}
 
 	if _startTimestamp != "" {
 		startTimestamp, err = strconv.ParseInt(_startTimestamp, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  http.StatusBadRequest,
-				"message": "Invalid startTimestamp",
-				"error":   err.Error(),
-			})
+			abortWithError(c, http.StatusBadRequest, codeInvalidStartTimestamp, "Invalid startTimestamp", err)
 			return
 		}
-		tx.Where("clipboard_item_time >= ?", startTimestamp)
+		filter.StartTimestamp = &startTimestamp
 	}
 
 	if _endTimestamp != "" {
 		endTimestamp, err = strconv.ParseInt(_endTimestamp, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  http.StatusBadRequest,
-				"message": "Invalid endTimestamp",
-				"error":   err.Error(),
-			})
+			abortWithError(c, http.StatusBadRequest, codeInvalidEndTimestamp, "Invalid endTimestamp", err)
 			return
 		}
-		tx.Where("clipboard_item_time <= ?", endTimestamp)
+		filter.EndTimestamp = &endTimestamp
+	}
+
+	queryStartTime := time.Now()
+	tx := database.Orm.Model(&items).Scopes(ownedClipboardItems(c), liveClipboardItems, filter.scope)
+	err = tx.Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting ClipboardItem", err)
+		return
 	}
 
+	err = tx.
+		Select(selectClipboardItemFields(fields)).
+		Order("clipboard_item_time desc").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting ClipboardItem", err)
+		return
+	}
 	if search != "" {
-		//log.Println("Searching for: " + search)
-		//tx.Debug()
-		tx.
-			Table("clipboard_items_fts").
-			Where("clipboard_items_fts MATCH ?", search).
-			Joins("NATURAL JOIN clipboard_items").
-			Count(&count)
-		if tx.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  http.StatusInternalServerError,
-				"message": "Error getting ClipboardItem",
-				"error":   tx.Error.Error(),
-			})
-			return
-		}
-		tx.Limit(limit).Scan(&items)
-	} else {
-		tx.Model(&items).Count(&count)
-		if tx.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  http.StatusInternalServerError,
-				"message": "Error getting ClipboardItem",
-				"error":   tx.Error.Error(),
-			})
-			return
-		}
-		tx.Limit(limit).Find(&items)
+		searchDuration.Observe(time.Since(queryStartTime).Seconds())
 	}
 
-	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error getting ClipboardItem",
-			"error":   tx.Error.Error(),
-		})
+	for _, item := range items {
+		auditClipboardItems(c, item.ClipboardItemTime)
+	}
+	projectedItems := projectClipboardItems(items, fields)
+	etag := collectionETag(gin.H{"count": count, "ClipboardItem": projectedItems})
+	c.Header("ETag", etag)
+	if matchETag(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -121,6 +137,6 @@ func getClipboardItem(c *gin.Context) {
 		"function_start_time": functionStartTime,
 		"function_end_time":   functionEndTime,
 		"message":             "ClipboardItem found successfully",
-		"ClipboardItem":       items,
+		"ClipboardItem":       projectedItems,
 	})
 }
@@ -11,12 +11,9 @@ import (
 func getClipboardItemCount(c *gin.Context) {
 	var count int64
 
-	database.Orm.Model(&ClipboardItem{}).Count(&count)
-	if database.Orm.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": database.Orm.Error.Error(),
-		})
+	err := database.Orm.Scopes(ownedClipboardItems(c), liveClipboardItems).Model(&ClipboardItem{}).Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error counting ClipboardItem", err)
 		return
 	}
 
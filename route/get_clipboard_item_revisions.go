@@ -0,0 +1,50 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+func getClipboardItemRevisions(c *gin.Context) {
+	var item ClipboardItem
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	err = database.Orm.Scopes(ownedClipboardItems(c), liveClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting ClipboardItem revisions", err)
+		return
+	}
+
+	revisions := []database.ClipboardItemRevision{}
+	err = database.Orm.
+		Where("clipboard_item_index = ?", item.Index).
+		Order("clipboard_item_revision desc").
+		Find(&revisions).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting ClipboardItem revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                 http.StatusOK,
+		"message":                "ClipboardItem revisions found successfully",
+		"count":                  len(revisions),
+		"ClipboardItemRevision":  item.ClipboardItemRevision,
+		"ClipboardItemRevisions": revisions,
+	})
+}
@@ -0,0 +1,95 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetClipboardItemRevisions(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(`{"ClipboardItemText": "test"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem/%d/revisions", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, "ClipboardItem revisions found successfully", got["message"])
+	assert.Equal(t, float64(1), got["count"])
+	assert.Equal(t, float64(2), got["ClipboardItemRevision"])
+	revisions := got["ClipboardItemRevisions"].([]interface{})
+	assert.Len(t, revisions, 1)
+	revision := revisions[0].(map[string]interface{})
+	assert.Equal(t, float64(1), revision["ClipboardItemRevision"])
+	assert.Equal(t, item.ClipboardItemText, revision["ClipboardItemText"])
+	assert.Equal(t, item.ClipboardItemData, revision["ClipboardItemData"])
+
+	database.Close()
+}
+
+func TestGetClipboardItemRevisionsParamsError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/a/revisions", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_id",
+		"message": "Invalid ID",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestGetClipboardItemRevisionsNotFoundError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/1/revisions", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_not_found",
+		"message": "ClipboardItem not found",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
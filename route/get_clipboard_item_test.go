@@ -32,6 +32,12 @@ func TestGetClipboardItems(t *testing.T) {
 		"endTimestamp":   "",
 		"limit":          "",
 		"search":         "",
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -49,6 +55,44 @@ func TestGetClipboardItems(t *testing.T) {
 	database.Close()
 }
 
+func TestGetClipboardItemsIfNoneMatch(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem", nil)
+	req.Header.Set("If-None-Match", etag)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 1
+	database.Orm.Create(&item2)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem", nil)
+	req.Header.Set("If-None-Match", etag)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	database.Close()
+}
+
 func TestGetClipboardItemsStartTimestampQuery(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	database.Open("file::memory:?cache=shared")
@@ -70,6 +114,12 @@ func TestGetClipboardItemsStartTimestampQuery(t *testing.T) {
 		"endTimestamp":   "",
 		"limit":          "",
 		"search":         "",
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -103,10 +153,12 @@ func TestGetClipboardItemsStartTimestampQueryError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_start_timestamp",
 		"message": "Invalid startTimestamp",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -134,6 +186,12 @@ func TestGetClipboardItemsEndTimeStampQuery(t *testing.T) {
 		"endTimestamp":   "1844674407370955161",
 		"limit":          "",
 		"search":         "",
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -167,10 +225,12 @@ func TestGetClipboardItemsEndTimeStampQueryError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_end_timestamp",
 		"message": "Invalid endTimestamp",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -201,6 +261,12 @@ func TestGetClipboardItemsLimitQuery(t *testing.T) {
 		"endTimestamp":   "",
 		"limit":          "1",
 		"search":         "",
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -234,10 +300,12 @@ func TestGetClipboardItemsLimitQueryError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_limit",
 		"message": "Invalid limit",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -265,6 +333,12 @@ func TestGetClipboardItemsSearchQuery(t *testing.T) {
 		"endTimestamp":   "",
 		"limit":          "",
 		"search":         item.ClipboardItemText,
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -303,6 +377,60 @@ func TestGetClipboardItemsAllQuery(t *testing.T) {
 		"endTimestamp":   "1844674407370955161",
 		"limit":          "1",
 		"search":         item.ClipboardItemText,
+		"fields":         "",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
+	}
+	expected := gin.H{
+		"status":         http.StatusOK,
+		"requested_form": requestedForm,
+		"count":          1,
+		"message":        "ClipboardItem found successfully",
+		"ClipboardItem":  items,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "function_start_time")
+	delete(got, "function_end_time")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestGetClipboardItemsFieldsQuery(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem?fields=ClipboardItemTime,ClipboardItemSize&search=%s", item.ClipboardItemText), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	items := []gin.H{
+		{
+			"ClipboardItemTime": item.ClipboardItemTime,
+			"ClipboardItemSize": len(item.ClipboardItemData),
+		},
+	}
+	requestedForm := gin.H{
+		"startTimestamp": "",
+		"endTimestamp":   "",
+		"limit":          "",
+		"search":         item.ClipboardItemText,
+		"fields":         "ClipboardItemTime,ClipboardItemSize",
+		"device":         "",
+		"sourceApp":      "",
+		"windowTitle":    "",
+		"tag":            "",
+		"pinned":         "",
 	}
 	expected := gin.H{
 		"status":         http.StatusOK,
@@ -319,3 +447,28 @@ func TestGetClipboardItemsAllQuery(t *testing.T) {
 
 	database.Close()
 }
+
+func TestGetClipboardItemsFieldsQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem?fields=clipboard_item_data", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_fields",
+		"message": "Invalid fields",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
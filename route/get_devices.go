@@ -0,0 +1,31 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// getDevices lists the devices ClipboardItems were copied on, most
+// recently seen first.
+func getDevices(c *gin.Context) {
+	devices := []database.Device{}
+
+	err := database.Orm.
+		Select("devices.*, (SELECT count(*) FROM clipboard_items WHERE clipboard_item_owner = devices.device_owner AND clipboard_item_workspace = ? AND clipboard_item_device = devices.device_name AND clipboard_item_deleted_time = 0) AS clipboard_item_count", workspaceOf(c)).
+		Where("device_owner = ?", ownerOf(c)).
+		Order("device_last_seen_time desc").
+		Find(&devices).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting Devices", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"count":   len(devices),
+		"message": "Devices found successfully",
+		"Device":  devices,
+	})
+}
@@ -0,0 +1,53 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetDevices(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for i, device := range []string{"laptop", "desktop", "laptop"} {
+		item := preparationClipboardItem()
+		item.ClipboardItemTime = int64(i + 1)
+		item.ClipboardItemDevice = device
+		database.Orm.Create(&item)
+	}
+	database.Orm.Model(&ClipboardItem{}).Where("clipboard_item_time = 3").Update("clipboard_item_deleted_time", 10)
+	database.TouchDevice(database.Orm, 0, "laptop", 10)
+	database.TouchDevice(database.Orm, 0, "desktop", 20)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/devices", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusOK,
+		"count":   2,
+		"message": "Devices found successfully",
+		"Device": []gin.H{
+			{"DeviceName": "desktop", "DeviceFirstSeenTime": 20, "DeviceLastSeenTime": 20, "ClipboardItemCount": 1},
+			{"DeviceName": "laptop", "DeviceFirstSeenTime": 10, "DeviceLastSeenTime": 10, "ClipboardItemCount": 1},
+		},
+	}
+	assert.Equal(t, reloadJSON(expected), loadJSON(w.Body.String()))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem?device=laptop", nil)
+	r.ServeHTTP(w, req)
+
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(1), got["count"])
+
+	database.Close()
+}
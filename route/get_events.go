@@ -0,0 +1,215 @@
+package route
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// eventHeartbeat keeps idle SSE connections from being closed by proxies.
+var eventHeartbeat = 15 * time.Second
+
+type eventFilter struct {
+	owner     int64
+	workspace int64
+	types     map[string]bool
+	fields    []string
+}
+
+func (filter eventFilter) match(e event) bool {
+	if e.Type == eventReset {
+		return true
+	}
+	return e.Owner == filter.owner && e.Workspace == filter.workspace && (filter.types == nil || filter.types[e.Type])
+}
+
+func (filter eventFilter) render(e event) gin.H {
+	rendered := gin.H{
+		"id":                e.ID,
+		"type":              e.Type,
+		"time":              e.Time,
+		"ClipboardItemTime": e.ClipboardItemTime,
+	}
+	if e.ClipboardItem != nil {
+		rendered["ClipboardItem"] = projectClipboardItem(*e.ClipboardItem, filter.fields)
+	}
+	return rendered
+}
+
+// parseEventRequest reads the filters and resume position shared by the
+// SSE and WebSocket endpoints, reporting errors itself.
+func parseEventRequest(c *gin.Context) (filter eventFilter, lastID uint64, resume bool, ok bool) {
+	var err error
+
+	filter.owner = ownerOf(c)
+	filter.workspace = workspaceOf(c)
+	_types := c.Query("types")
+	if _types != "" {
+		filter.types = map[string]bool{}
+		for _, typ := range strings.Split(_types, ",") {
+			typ = strings.TrimSpace(typ)
+			if !isClipboardItemEventType(typ) {
+				abortWithError(c, http.StatusBadRequest, codeInvalidTypes, "Invalid types", errors.New("unknown event type: "+typ))
+				return filter, 0, false, false
+			}
+			filter.types[typ] = true
+		}
+	}
+
+	filter.fields, err = parseClipboardItemFields(c.Query("fields"))
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidFields, "Invalid fields", err)
+		return filter, 0, false, false
+	}
+
+	_lastID := c.GetHeader("Last-Event-ID")
+	if _lastID == "" {
+		_lastID = c.Query("lastEventId")
+	}
+	if _lastID != "" {
+		lastID, err = strconv.ParseUint(_lastID, 10, 64)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLastEventID, "Invalid Last-Event-ID", err)
+			return filter, 0, false, false
+		}
+		resume = true
+	}
+	return filter, lastID, resume, true
+}
+
+// resumeEvents lists the events a client has to receive before live ones.
+func resumeEvents(missed []event, ok bool) []event {
+	if !ok {
+		return []event{{Type: eventReset}}
+	}
+	return missed
+}
+
+// getEvents streams ClipboardItem changes as Server-Sent Events.
+func getEvents(c *gin.Context) {
+	filter, lastID, resume, ok := parseEventRequest(c)
+	if !ok {
+		return
+	}
+
+	missed, ch, ok := clipboardItemEvents.subscribe(lastID, resume)
+	defer clipboardItemEvents.unsubscribe(ch)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(e event) error {
+		if !filter.match(e) {
+			return nil
+		}
+		b, err := json.Marshal(filter.render(e))
+		if err != nil {
+			return err
+		}
+		if e.ID != 0 {
+			_, err = fmt.Fprintf(c.Writer, "id: %d\n", e.ID)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.Type, b)
+		return err
+	}
+
+	for _, e := range resumeEvents(missed, ok) {
+		if write(e) != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+		case e, open := <-ch:
+			if !open {
+				return
+			}
+			if write(e) != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// getEventsWebSocket sends ClipboardItem changes as JSON WebSocket messages.
+func getEventsWebSocket(c *gin.Context) {
+	filter, lastID, resume, ok := parseEventRequest(c)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{Handshake: checkWebSocketOrigin, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		missed, ch, ok := clipboardItemEvents.subscribe(lastID, resume)
+		defer clipboardItemEvents.unsubscribe(ch)
+
+		// Clients only listen, a read returning means the connection is gone.
+		closed := make(chan struct{})
+		go func() {
+			var message string
+			for websocket.Message.Receive(ws, &message) == nil {
+			}
+			close(closed)
+		}()
+
+		for _, e := range resumeEvents(missed, ok) {
+			if filter.match(e) && websocket.JSON.Send(ws, filter.render(e)) != nil {
+				return
+			}
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case e, open := <-ch:
+				if !open {
+					return
+				}
+				if filter.match(e) && websocket.JSON.Send(ws, filter.render(e)) != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkWebSocketOrigin refuses handshakes from pages on another origin,
+// browsers attach cookies and basic auth to cross-site WebSocket requests.
+// Clients that send no Origin are not browsers and are let through.
+func checkWebSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	config.Origin = origin
+	if origin != nil && !strings.EqualFold(origin.Host, req.Host) {
+		return fmt.Errorf("cross-origin WebSocket handshake from %s", origin)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package route
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"golang.org/x/net/websocket"
+)
+
+// readServerSentEvent reads one event, skipping heartbeats.
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) (id string, typ string, data string) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && typ != "":
+			return id, typ, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func insertClipboardItemOverHTTP(t *testing.T, url string, item ClipboardItem) {
+	itemReq := clipboardItemToGinH(item)
+	delete(itemReq, "Index")
+	resp, err := http.Post(url+"/api/v1/ClipboardItem", "application/json", strings.NewReader(dumpJSON(itemReq)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestGetEvents(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	clipboardItemEvents = newEventBus()
+	server := httptest.NewServer(SetupRouter())
+	defer server.Close()
+	client := http.Client{Timeout: 5 * time.Second}
+	spec := loadOpenAPISpec(t)
+	eventSchema := resolveOpenAPIRef(spec, "#/components/schemas/Event")
+
+	resp, err := client.Get(server.URL + "/api/v1/events?types=ClipboardItem.created&fields=ClipboardItemText")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	item := preparationClipboardItem()
+	insertClipboardItemOverHTTP(t, server.URL, item)
+
+	id, typ, data := readServerSentEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	assert.Equal(t, "1", id)
+	assert.Equal(t, eventClipboardItemCreated, typ)
+	got := loadJSON(data)
+	assert.Empty(t, validateOpenAPISchema(spec, eventSchema, map[string]interface{}(got), "event"))
+	assert.Equal(t, map[string]interface{}{"ClipboardItemText": item.ClipboardItemText}, got["ClipboardItem"])
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/ClipboardItem/%d", server.URL, item.ClipboardItemTime), nil)
+	deleteResp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteResp.Body.Close()
+
+	req, _ = http.NewRequest("GET", server.URL+"/api/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, typ, data = readServerSentEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	assert.Equal(t, "2", id)
+	assert.Equal(t, eventClipboardItemDeleted, typ)
+	assert.Equal(t, float64(item.ClipboardItemTime), loadJSON(data)["ClipboardItemTime"])
+
+	req.Header.Set("Last-Event-ID", "9")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, typ, _ = readServerSentEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	assert.Equal(t, eventReset, typ)
+
+	database.Close()
+}
+
+func TestGetEventsQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/events?types=a", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_types", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/events/ws", nil)
+	req.Header.Set("Last-Event-ID", "a")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_last_event_id", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
+
+func TestGetEventsWebSocket(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	clipboardItemEvents = newEventBus()
+	server := httptest.NewServer(SetupRouter())
+	defer server.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/events/ws", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	item := preparationClipboardItem()
+	insertClipboardItemOverHTTP(t, server.URL, item)
+
+	var got gin.H
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	err = websocket.JSON.Receive(ws, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, eventClipboardItemCreated, got["type"])
+	assert.Equal(t, float64(item.ClipboardItemTime), got["ClipboardItemTime"])
+	assert.Equal(t, item.ClipboardItemText, got["ClipboardItem"].(map[string]interface{})["ClipboardItemText"])
+
+	database.Close()
+}
+
+func TestGetEventsWebSocketCrossOrigin(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	clipboardItemEvents = newEventBus()
+	server := httptest.NewServer(SetupRouter())
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/events/ws"
+	_, err := websocket.Dial(url, "", "http://evil.example")
+	assert.Error(t, err)
+
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+
+	database.Close()
+}
@@ -0,0 +1,25 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func getIngestRules(c *gin.Context) {
+	rules := []database.IngestRule{}
+
+	err := database.Orm.Order("`index`").Find(&rules).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting IngestRules", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     http.StatusOK,
+		"count":      len(rules),
+		"message":    "IngestRules found successfully",
+		"IngestRule": rules,
+	})
+}
@@ -0,0 +1,33 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetIngestRules(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.IngestRule{IngestRuleType: "max_size", IngestRuleValue: "1024"})
+	database.Orm.Create(&database.IngestRule{IngestRuleType: "source_app_deny", IngestRuleValue: "KeePassXC"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/admin/ingest-rules", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(2), got["count"])
+	rules := got["IngestRule"].([]interface{})
+	assert.Equal(t, "max_size", rules[0].(map[string]interface{})["IngestRuleType"])
+	assert.Equal(t, "KeePassXC", rules[1].(map[string]interface{})["IngestRuleValue"])
+
+	database.Close()
+}
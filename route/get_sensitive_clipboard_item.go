@@ -0,0 +1,74 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// sensitiveReportFields leave out what was flagged, the report says where
+// secrets were copied without repeating them.
+var sensitiveReportFields = []string{
+	"ClipboardItemTime",
+	"ClipboardItemSize",
+	"ClipboardItemDevice",
+	"ClipboardItemSourceApp",
+	"ClipboardItemWindowTitle",
+	"ClipboardItemSensitive",
+	"ClipboardItemSecret",
+	"ClipboardItemExpiresTime",
+}
+
+// getSensitiveClipboardItem lists the live ClipboardItems detection rules
+// flagged on insert, newest first.
+func getSensitiveClipboardItem(c *gin.Context) {
+	var limit int
+	var count int64
+	var err error
+
+	_limit := c.Query("limit")
+
+	items := []ClipboardItem{}
+
+	if _limit == "" {
+		limit = 100
+	} else {
+		limit, err = strconv.Atoi(_limit)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
+			return
+		}
+	}
+
+	tx := database.Orm.
+		Scopes(ownedClipboardItems(c), liveClipboardItems).
+		Model(&items).
+		Where("clipboard_items.clipboard_item_sensitive != ''")
+	err = tx.Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting sensitive ClipboardItems", err)
+		return
+	}
+
+	err = tx.
+		Select(selectClipboardItemFields(sensitiveReportFields)).
+		Order("clipboard_item_time desc").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting sensitive ClipboardItems", err)
+		return
+	}
+	for _, item := range items {
+		auditClipboardItems(c, item.ClipboardItemTime)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        http.StatusOK,
+		"count":         count,
+		"message":       "Sensitive ClipboardItems found successfully",
+		"ClipboardItem": projectClipboardItems(items, sensitiveReportFields),
+	})
+}
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetSensitiveClipboardItem(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	plain := preparationClipboardItem()
+	secret := preparationClipboardItem()
+	secret.ClipboardItemTime++
+	secret.ClipboardItemText = "export GITHUB_TOKEN=ghp_" + strings.Repeat("a", 36)
+	secret.ClipboardItemData = toBase64(secret.ClipboardItemText)
+	secret.ClipboardItemHash = toSha256(secret.ClipboardItemData)
+	for _, item := range []ClipboardItem{plain, secret} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+		req.Header.Set("X-Clipboard-Archive-Device", "laptop")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/sensitive", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(1), got["count"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"ClipboardItemTime":        float64(secret.ClipboardItemTime),
+		"ClipboardItemSize":        float64(len(secret.ClipboardItemData)),
+		"ClipboardItemDevice":      "laptop",
+		"ClipboardItemSourceApp":   "",
+		"ClipboardItemWindowTitle": "",
+		"ClipboardItemSensitive":   "github_token",
+		"ClipboardItemSecret":      true,
+		"ClipboardItemExpiresTime": float64(0),
+	}}, got["ClipboardItem"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/sensitive?limit=a", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_limit", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
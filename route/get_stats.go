@@ -0,0 +1,109 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+type dailyStat struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+	Size  int64  `json:"size"`
+}
+
+type growthStat struct {
+	Date  string `json:"date"`
+	Total int64  `json:"total"`
+}
+
+type recopiedItem struct {
+	Index             int64  `json:"-"` // read so ClipboardItemText can be decrypted
+	ClipboardItemTime int64  `json:"ClipboardItemTime"`
+	ClipboardItemText string `gorm:"serializer:encrypted_text" json:"ClipboardItemText"`
+	CopyCount         int64  `json:"copy_count"` // times copied, the first copy included
+	LastCopyTime      int64  `json:"last_copy_time"`
+}
+
+func getStats(c *gin.Context) {
+	var top int
+	var totalCount int64
+	var totalSize int64
+	var averageSize int64
+	var err error
+
+	_top := c.Query("top")
+
+	if _top == "" {
+		top = 10
+	} else {
+		top, err = strconv.Atoi(_top)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidTop, "Invalid top", err)
+			return
+		}
+	}
+
+	dailyStats := []database.ClipboardItemDailyStat{}
+	err = database.Orm.Where("owner = ? AND workspace = ?", ownerOf(c), workspaceOf(c)).Order("day").Find(&dailyStats).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting stats", err)
+		return
+	}
+
+	hourlyStats := []database.ClipboardItemHourlyStat{}
+	err = database.Orm.Where("owner = ? AND workspace = ?", ownerOf(c), workspaceOf(c)).Find(&hourlyStats).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting stats", err)
+		return
+	}
+
+	recopiedItems := []recopiedItem{}
+	err = database.Orm.
+		Table("clipboard_item_recopies").
+		Select("clipboard_items.`index`, clipboard_items.clipboard_item_time, clipboard_items.clipboard_item_text, clipboard_item_recopies.copy_count + 1 AS copy_count, clipboard_item_recopies.last_copy_time").
+		Joins("JOIN clipboard_items ON clipboard_items.clipboard_item_owner = clipboard_item_recopies.owner AND clipboard_items.clipboard_item_workspace = clipboard_item_recopies.workspace AND clipboard_items.clipboard_item_hash = clipboard_item_recopies.clipboard_item_hash").
+		Scopes(ownedClipboardItems(c), liveClipboardItems).
+		Order("clipboard_item_recopies.copy_count desc").
+		Limit(top).
+		Scan(&recopiedItems).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting stats", err)
+		return
+	}
+
+	daily := []dailyStat{}
+	growth := []growthStat{}
+	for _, s := range dailyStats {
+		date := time.UnixMilli(s.Day * 86400000).UTC().Format("2006-01-02")
+		totalCount += s.ItemCount
+		totalSize += s.TotalSize
+		daily = append(daily, dailyStat{Date: date, Count: s.ItemCount, Size: s.TotalSize})
+		growth = append(growth, growthStat{Date: date, Total: totalCount})
+	}
+	if totalCount > 0 {
+		averageSize = totalSize / totalCount
+	}
+
+	hourOfWeek := make([]int64, 168)
+	for _, s := range hourlyStats {
+		if s.HourOfWeek >= 0 && s.HourOfWeek < 168 {
+			hourOfWeek[s.HourOfWeek] = s.ItemCount
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       http.StatusOK,
+		"message":      "Stats found successfully",
+		"total_count":  totalCount,
+		"total_size":   totalSize,
+		"average_size": averageSize,
+		"daily":        daily,
+		"growth":       growth,
+		"hour_of_week": hourOfWeek,
+		"top_recopied": recopiedItems,
+	})
+}
@@ -0,0 +1,172 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetStats(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 345600000 // 1970-01-05, Monday
+	database.Orm.Create(&item)
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 345600000 + 3600000
+	database.Orm.Create(&item2)
+	item3 := preparationClipboardItem()
+	item3.ClipboardItemTime = 432000000 + 3600000
+	database.Orm.Create(&item3)
+	database.Orm.Delete(&item3)
+
+	// Copied again twice, then sent again, which is no copy
+	itemReq := clipboardItemToGinH(item)
+	delete(itemReq, "Index")
+	for _, copyTime := range []int64{item.ClipboardItemTime + 60000, item.ClipboardItemTime + 120000, item.ClipboardItemTime} {
+		itemReq["ClipboardItemTime"] = copyTime
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(itemReq)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/stats", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	totalSize := len(item.ClipboardItemData) + len(item2.ClipboardItemData)
+	hourOfWeek := make([]int, 168)
+	hourOfWeek[0] = 1
+	hourOfWeek[1] = 1
+	expected := gin.H{
+		"status":       http.StatusOK,
+		"message":      "Stats found successfully",
+		"total_count":  2,
+		"total_size":   totalSize,
+		"average_size": totalSize / 2,
+		"daily": []gin.H{
+			{"date": "1970-01-05", "count": 2, "size": totalSize},
+		},
+		"growth": []gin.H{
+			{"date": "1970-01-05", "total": 2},
+		},
+		"hour_of_week": hourOfWeek,
+		"top_recopied": []gin.H{
+			{
+				"ClipboardItemTime": item.ClipboardItemTime,
+				"ClipboardItemText": item.ClipboardItemText,
+				"copy_count":        3,
+				"last_copy_time":    item.ClipboardItemTime + 120000,
+			},
+		},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestGetStatsTopQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/stats?top=a", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_top",
+		"message": "Invalid top",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestGetStatsDatabaseError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := SetupRouter()
+
+	database.OpenNoDatabase()
+	defer database.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/stats", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusInternalServerError,
+		"code":    "internal_error",
+		"message": "Error getting stats",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+}
+
+func TestGetStatsEncrypted(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	s, _ := database.GenerateEncryptionKey()
+	key, _ := database.ParseEncryptionKey(s)
+	assert.NoError(t, database.SetEncryptionKeys(key, nil, false))
+
+	item := preparationClipboardItem()
+	itemReq := clipboardItemToGinH(item)
+	delete(itemReq, "Index")
+	for i := 0; i < 2; i++ {
+		itemReq["ClipboardItemTime"] = item.ClipboardItemTime + int64(i)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(itemReq)))
+		r.ServeHTTP(w, req)
+	}
+	var stored ClipboardItem
+	database.Orm.First(&stored)
+	assert.Equal(t, database.KeyClipboardItemHash(item.ClipboardItemHash), stored.ClipboardItemHash)
+	assert.NotEqual(t, item.ClipboardItemHash, stored.ClipboardItemHash)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/stats", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(len(item.ClipboardItemData)), got["total_size"])
+	recopied := got["top_recopied"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, item.ClipboardItemText, recopied["ClipboardItemText"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem?fields=ClipboardItemSize", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	items := loadJSON(w.Body.String())["ClipboardItem"].([]interface{})
+	assert.Equal(t, float64(len(item.ClipboardItemData)), items[0].(map[string]interface{})["ClipboardItemSize"])
+
+	database.SetEncryptionKeys(nil, nil, false)
+	database.Close()
+}
@@ -0,0 +1,56 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func getTrashClipboardItem(c *gin.Context) {
+	var limit int
+	var count int64
+	var err error
+
+	_limit := c.Query("limit")
+
+	items := []ClipboardItem{}
+
+	if _limit == "" {
+		limit = 100
+	} else {
+		limit, err = strconv.Atoi(_limit)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
+			return
+		}
+	}
+
+	tx := database.Orm.Scopes(ownedClipboardItems(c), trashedClipboardItems).Model(&items)
+	err = tx.Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting trash", err)
+		return
+	}
+
+	err = tx.
+		Select(selectClipboardItemFields(nil)).
+		Order("clipboard_item_deleted_time desc").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting trash", err)
+		return
+	}
+	for _, item := range items {
+		auditClipboardItems(c, item.ClipboardItemTime)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        http.StatusOK,
+		"count":         count,
+		"message":       "Trash found successfully",
+		"ClipboardItem": items,
+	})
+}
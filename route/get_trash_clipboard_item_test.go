@@ -0,0 +1,106 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetTrashClipboardItem(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 1
+	database.Orm.Create(&item2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/trash", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	database.Orm.Scopes(trashedClipboardItems).First(&item)
+	expected := gin.H{
+		"status":        http.StatusOK,
+		"count":         1,
+		"message":       "Trash found successfully",
+		"ClipboardItem": []ClipboardItem{item},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestGetTrashClipboardItemHidden(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, url := range []string{
+		"/api/v1/ClipboardItem",
+		fmt.Sprintf("/api/v1/ClipboardItem?search=%s", item.ClipboardItemText),
+		"/api/v1/ClipboardItem/count",
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", url, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(0), loadJSON(w.Body.String())["count"], url)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	database.Close()
+}
+
+func TestGetTrashClipboardItemLimitQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/trash?limit=a", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_limit",
+		"message": "Invalid limit",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
@@ -0,0 +1,63 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// getWebhookDeliveries lists the delivery log of a webhook, newest first.
+func getWebhookDeliveries(c *gin.Context) {
+	var webhook database.Webhook
+	var limit int
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	_limit := c.Query("limit")
+	if _limit == "" {
+		limit = 100
+	} else {
+		limit, err = strconv.Atoi(_limit)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidLimit, "Invalid limit", err)
+			return
+		}
+	}
+
+	err = database.Orm.Where("webhook_owner = ? AND webhook_workspace = ?", ownerOf(c), workspaceOf(c)).First(&webhook, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeWebhookNotFound, "Webhook not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting Webhook deliveries", err)
+		return
+	}
+
+	deliveries := []database.WebhookDelivery{}
+	err = database.Orm.
+		Where("webhook_index = ?", id).
+		Order("`index` desc").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting Webhook deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":          http.StatusOK,
+		"count":           len(deliveries),
+		"message":         "Webhook deliveries found successfully",
+		"WebhookDelivery": deliveries,
+	})
+}
@@ -0,0 +1,43 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	webhook := database.Webhook{WebhookURL: "http://127.0.0.1/hook", WebhookSecret: "secret"}
+	database.Orm.Create(&webhook)
+	for i := 0; i < 3; i++ {
+		database.Orm.Create(&database.WebhookDelivery{WebhookIndex: webhook.Index, WebhookDeliveryState: webhookDeliveryPending})
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/webhooks/%d/deliveries?limit=2", webhook.Index), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(2), got["count"])
+	deliveries := got["WebhookDelivery"].([]interface{})
+	assert.Equal(t, float64(3), deliveries[0].(map[string]interface{})["Index"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/webhooks/%d/deliveries?limit=a", webhook.Index), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	database.Close()
+}
@@ -0,0 +1,30 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func getWebhooks(c *gin.Context) {
+	webhooks := []database.Webhook{}
+
+	err := database.Orm.Where("webhook_owner = ? AND webhook_workspace = ?", ownerOf(c), workspaceOf(c)).Order("`index`").Find(&webhooks).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting Webhooks", err)
+		return
+	}
+
+	// Secrets are only shown on creation.
+	for i := range webhooks {
+		webhooks[i].WebhookSecret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"count":   len(webhooks),
+		"message": "Webhooks found successfully",
+		"Webhook": webhooks,
+	})
+}
@@ -0,0 +1,44 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetWebhooks(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	webhook := database.Webhook{WebhookURL: "http://127.0.0.1/hook", WebhookSecret: "secret", WebhookCreatedTime: 1}
+	database.Orm.Create(&webhook)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/webhooks", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusOK,
+		"count":   1,
+		"message": "Webhooks found successfully",
+		"Webhook": []gin.H{{
+			"Index":              webhook.Index,
+			"WebhookURL":         "http://127.0.0.1/hook",
+			"WebhookEvents":      "",
+			"WebhookSearch":      "",
+			"WebhookCreatedTime": 1,
+		}},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
@@ -0,0 +1,31 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// getWorkspaces lists the named Workspaces of the User, the default one is
+// not listed.
+func getWorkspaces(c *gin.Context) {
+	workspaces := []database.Workspace{}
+
+	err := database.Orm.
+		Select("workspaces.*", "(SELECT count(*) FROM clipboard_items WHERE clipboard_item_owner = workspaces.workspace_owner AND clipboard_item_workspace = workspaces.`index` AND clipboard_item_deleted_time = 0) AS clipboard_item_count").
+		Where("workspace_owner = ?", ownerOf(c)).
+		Order("workspace_name").
+		Find(&workspaces).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error getting Workspaces", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    http.StatusOK,
+		"count":     len(workspaces),
+		"message":   "Workspaces found successfully",
+		"Workspace": workspaces,
+	})
+}
@@ -0,0 +1,41 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestGetWorkspaces(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	work := database.Workspace{WorkspaceName: "work"}
+	database.Orm.Create(&work)
+	database.Orm.Create(&database.Workspace{WorkspaceName: "home"})
+	database.Orm.Create(&database.Workspace{WorkspaceOwner: 1, WorkspaceName: "other"})
+
+	item := preparationClipboardItem()
+	item.ClipboardItemWorkspace = work.Index
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/workspaces", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := loadJSON(w.Body.String())
+	assert.Equal(t, float64(2), response["count"])
+	workspaces := response["Workspace"].([]interface{})
+	assert.Equal(t, "home", workspaces[0].(map[string]interface{})["WorkspaceName"])
+	assert.Equal(t, float64(0), workspaces[0].(map[string]interface{})["ClipboardItemCount"])
+	assert.Equal(t, "work", workspaces[1].(map[string]interface{})["WorkspaceName"])
+	assert.Equal(t, float64(1), workspaces[1].(map[string]interface{})["ClipboardItemCount"])
+
+	database.Close()
+}
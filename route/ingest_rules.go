@@ -0,0 +1,225 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+const headerMimeTypes = "X-Clipboard-Archive-Mime-Types"
+
+// Ingest rule types. Sizes count the bytes of ClipboardItemData, lengths
+// the characters of ClipboardItemText. When there are allow rules of a
+// kind, one of them has to match. MIME types are matched with path.Match
+// patterns such as image/*, and only against the MIME types a client
+// declares, the server does not look inside ClipboardItemData. A
+// ClipboardItem declaring none passes no mime_allow rule.
+const (
+	ingestRuleMaxSize       = "max_size"
+	ingestRuleMinLength     = "min_length"
+	ingestRuleTextAllow     = "text_allow"
+	ingestRuleTextDeny      = "text_deny"
+	ingestRuleMimeAllow     = "mime_allow"
+	ingestRuleMimeDeny      = "mime_deny"
+	ingestRuleSourceAppDeny = "source_app_deny"
+)
+
+// validateIngestRule reports what is wrong with rule, nil if nothing.
+func validateIngestRule(rule database.IngestRule) error {
+	switch rule.IngestRuleType {
+	case ingestRuleMaxSize, ingestRuleMinLength:
+		n, err := strconv.ParseInt(rule.IngestRuleValue, 10, 64)
+		if err != nil || n < 0 {
+			return errors.New("IngestRuleValue must be a non-negative integer")
+		}
+	case ingestRuleTextAllow, ingestRuleTextDeny:
+		_, err := regexp.Compile(rule.IngestRuleValue)
+		if err != nil {
+			return err
+		}
+	case ingestRuleMimeAllow, ingestRuleMimeDeny:
+		_, err := path.Match(rule.IngestRuleValue, "")
+		if err != nil || rule.IngestRuleValue == "" {
+			return errors.New("IngestRuleValue must be a MIME type pattern")
+		}
+	case ingestRuleSourceAppDeny:
+		if rule.IngestRuleValue == "" {
+			return errors.New("IngestRuleValue must be an application name")
+		}
+	default:
+		return fmt.Errorf("unknown IngestRuleType %q", rule.IngestRuleType)
+	}
+	return nil
+}
+
+// clipboardItemMimeTypes are the MIME types declared in the body, or else
+// in the comma separated X-Clipboard-Archive-Mime-Types header.
+func clipboardItemMimeTypes(c *gin.Context, item *ClipboardItem) []string {
+	if len(item.ClipboardItemMimeTypes) > 0 {
+		return item.ClipboardItemMimeTypes
+	}
+	types := []string{}
+	for _, t := range strings.Split(c.GetHeader(headerMimeTypes), ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// ingestRule is an IngestRule with the pattern of a text rule compiled.
+type ingestRule struct {
+	database.IngestRule
+	pattern *regexp.Regexp
+}
+
+// compileIngestRules compiles the patterns of rules, which were validated
+// when they were stored. A pattern that no longer compiles matches nothing.
+func compileIngestRules(rules []database.IngestRule) []ingestRule {
+	compiled := make([]ingestRule, 0, len(rules))
+	for _, rule := range rules {
+		r := ingestRule{IngestRule: rule}
+		switch rule.IngestRuleType {
+		case ingestRuleTextAllow, ingestRuleTextDeny:
+			r.pattern, _ = regexp.Compile(rule.IngestRuleValue)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled
+}
+
+// loadedIngestRules caches the compiled ingest rules of the database they
+// were loaded from, reloadIngestRules drops them when the rules change.
+var loadedIngestRules = struct {
+	sync.Mutex
+	db    *gorm.DB
+	rules []ingestRule
+}{}
+
+// currentIngestRules are the ingest rules in the order they were created.
+func currentIngestRules() ([]ingestRule, error) {
+	loadedIngestRules.Lock()
+	defer loadedIngestRules.Unlock()
+	if loadedIngestRules.db == database.Orm {
+		return loadedIngestRules.rules, nil
+	}
+
+	rules := []database.IngestRule{}
+	err := database.Orm.Order("`index`").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	loadedIngestRules.db = database.Orm
+	loadedIngestRules.rules = compileIngestRules(rules)
+	return loadedIngestRules.rules, nil
+}
+
+// reloadIngestRules makes the next insert load the ingest rules again.
+func reloadIngestRules() {
+	loadedIngestRules.Lock()
+	defer loadedIngestRules.Unlock()
+	loadedIngestRules.db = nil
+	loadedIngestRules.rules = nil
+}
+
+func matchText(pattern *regexp.Regexp, text string) bool {
+	return pattern != nil && pattern.MatchString(text)
+}
+
+func matchMimeType(pattern string, types []string) bool {
+	for _, t := range types {
+		matched, _ := path.Match(pattern, t)
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// violatedIngestRule returns the first rule item breaks and why, nil if it
+// passes them all. The server cannot read ClipboardItemText of end-to-end
+// encrypted Workspaces, so text rules are skipped there.
+func violatedIngestRule(rules []ingestRule, item *ClipboardItem, mimeTypes []string, endToEnd bool) (*database.IngestRule, string) {
+	allows := map[string]*database.IngestRule{}
+	allowed := map[string]bool{}
+	for i := range rules {
+		rule := &rules[i].IngestRule
+		pattern := rules[i].pattern
+		value := rule.IngestRuleValue
+		switch rule.IngestRuleType {
+		case ingestRuleMaxSize:
+			n, _ := strconv.ParseInt(value, 10, 64)
+			if int64(len(item.ClipboardItemData)) > n {
+				return rule, fmt.Sprintf("ClipboardItemData is larger than %d bytes", n)
+			}
+		case ingestRuleMinLength:
+			n, _ := strconv.ParseInt(value, 10, 64)
+			if !endToEnd && item.ClipboardItemText != "" && int64(utf8.RuneCountInString(item.ClipboardItemText)) < n {
+				return rule, fmt.Sprintf("ClipboardItemText is shorter than %d characters", n)
+			}
+		case ingestRuleTextDeny:
+			if !endToEnd && matchText(pattern, item.ClipboardItemText) {
+				return rule, "ClipboardItemText matches a denied pattern"
+			}
+		case ingestRuleTextAllow:
+			if endToEnd {
+				continue
+			}
+			allows[rule.IngestRuleType] = rule
+			allowed[rule.IngestRuleType] = allowed[rule.IngestRuleType] || matchText(pattern, item.ClipboardItemText)
+		case ingestRuleMimeDeny:
+			if matchMimeType(value, mimeTypes) {
+				return rule, "a MIME type is denied"
+			}
+		case ingestRuleMimeAllow:
+			allows[rule.IngestRuleType] = rule
+			allowed[rule.IngestRuleType] = allowed[rule.IngestRuleType] || matchMimeType(value, mimeTypes)
+		case ingestRuleSourceAppDeny:
+			if strings.EqualFold(value, item.ClipboardItemSourceApp) {
+				return rule, "the source application is denied"
+			}
+		}
+	}
+	if rule, ok := allows[ingestRuleTextAllow]; ok && !allowed[ingestRuleTextAllow] {
+		return rule, "ClipboardItemText matches no allowed pattern"
+	}
+	if rule, ok := allows[ingestRuleMimeAllow]; ok && !allowed[ingestRuleMimeAllow] {
+		if len(mimeTypes) == 0 {
+			return rule, "no MIME types are declared"
+		}
+		return rule, "no MIME type is allowed"
+	}
+	return nil, ""
+}
+
+// checkIngestRules reports whether item passes the ingest rules, reporting
+// errors itself.
+func checkIngestRules(c *gin.Context, item *ClipboardItem) bool {
+	rules, err := currentIngestRules()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error inserting ClipboardItem", err)
+		return false
+	}
+
+	rule, reason := violatedIngestRule(rules, item, clipboardItemMimeTypes(c, item), endToEndOf(c))
+	if rule != nil {
+		rejectedTotal.Inc(codeIngestRejected)
+		abortWithDetails(c, http.StatusUnprocessableEntity, codeIngestRejected, "ClipboardItem rejected by an ingest rule", gin.H{
+			"IngestRule": *rule,
+			"reason":     reason,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestViolatedIngestRule(t *testing.T) {
+	item := &ClipboardItem{ClipboardItemText: "hello", ClipboardItemData: "aGVsbG8=", ClipboardItemSourceApp: "KeePassXC"}
+	check := func(rules ...database.IngestRule) string {
+		rule, reason := violatedIngestRule(compileIngestRules(rules), item, []string{"text/plain", "application/x-copyq-owner-window-title"}, false)
+		if rule == nil {
+			return ""
+		}
+		return rule.IngestRuleType + ": " + reason
+	}
+
+	assert.Equal(t, "", check())
+	assert.Equal(t, "max_size: ClipboardItemData is larger than 4 bytes", check(database.IngestRule{IngestRuleType: "max_size", IngestRuleValue: "4"}))
+	assert.Equal(t, "", check(database.IngestRule{IngestRuleType: "max_size", IngestRuleValue: "8"}))
+	assert.Equal(t, "min_length: ClipboardItemText is shorter than 6 characters", check(database.IngestRule{IngestRuleType: "min_length", IngestRuleValue: "6"}))
+	assert.Equal(t, "text_deny: ClipboardItemText matches a denied pattern", check(database.IngestRule{IngestRuleType: "text_deny", IngestRuleValue: "^h"}))
+	assert.Equal(t, "", check(
+		database.IngestRule{IngestRuleType: "text_allow", IngestRuleValue: "^x"},
+		database.IngestRule{IngestRuleType: "text_allow", IngestRuleValue: "o$"},
+	))
+	assert.Equal(t, "text_allow: ClipboardItemText matches no allowed pattern", check(database.IngestRule{IngestRuleType: "text_allow", IngestRuleValue: "^x"}))
+	assert.Equal(t, "", check(database.IngestRule{IngestRuleType: "mime_allow", IngestRuleValue: "text/*"}))
+	assert.Equal(t, "mime_allow: no MIME type is allowed", check(database.IngestRule{IngestRuleType: "mime_allow", IngestRuleValue: "image/*"}))
+	assert.Equal(t, "mime_deny: a MIME type is denied", check(database.IngestRule{IngestRuleType: "mime_deny", IngestRuleValue: "text/plain"}))
+	assert.Equal(t, "source_app_deny: the source application is denied", check(database.IngestRule{IngestRuleType: "source_app_deny", IngestRuleValue: "keepassxc"}))
+
+	// Without declared MIME types nothing is allowed, and text rules have
+	// nothing to go on in end-to-end encrypted Workspaces.
+	rule, reason := violatedIngestRule(compileIngestRules([]database.IngestRule{{IngestRuleType: "mime_allow", IngestRuleValue: "image/*"}}), item, []string{}, false)
+	assert.NotNil(t, rule)
+	assert.Equal(t, "no MIME types are declared", reason)
+	rule, _ = violatedIngestRule(compileIngestRules([]database.IngestRule{{IngestRuleType: "mime_deny", IngestRuleValue: "image/*"}}), item, []string{}, false)
+	assert.Nil(t, rule)
+	rule, _ = violatedIngestRule(compileIngestRules([]database.IngestRule{{IngestRuleType: "text_allow", IngestRuleValue: "^x"}}), item, []string{}, true)
+	assert.Nil(t, rule)
+}
+
+func TestInsertClipboardItemIngestRejected(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.IngestRule{IngestRuleType: "mime_deny", IngestRuleValue: "image/*"})
+
+	item := preparationClipboardItem()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	req.Header.Set(headerMimeTypes, "text/plain, image/png")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, "ingest_rejected", got["code"])
+	details := got["details"].(map[string]interface{})
+	assert.Equal(t, "a MIME type is denied", details["reason"])
+	assert.Equal(t, "image/*", details["IngestRule"].(map[string]interface{})["IngestRuleValue"])
+
+	body := clipboardItemToGinH(item)
+	body["ClipboardItemMimeTypes"] = []string{"text/plain"}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(body)))
+	req.Header.Set(headerMimeTypes, "image/png")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	database.Close()
+}
+
+func TestIngestRulesReload(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	inserted := int64(0)
+	insert := func(text string) int {
+		inserted++
+		item := preparationClipboardItem()
+		item.ClipboardItemTime += inserted
+		item.ClipboardItemText = text
+		item.ClipboardItemData = toBase64(text)
+		item.ClipboardItemHash = toSha256(item.ClipboardItemData)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	admin := func(method string, url string, body string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1/admin/ingest-rules"+url, strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		assert.Less(t, w.Code, 300, w.Body.String())
+	}
+
+	assert.Equal(t, http.StatusCreated, insert("secret 1"))
+	admin("POST", "", `{"IngestRuleType": "text_deny", "IngestRuleValue": "^secret"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, insert("secret 2"))
+	var rule database.IngestRule
+	database.Orm.First(&rule)
+	admin("PATCH", "/"+strconv.FormatInt(rule.Index, 10), `{"IngestRuleValue": "^hidden"}`)
+	assert.Equal(t, http.StatusCreated, insert("secret 3"))
+	assert.Equal(t, http.StatusUnprocessableEntity, insert("hidden 4"))
+	admin("DELETE", "/"+strconv.FormatInt(rule.Index, 10), "")
+	assert.Equal(t, http.StatusCreated, insert("hidden 5"))
+
+	database.Close()
+}
+
+func TestIngestRulesAdminRequired(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	_, token, _ := database.CreateUser("alice")
+	admin, adminToken, _ := database.CreateUser("bob")
+	database.SetUserAdmin(admin.UserName, true)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/admin/ingest-rules", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "admin_required", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/admin/ingest-rules", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	database.Close()
+}
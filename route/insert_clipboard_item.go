@@ -1,46 +1,183 @@
 package route
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func insertClipboardItem(c *gin.Context) {
 	var item ClipboardItem
 
-	err := c.BindJSON(&item)
+	err := c.ShouldBindJSON(&item)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  http.StatusBadRequest,
-			"message": "Invalid JSON",
-			"error":   err.Error(),
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+	if !checkBlindTokens(c, &item) {
+		return
+	}
+	if !endToEndOf(c) {
+		item.ClipboardItemHash = database.KeyClipboardItemHash(item.ClipboardItemHash)
+	}
+
+	item.ClipboardItemRevision = 1
+	item.ClipboardItemDeletedTime = 0
+	item.ClipboardItemExpiresTime = 0
+	item.ClipboardItemOwner = ownerOf(c)
+	item.ClipboardItemWorkspace = workspaceOf(c)
+	fillClipboardItemSource(c, &item)
+	if !checkIngestRules(c, &item) {
+		return
+	}
+	if !checkSensitiveContent(c, &item) {
+		return
+	}
+	if !applyClipboardItemTTL(c, &item) {
+		return
+	}
+
+	err = database.TouchDevice(database.Orm, item.ClipboardItemOwner, item.ClipboardItemDevice, utils.GetUnixMillisTimestamp())
+	if err != nil {
+		log.Println("Error recording device: ", err)
+	}
+
+	// An expired one is gone already and only waits to be purged
+	err = database.Orm.
+		Scopes(ownedClipboardItems(c), expiredClipboardItems).
+		Where("clipboard_item_hash = ?", item.ClipboardItemHash).
+		Delete(&ClipboardItem{}).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error inserting ClipboardItem", err)
+		return
+	}
+
+	// Copying something in trash again brings it back, with its history
+	restored, err := restoreTrashedCopy(c, item.ClipboardItemHash)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error inserting ClipboardItem", err)
+		return
+	}
+	if restored != nil {
+		recordRecopy(&item)
+		auditClipboardItems(c, restored.ClipboardItemTime)
+		c.Header("ETag", clipboardItemETag(*restored))
+		c.JSON(http.StatusOK, gin.H{
+			"status":        http.StatusOK,
+			"message":       "ClipboardItem restored from trash",
+			"ClipboardItem": restored,
 		})
 		return
 	}
 
-	tx := database.Orm.Create(&item)
-	UniqueError := "constraint failed: UNIQUE constraint failed: clipboard_items.clipboard_item_hash (2067)"
-	if tx.Error != nil {
-		if tx.Error.Error() == UniqueError {
-			c.JSON(http.StatusConflict, gin.H{
-				"status":  http.StatusConflict,
-				"message": "ClipboardItem already exists",
-			})
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		err := tx.Create(&item).Error
+		if err != nil {
+			return err
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemCreated, item.ClipboardItemOwner, item.ClipboardItemWorkspace, item.ClipboardItemTime, &item)
+	})
+	duplicate := isUniqueHashError(err)
+	if isUniqueTimeError(err) {
+		// Sent again, rather than something else copied at the same time,
+		// it is not copied again
+		var count int64
+		err := database.Orm.Model(&ClipboardItem{}).
+			Scopes(ownedClipboardItems(c)).
+			Where("clipboard_item_time = ? AND clipboard_item_hash = ?", item.ClipboardItemTime, item.ClipboardItemHash).
+			Count(&count).Error
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error inserting ClipboardItem", err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error inserting ClipboardItem",
-			"error":   tx.Error.Error(),
-		})
+		if count == 0 {
+			abortWithError(c, http.StatusConflict, codeClipboardItemExists, "ClipboardItem already exists at this ClipboardItemTime", nil)
+			return
+		}
+		duplicatesTotal.Inc()
+		abortWithError(c, http.StatusConflict, codeClipboardItemExists, "ClipboardItem already exists", nil)
+		return
+	}
+	if duplicate {
+		recordRecopy(&item)
+		duplicatesTotal.Inc()
+		abortWithError(c, http.StatusConflict, codeClipboardItemExists, "ClipboardItem already exists", nil)
+		return
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error inserting ClipboardItem", err)
 		return
 	}
 
+	insertedTotal.Inc()
+	auditClipboardItems(c, item.ClipboardItemTime)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status":        http.StatusCreated,
 		"message":       "ClipboardItem created successfully",
 		"ClipboardItem": item,
 	})
 }
+
+// recordRecopy counts item, copied again, towards the most re-copied
+// ClipboardItems in stats.
+func recordRecopy(item *ClipboardItem) {
+	database.Orm.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "owner"}, {Name: "workspace"}, {Name: "clipboard_item_hash"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"copy_count":     gorm.Expr("copy_count + 1"),
+			"last_copy_time": item.ClipboardItemTime,
+		}),
+	}).Create(&database.ClipboardItemRecopy{
+		Owner:             item.ClipboardItemOwner,
+		Workspace:         item.ClipboardItemWorkspace,
+		ClipboardItemHash: item.ClipboardItemHash,
+		CopyCount:         1,
+		LastCopyTime:      item.ClipboardItemTime,
+	})
+}
+
+// restoreTrashedCopy takes the ClipboardItem in trash with hash out of it,
+// it is nil when there is none or it changed in between.
+func restoreTrashedCopy(c *gin.Context, hash string) (*ClipboardItem, error) {
+	var item ClipboardItem
+
+	err := database.Orm.Scopes(ownedClipboardItems(c), trashedClipboardItems).Where("clipboard_item_hash = ?", hash).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revision := item.ClipboardItemRevision
+	item.ClipboardItemDeletedTime = 0
+	item.ClipboardItemRevision++
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&item).
+			Where("clipboard_item_revision = ?", revision).
+			Select("clipboard_item_deleted_time", "clipboard_item_revision").
+			Updates(&item)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClipboardItemModified
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemRestored, item.ClipboardItemOwner, item.ClipboardItemWorkspace, item.ClipboardItemTime, &item)
+	})
+	if errors.Is(err, errClipboardItemModified) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
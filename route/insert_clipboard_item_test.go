@@ -27,6 +27,8 @@ func TestInsertClipboardItem(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	itemReq["Index"] = 1
+	itemReq["ClipboardItemSize"] = len(item.ClipboardItemData)
+	itemReq["ClipboardItemRevision"] = 1
 	expected := gin.H{
 		"status":        http.StatusCreated,
 		"message":       "ClipboardItem created successfully",
@@ -37,6 +39,8 @@ func TestInsertClipboardItem(t *testing.T) {
 	assert.Equal(t, expected, got)
 
 	item.Index = 1
+	item.ClipboardItemSize = int64(len(item.ClipboardItemData))
+	item.ClipboardItemRevision = 1
 	var item2 ClipboardItem
 	database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item2)
 	assert.Equal(t, item, item2)
@@ -44,6 +48,37 @@ func TestInsertClipboardItem(t *testing.T) {
 	database.Close()
 }
 
+func TestInsertClipboardItemSource(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemWindowTitle = "notes.txt - Editor"
+	itemReq := clipboardItemToGinH(item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(itemReq)))
+	req.Header.Set("X-Clipboard-Archive-Device", "laptop")
+	req.Header.Set("X-Clipboard-Archive-Source-App", "editor")
+	req.Header.Set("X-Clipboard-Archive-Window-Title", "ignored")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var item2 ClipboardItem
+	database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item2)
+	assert.Equal(t, "laptop", item2.ClipboardItemDevice)
+	assert.Equal(t, "editor", item2.ClipboardItemSourceApp)
+	assert.Equal(t, "notes.txt - Editor", item2.ClipboardItemWindowTitle)
+
+	var device database.Device
+	database.Orm.First(&device, "device_name = ?", "laptop")
+	assert.NotZero(t, device.DeviceLastSeenTime)
+
+	database.Close()
+}
+
 func TestInsertClipboardItemBindJsonError(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	database.Open("file::memory:?cache=shared")
@@ -57,10 +92,12 @@ func TestInsertClipboardItemBindJsonError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_json",
 		"message": "Invalid JSON",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -84,15 +121,49 @@ func TestInsertClipboardItemUniqueError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusConflict,
+		"code":    "clipboard_item_exists",
 		"message": "ClipboardItem already exists",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	assert.Equal(t, expected, got)
 
 	database.Close()
 }
 
+func TestInsertClipboardItemTrashed(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	itemReq := clipboardItemToGinH(item)
+	item.ClipboardItemDeletedTime = 1
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(itemReq)))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ClipboardItem restored from trash", loadJSON(w.Body.String())["message"])
+
+	// The one in trash comes back rather than being replaced
+	var items []ClipboardItem
+	database.Orm.Find(&items)
+	assert.Len(t, items, 1)
+	assert.Equal(t, item.Index, items[0].Index)
+	assert.Equal(t, int64(0), items[0].ClipboardItemDeletedTime)
+	assert.Equal(t, int64(2), items[0].ClipboardItemRevision)
+	// It was copied again
+	var recopy database.ClipboardItemRecopy
+	database.Orm.First(&recopy)
+	assert.Equal(t, int64(1), recopy.CopyCount)
+
+	database.Close()
+}
+
 func TestInsertClipboardItemDatabaseError(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	r := SetupRouter()
@@ -110,11 +181,13 @@ func TestInsertClipboardItemDatabaseError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusInternalServerError,
+		"code":    "internal_error",
 		"message": "Error inserting ClipboardItem",
 	}
 	delete(expected, "error")
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
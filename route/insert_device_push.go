@@ -0,0 +1,86 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+type devicePushRequest struct {
+	ClipboardItemTime int64  `json:"ClipboardItemTime" binding:"required"`
+	TTL               string `json:"ttl"` // Go duration, DevicePushTTL by default
+}
+
+// insertDevicePush queues a ClipboardItem for a device to put on its
+// clipboard.
+func insertDevicePush(c *gin.Context) {
+	var request devicePushRequest
+	var count int64
+
+	device := c.Params.ByName("device")
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	ttl := DevicePushTTL
+	if request.TTL != "" {
+		ttl, err = time.ParseDuration(request.TTL)
+		if err == nil && (ttl <= 0 || ttl > DevicePushTTL) {
+			err = errors.New("must be positive and at most " + DevicePushTTL.String())
+		}
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidTTL, "Invalid ttl", err)
+			return
+		}
+	}
+
+	err = database.Orm.
+		Model(&ClipboardItem{}).
+		Scopes(ownedClipboardItems(c), liveClipboardItems).
+		Where("clipboard_item_time = ?", request.ClipboardItemTime).
+		Count(&count).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating DevicePush", err)
+		return
+	}
+	if count == 0 {
+		abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
+		return
+	}
+
+	now := utils.GetUnixMillisTimestamp()
+	push := database.DevicePush{
+		DeviceName:            device,
+		ClipboardItemTime:     request.ClipboardItemTime,
+		DevicePushCreatedTime: now,
+		DevicePushExpiresTime: now + ttl.Milliseconds(),
+		DevicePushOwner:       ownerOf(c),
+		DevicePushWorkspace:   workspaceOf(c),
+	}
+
+	err = database.Orm.Where("device_push_expires_time <= ?", now).Delete(&database.DevicePush{}).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating DevicePush", err)
+		return
+	}
+	err = database.Orm.Create(&push).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating DevicePush", err)
+		return
+	}
+	devicePushes.notify(push.DevicePushOwner, device)
+	auditClipboardItems(c, push.ClipboardItemTime)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":     http.StatusCreated,
+		"message":    "DevicePush created successfully",
+		"DevicePush": push,
+	})
+}
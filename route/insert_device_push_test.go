@@ -0,0 +1,67 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestInsertDevicePush(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/devices/desktop/push", strings.NewReader(`{"ClipboardItemTime": 1, "ttl": "10m"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var push database.DevicePush
+	database.Orm.First(&push)
+	assert.Equal(t, "desktop", push.DeviceName)
+	assert.Equal(t, int64(1), push.ClipboardItemTime)
+	assert.Equal(t, int64(10*60*1000), push.DevicePushExpiresTime-push.DevicePushCreatedTime)
+
+	database.Close()
+}
+
+func TestInsertDevicePushBadRequest(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	database.Orm.Create(&item)
+	item = preparationClipboardItem()
+	item.ClipboardItemTime = 2
+	item.ClipboardItemDeletedTime = 10
+	database.Orm.Create(&item)
+
+	for body, code := range map[string]string{
+		`{`:                                      codeInvalidJSON,
+		`{"ClipboardItemTime": 1, "ttl": "a"}`:   codeInvalidTTL,
+		`{"ClipboardItemTime": 1, "ttl": "-1s"}`: codeInvalidTTL,
+		`{"ClipboardItemTime": 1, "ttl": "10000h"}`: codeInvalidTTL,
+		`{"ClipboardItemTime": 2}`:                  codeClipboardItemNotFound,
+		`{"ClipboardItemTime": 3}`:                  codeClipboardItemNotFound,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/devices/desktop/push", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, code, loadJSON(w.Body.String())["code"], body)
+	}
+
+	database.Close()
+}
@@ -0,0 +1,48 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+type ingestRuleRequest struct {
+	IngestRuleType  string `json:"IngestRuleType" binding:"required"`
+	IngestRuleValue string `json:"IngestRuleValue" binding:"required"`
+}
+
+func insertIngestRule(c *gin.Context) {
+	var request ingestRuleRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	rule := database.IngestRule{
+		IngestRuleType:        request.IngestRuleType,
+		IngestRuleValue:       request.IngestRuleValue,
+		IngestRuleCreatedTime: utils.GetUnixMillisTimestamp(),
+	}
+	err = validateIngestRule(rule)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidIngestRule, "Invalid IngestRule", err)
+		return
+	}
+
+	err = database.Orm.Create(&rule).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating IngestRule", err)
+		return
+	}
+
+	reloadIngestRules()
+	c.JSON(http.StatusCreated, gin.H{
+		"status":     http.StatusCreated,
+		"message":    "IngestRule created successfully",
+		"IngestRule": rule,
+	})
+}
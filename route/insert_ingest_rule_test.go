@@ -0,0 +1,62 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestInsertIngestRule(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/admin/ingest-rules", strings.NewReader(`{"IngestRuleType": "text_deny", "IngestRuleValue": "^otpauth://"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	rule := loadJSON(w.Body.String())["IngestRule"].(map[string]interface{})
+	assert.Equal(t, "text_deny", rule["IngestRuleType"])
+	assert.Equal(t, "^otpauth://", rule["IngestRuleValue"])
+
+	var stored database.IngestRule
+	database.Orm.First(&stored)
+	assert.Equal(t, "^otpauth://", stored.IngestRuleValue)
+
+	database.Close()
+}
+
+func TestInsertIngestRuleInvalid(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for _, body := range []string{
+		`{"IngestRuleType": "max_size", "IngestRuleValue": "-1"}`,
+		`{"IngestRuleType": "min_length", "IngestRuleValue": "a"}`,
+		`{"IngestRuleType": "text_allow", "IngestRuleValue": "("}`,
+		`{"IngestRuleType": "mime_deny", "IngestRuleValue": "image/["}`,
+		`{"IngestRuleType": "max_length", "IngestRuleValue": "1"}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/admin/ingest-rules", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Equal(t, "invalid_ingest_rule", loadJSON(w.Body.String())["code"])
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/admin/ingest-rules", strings.NewReader(`{"IngestRuleType": "max_size"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_json", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
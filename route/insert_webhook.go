@@ -0,0 +1,98 @@
+package route
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+type webhookRequest struct {
+	WebhookURL    string `json:"WebhookURL" binding:"required"`
+	WebhookSecret string `json:"WebhookSecret"`
+	WebhookEvents string `json:"WebhookEvents"`
+	WebhookSearch string `json:"WebhookSearch"`
+}
+
+func insertWebhook(c *gin.Context) {
+	var request webhookRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	u, err := url.Parse(request.WebhookURL)
+	if err == nil && ((u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		err = errors.New("must be an absolute http or https URL")
+	}
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidWebhookURL, "Invalid WebhookURL", err)
+		return
+	}
+
+	var events []string
+	if request.WebhookEvents != "" {
+		for _, typ := range strings.Split(request.WebhookEvents, ",") {
+			typ = strings.TrimSpace(typ)
+			if !isClipboardItemEventType(typ) {
+				abortWithError(c, http.StatusBadRequest, codeInvalidWebhookEvents, "Invalid WebhookEvents", errors.New("unknown event type: "+typ))
+				return
+			}
+			events = append(events, typ)
+		}
+	}
+
+	if request.WebhookSearch != "" && endToEndOf(c) {
+		abortWithError(c, http.StatusBadRequest, codeInvalidWebhookSearch, "Invalid WebhookSearch", errors.New("end-to-end encrypted Workspaces are not indexed"))
+		return
+	}
+	if request.WebhookSearch != "" {
+		var count int64
+		err = database.Orm.
+			Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ? AND rowid = 0", request.WebhookSearch).
+			Scan(&count).Error
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidWebhookSearch, "Invalid WebhookSearch", err)
+			return
+		}
+	}
+
+	if request.WebhookSecret == "" {
+		b := make([]byte, 32)
+		_, err = rand.Read(b)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating Webhook", err)
+			return
+		}
+		request.WebhookSecret = hex.EncodeToString(b)
+	}
+
+	webhook := database.Webhook{
+		WebhookURL:         request.WebhookURL,
+		WebhookSecret:      request.WebhookSecret,
+		WebhookEvents:      strings.Join(events, ","),
+		WebhookSearch:      request.WebhookSearch,
+		WebhookCreatedTime: utils.GetUnixMillisTimestamp(),
+		WebhookOwner:       ownerOf(c),
+		WebhookWorkspace:   workspaceOf(c),
+	}
+	err = database.Orm.Create(&webhook).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating Webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  http.StatusCreated,
+		"message": "Webhook created successfully",
+		"Webhook": webhook,
+	})
+}
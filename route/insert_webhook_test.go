@@ -0,0 +1,59 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestInsertWebhook(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	body := `{"WebhookURL": "http://127.0.0.1/hook", "WebhookEvents": "ClipboardItem.created, ClipboardItem.deleted", "WebhookSearch": "hello"}`
+	req, _ := http.NewRequest("POST", "/api/v1/webhooks", strings.NewReader(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	got := loadJSON(w.Body.String())
+	webhook := got["Webhook"].(map[string]interface{})
+	assert.Equal(t, "Webhook created successfully", got["message"])
+	assert.Equal(t, "ClipboardItem.created,ClipboardItem.deleted", webhook["WebhookEvents"])
+	assert.Len(t, webhook["WebhookSecret"], 64)
+
+	var stored database.Webhook
+	database.Orm.First(&stored)
+	assert.Equal(t, webhook["WebhookSecret"], stored.WebhookSecret)
+
+	database.Close()
+}
+
+func TestInsertWebhookError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for body, code := range map[string]string{
+		`{`:                       "invalid_json",
+		`{"WebhookURL": "/hook"}`: "invalid_webhook_url",
+		`{"WebhookURL": "http://a", "WebhookEvents": "a"}`:  "invalid_webhook_events",
+		`{"WebhookURL": "http://a", "WebhookSearch": "\""}`: "invalid_webhook_search",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/webhooks", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, code, loadJSON(w.Body.String())["code"])
+	}
+
+	database.Close()
+}
@@ -0,0 +1,76 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+)
+
+type workspaceRequest struct {
+	WorkspaceName           string `json:"WorkspaceName" binding:"required"`
+	WorkspaceTrashRetention int64  `json:"WorkspaceTrashRetention"`
+	WorkspaceEndToEnd       bool   `json:"WorkspaceEndToEnd"`
+}
+
+// insertWorkspace creates a named Workspace. Whether it is end-to-end
+// encrypted is decided here for good.
+func insertWorkspace(c *gin.Context) {
+	var request workspaceRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	if !checkWorkspaceName(c, request.WorkspaceName) {
+		return
+	}
+
+	workspace := database.Workspace{
+		WorkspaceOwner:          ownerOf(c),
+		WorkspaceName:           request.WorkspaceName,
+		WorkspaceTrashRetention: request.WorkspaceTrashRetention,
+		WorkspaceEndToEnd:       request.WorkspaceEndToEnd,
+		WorkspaceCreatedTime:    utils.GetUnixMillisTimestamp(),
+	}
+	err = database.Orm.Create(&workspace).Error
+	if isUniqueWorkspaceNameError(err) {
+		// Created by another request since checkWorkspaceName
+		abortWithError(c, http.StatusConflict, codeWorkspaceExists, "Workspace already exists", nil)
+		return
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error creating Workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":    http.StatusCreated,
+		"message":   "Workspace created successfully",
+		"Workspace": workspace,
+	})
+}
+
+// checkWorkspaceName reports whether name can be given to a new or renamed
+// Workspace, reporting errors itself.
+func checkWorkspaceName(c *gin.Context, name string) bool {
+	if !workspaceNamePattern.MatchString(name) {
+		abortWithError(c, http.StatusBadRequest, codeInvalidWorkspaceName, "Invalid WorkspaceName", errors.New("must be 1 to 64 letters, digits, dots, underscores or dashes"))
+		return false
+	}
+
+	_, err := database.FindWorkspace(ownerOf(c), name)
+	if err == nil {
+		abortWithError(c, http.StatusConflict, codeWorkspaceExists, "Workspace already exists", nil)
+		return false
+	}
+	if !errors.Is(err, database.ErrWorkspaceNotFound) {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error finding Workspace", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+func TestInsertWorkspace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{"WorkspaceName": "work", "WorkspaceTrashRetention": 1000}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	workspace := loadJSON(w.Body.String())["Workspace"].(map[string]interface{})
+	assert.Equal(t, "work", workspace["WorkspaceName"])
+	assert.Equal(t, float64(1000), workspace["WorkspaceTrashRetention"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{"WorkspaceName": "work"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "workspace_exists", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
+
+func TestInsertWorkspaceRace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	// Created by someone else between checking the name and inserting it.
+	err := database.Orm.Callback().Create().Before("gorm:create").Register("test:insert", func(tx *gorm.DB) {
+		if tx.Statement.Table == "workspaces" {
+			tx.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO workspaces (workspace_owner, workspace_name) VALUES (0, 'work')")
+		}
+	})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{"WorkspaceName": "work"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "workspace_exists", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
+
+func TestInsertWorkspaceInvalidName(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for _, body := range []string{`{"WorkspaceName": "a/b"}`, `{"WorkspaceName": "` + strings.Repeat("a", 65) + `"}`} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_workspace_name", loadJSON(w.Body.String())["code"])
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_json", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
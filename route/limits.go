@@ -0,0 +1,187 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit is how many requests per second each User, or each client IP
+// of an archive without Users, may make on average, 0 turns it off.
+// RateLimitBurst is how many it may make at once.
+var (
+	RateLimit      float64 = 0
+	RateLimitBurst int64   = 100
+)
+
+// MaxBodySize is the largest request body in bytes, BodyLimits overrides it
+// for routes such as "POST /ClipboardItem", named without the /api/v1 and
+// /workspaces/:workspace prefixes. 0 lifts the limit.
+var (
+	MaxBodySize int64 = 1 << 20
+	BodyLimits        = map[string]int64{
+		"POST /ClipboardItem":      64 << 20,
+		"PUT /ClipboardItem/:id":   64 << 20,
+		"PATCH /ClipboardItem/:id": 64 << 20,
+		"POST /sync/changes":       256 << 20,
+	}
+)
+
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerBodyLimit          = "X-Clipboard-Archive-Body-Limit"
+)
+
+// bucket is a token bucket, refilled at RateLimit tokens per second up to
+// RateLimitBurst.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var buckets = struct {
+	sync.Mutex
+	m     map[string]*bucket
+	swept time.Time
+}{m: map[string]*bucket{}}
+
+// take spends cost tokens of the bucket of key, returning the tokens left,
+// or how long until there is one again. A cost of 0 only looks.
+func take(key string, now time.Time, cost float64) (float64, time.Duration) {
+	buckets.Lock()
+	defer buckets.Unlock()
+
+	burst := float64(RateLimitBurst)
+	if burst < 1 {
+		burst = 1
+	}
+	refill := func(b *bucket) {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*RateLimit)
+		b.last = now
+	}
+	// Full buckets are as good as new ones, dropping them keeps the map
+	// from growing with every client ever seen
+	if now.Sub(buckets.swept) > time.Minute {
+		for k, b := range buckets.m {
+			refill(b)
+			if b.tokens >= burst {
+				delete(buckets.m, k)
+			}
+		}
+		buckets.swept = now
+	}
+
+	b, ok := buckets.m[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		buckets.m[key] = b
+	}
+	refill(b)
+	if b.tokens < 1 {
+		return 0, time.Duration((1 - b.tokens) / RateLimit * float64(time.Second))
+	}
+	b.tokens -= cost
+	return b.tokens, 0
+}
+
+// limitRate reports the tokens left in a bucket and refuses the request
+// when it is empty, reporting whether it may go on.
+func limitRate(c *gin.Context, tokens float64, wait time.Duration) bool {
+	c.Header(headerRateLimitLimit, strconv.FormatInt(RateLimitBurst, 10))
+	c.Header(headerRateLimitRemaining, strconv.FormatInt(int64(tokens), 10))
+	if wait > 0 {
+		c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+		abortWithDetails(c, http.StatusTooManyRequests, codeRateLimited, "Too many requests", gin.H{
+			"limit":  RateLimit,
+			"burst":  RateLimitBurst,
+			"waitMs": wait.Milliseconds(),
+		})
+		return false
+	}
+	return true
+}
+
+// limitClientIP runs before authenticate and refuses client IPs whose
+// bucket is empty. Requests failing authentication spend its tokens, see
+// chargeClientIP, so API tokens cannot be guessed faster than RateLimit.
+// Requests of Users are charged to the User by rateLimit instead.
+func limitClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if RateLimit <= 0 {
+			c.Next()
+			return
+		}
+		tokens, wait := take("ip:"+c.ClientIP(), time.Now(), 0)
+		if !limitRate(c, tokens, wait) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// chargeClientIP spends a token of the bucket of the client IP.
+func chargeClientIP(c *gin.Context) {
+	if RateLimit > 0 {
+		take("ip:"+c.ClientIP(), time.Now(), 1)
+	}
+}
+
+// rateLimit throttles each User, or each client IP without Users, to
+// RateLimit requests per second. It runs after authenticate.
+func rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if RateLimit <= 0 {
+			c.Next()
+			return
+		}
+		key := "ip:" + c.ClientIP()
+		if owner := ownerOf(c); owner != 0 {
+			key = fmt.Sprintf("user:%d", owner)
+		}
+		tokens, wait := take(key, time.Now(), 1)
+		if !limitRate(c, tokens, wait) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// bodyLimitOf is the body limit of the route a request matched.
+func bodyLimitOf(c *gin.Context) int64 {
+	route := strings.TrimPrefix(c.FullPath(), "/api/v1")
+	if strings.HasPrefix(route, "/workspaces/:workspace/") {
+		route = strings.TrimPrefix(route, "/workspaces/:workspace")
+	}
+	limit, ok := BodyLimits[c.Request.Method+" "+route]
+	if !ok {
+		return MaxBodySize
+	}
+	return limit
+}
+
+// limitBody refuses bodies declared larger than the limit of their route
+// before reading them, and stops reading the others at the limit, which
+// errorHandler reports as 413 as well.
+func limitBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := bodyLimitOf(c)
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+		c.Header(headerBodyLimit, strconv.FormatInt(limit, 10))
+		if c.Request.ContentLength > limit {
+			abortWithDetails(c, http.StatusRequestEntityTooLarge, codeRequestTooLarge, "Request body too large", gin.H{"limit": limit})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,124 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	defer func(limit float64, burst int64) { RateLimit, RateLimitBurst = limit, burst }(RateLimit, RateLimitBurst)
+	RateLimit, RateLimitBurst = 0.5, 2
+	buckets.m = map[string]*bucket{}
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/count", nil)
+		req.RemoteAddr = remoteAddr
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		w := get("192.0.2.1:1234")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w := get("192.0.2.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	response := loadJSON(w.Body.String())
+	assert.Equal(t, "rate_limited", response["code"])
+	assert.Equal(t, float64(2), response["details"].(map[string]interface{})["burst"])
+
+	// Other clients have buckets of their own
+	w = get("192.0.2.2:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	database.Close()
+}
+
+func TestRateLimitGuessing(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	defer func(limit float64, burst int64) { RateLimit, RateLimitBurst = limit, burst }(RateLimit, RateLimitBurst)
+	RateLimit, RateLimitBurst = 0.5, 2
+	buckets.m = map[string]*bucket{}
+	_, token, _ := database.CreateUser("alice")
+
+	get := func(remoteAddr string, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/count", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Users spend their own bucket, not that of their IP
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, get("192.0.2.1:1234", token).Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, get("192.0.2.1:1234", "guess").Code)
+	}
+	w := get("192.0.2.1:1234", "guess")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "rate_limited", loadJSON(w.Body.String())["code"])
+
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.2:1234", "guess").Code)
+
+	database.Close()
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	defer func(size int64) { MaxBodySize = size }(MaxBodySize)
+	MaxBodySize = 24
+	BodyLimits["POST /webhooks"] = 32
+	defer delete(BodyLimits, "POST /webhooks")
+
+	// Small enough
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{"WorkspaceName":"w"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Declared too large, refused before reading
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces", strings.NewReader(`{"WorkspaceName": "work"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "24", w.Header().Get("X-Clipboard-Archive-Body-Limit"))
+	response := loadJSON(w.Body.String())
+	assert.Equal(t, "request_too_large", response["code"])
+	assert.Equal(t, float64(24), response["details"].(map[string]interface{})["limit"])
+
+	// Undeclared, stopped at the limit of the route, in a Workspace too
+	for _, url := range []string{"/api/v1/webhooks", "/api/v1/workspaces/w/webhooks"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", url, io.NopCloser(strings.NewReader(`{"WebhookURL": "http://example.com/hook"}`)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, url)
+		assert.Equal(t, "32", w.Header().Get("X-Clipboard-Archive-Body-Limit"), url)
+		assert.Equal(t, "request_too_large", loadJSON(w.Body.String())["code"], url)
+	}
+
+	database.Close()
+}
@@ -0,0 +1,125 @@
+package route
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/metrics"
+)
+
+// ServeMetrics serves /metrics on the API router, which it only does with a
+// MetricsToken to ask for, otherwise SetupMetricsRouter serves it on a
+// listener of its own. MetricsUsageInterval is how long the database usage
+// measured for a scrape is reused.
+var (
+	ServeMetrics         = false
+	MetricsToken         = ""
+	MetricsUsageInterval = 30 * time.Second
+)
+
+var usageMeasured = struct {
+	sync.Mutex
+	at time.Time
+}{}
+
+var registry = metrics.NewRegistry()
+
+var (
+	requestsTotal         = registry.NewCounter("clipboard_archive_http_requests_total", "HTTP requests answered, by method, route and status.", "method", "route", "status")
+	requestDuration       = registry.NewHistogram("clipboard_archive_http_request_duration_seconds", "Time taken to answer HTTP requests, by method, route and status.", metrics.DefaultBuckets, "method", "route", "status")
+	insertedTotal         = registry.NewCounter("clipboard_archive_clipboard_items_inserted_total", "ClipboardItems inserted.")
+	duplicatesTotal       = registry.NewCounter("clipboard_archive_clipboard_item_duplicates_total", "ClipboardItems not inserted because they already exist.")
+	rejectedTotal         = registry.NewCounter("clipboard_archive_clipboard_items_rejected_total", "ClipboardItems rejected on insert, edit or sync, by error code.", "code")
+	searchDuration        = registry.NewHistogram("clipboard_archive_search_duration_seconds", "Time taken by full text searches of ClipboardItems.", metrics.DefaultBuckets)
+	databaseBytes         = registry.NewGauge("clipboard_archive_database_bytes", "Size of the database.")
+	walBytes              = registry.NewGauge("clipboard_archive_database_wal_bytes", "Size of the write-ahead log of the database.")
+	clipboardItemsGauge   = registry.NewGauge("clipboard_archive_clipboard_items", "ClipboardItems stored, by state live or trashed.", "state")
+	sweepsTotal           = registry.NewCounter("clipboard_archive_sweeps_total", "Runs of the retention sweepers, by sweeper and result ok or error.", "sweeper", "result")
+	sweptTotal            = registry.NewCounter("clipboard_archive_swept_total", "Rows purged by the retention sweepers.", "sweeper")
+	sweepLastRunTimestamp = registry.NewGauge("clipboard_archive_sweep_last_run_timestamp_seconds", "Unix timestamp of the last run of the retention sweepers.", "sweeper")
+)
+
+// ObserveSweep records a run of a retention sweeper that purged rows.
+func ObserveSweep(sweeper string, purged int64, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	sweepsTotal.Inc(sweeper, result)
+	sweptTotal.Add(float64(purged), sweeper)
+	sweepLastRunTimestamp.Set(float64(time.Now().Unix()), sweeper)
+}
+
+// measureRequests counts requests and their latency. It runs before
+// errorHandler to see the status errors are rendered with. Requests for
+// unknown routes are counted together, they would grow the labels without
+// bound.
+func measureRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requestsTotal.Inc(c.Request.Method, route, status)
+		requestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+}
+
+// getMetrics serves the metrics in the Prometheus text format.
+func getMetrics(c *gin.Context) {
+	if MetricsToken != "" && subtle.ConstantTimeCompare([]byte(bearerToken(c)), []byte(MetricsToken)) != 1 {
+		unauthorized(c, "Invalid metrics token", nil)
+		return
+	}
+
+	err := measureUsage(time.Now())
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error measuring database", err)
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = registry.WriteText(c.Writer)
+}
+
+// measureUsage sets the database usage gauges, unless they were set less
+// than MetricsUsageInterval before now. Counting the ClipboardItems reads
+// the whole table, scrapes should not.
+func measureUsage(now time.Time) error {
+	usageMeasured.Lock()
+	defer usageMeasured.Unlock()
+	if now.Sub(usageMeasured.at) < MetricsUsageInterval {
+		return nil
+	}
+
+	usage, err := database.GetUsage()
+	if err != nil {
+		return err
+	}
+	databaseBytes.Set(float64(usage.DatabaseBytes))
+	walBytes.Set(float64(usage.WALBytes))
+	clipboardItemsGauge.Set(float64(usage.ClipboardItems), "live")
+	clipboardItemsGauge.Set(float64(usage.TrashedClipboardItems), "trashed")
+	usageMeasured.at = now
+	return nil
+}
+
+// SetupMetricsRouter serves only /metrics, for a listener apart from the
+// API.
+func SetupMetricsRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), requestID(), errorHandler())
+	r.NoRoute(routeNotFound)
+	r.GET("/metrics", getMetrics)
+	return r
+}
@@ -0,0 +1,18 @@
+package route
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec documents every /api/v1 route, openapi_test.go keeps it
+// in sync with the handlers.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
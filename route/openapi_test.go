@@ -0,0 +1,340 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func loadOpenAPISpec(t *testing.T) map[string]interface{} {
+	var spec map[string]interface{}
+	err := json.Unmarshal(openAPISpec, &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// resolveOpenAPIRef follows a local "#/..." reference.
+func resolveOpenAPIRef(spec map[string]interface{}, ref string) map[string]interface{} {
+	node := spec
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		node, _ = node[key].(map[string]interface{})
+	}
+	return node
+}
+
+func matchOpenAPIType(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// validateOpenAPISchema checks value against the subset of JSON Schema used
+// by openapi.json and returns every mismatch.
+func validateOpenAPISchema(spec map[string]interface{}, schema map[string]interface{}, value interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return validateOpenAPISchema(spec, resolveOpenAPIRef(spec, ref), value, at)
+	}
+
+	var types []string
+	switch typ := schema["type"].(type) {
+	case string:
+		types = []string{typ}
+	case []interface{}:
+		for _, t := range typ {
+			types = append(types, t.(string))
+		}
+	}
+	if len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if matchOpenAPIType(typ, value) {
+				matched = true
+			}
+		}
+		if !matched {
+			return []string{fmt.Sprintf("%s: %v is not %v", at, value, types)}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if e == value {
+				found = true
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+		}
+	}
+
+	var problems []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %s", at, key))
+			}
+		}
+		for key, property := range v {
+			propertySchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: undocumented %s", at, key))
+				}
+				continue
+			}
+			problems = append(problems, validateOpenAPISchema(spec, propertySchema, property, at+"."+key)...)
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			problems = append(problems, fmt.Sprintf("%s: fewer than %v items", at, minItems))
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			problems = append(problems, fmt.Sprintf("%s: more than %v items", at, maxItems))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, validateOpenAPISchema(spec, items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// validateOpenAPIResponse checks a recorded response against the operation
+// for the path template and method.
+func validateOpenAPIResponse(t *testing.T, spec map[string]interface{}, template string, method string, w *httptest.ResponseRecorder) {
+	paths := spec["paths"].(map[string]interface{})
+	pathItem, ok := paths[template].(map[string]interface{})
+	if !ok {
+		t.Errorf("%s %s: path not documented", method, template)
+		return
+	}
+	operation, ok := pathItem[strings.ToLower(method)].(map[string]interface{})
+	if !ok {
+		t.Errorf("%s %s: method not documented", method, template)
+		return
+	}
+	responses := operation["responses"].(map[string]interface{})
+	response, ok := responses[strconv.Itoa(w.Code)].(map[string]interface{})
+	if !ok {
+		t.Errorf("%s %s: status %d not documented", method, template, w.Code)
+		return
+	}
+	if ref, ok := response["$ref"].(string); ok {
+		response = resolveOpenAPIRef(spec, ref)
+	}
+
+	content, ok := response["content"].(map[string]interface{})
+	if !ok {
+		assert.Empty(t, w.Body.String(), "%s %s: undocumented body", method, template)
+		return
+	}
+	mediaType, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	schema := mediaType["schema"].(map[string]interface{})
+
+	var body interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	if err != nil {
+		t.Errorf("%s %s: %v", method, template, err)
+		return
+	}
+	for _, problem := range validateOpenAPISchema(spec, schema, body, "body") {
+		t.Errorf("%s %s %d: %s", method, template, w.Code, problem)
+	}
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, "3.1.0", got["openapi"])
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	defer func() { ServeMetrics, MetricsToken = false, "" }()
+	ServeMetrics, MetricsToken = true, "secret"
+	r := SetupRouter()
+	spec := loadOpenAPISpec(t)
+
+	param := regexp.MustCompile(`:(\w+)`)
+	var routes []string
+	for _, route := range r.Routes() {
+		path := param.ReplaceAllString(strings.TrimPrefix(route.Path, "/api/v1"), "{$1}")
+		routes = append(routes, strings.ToLower(route.Method)+" "+path)
+	}
+
+	var documented []string
+	for path, pathItem := range spec["paths"].(map[string]interface{}) {
+		for method := range pathItem.(map[string]interface{}) {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented)
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+	spec := loadOpenAPISpec(t)
+
+	item := preparationClipboardItem()
+	id := fmt.Sprintf("%d", item.ClipboardItemTime)
+	itemReq := clipboardItemToGinH(item)
+	delete(itemReq, "Index")
+	database.Orm.Create(&database.AuditEntry{AuditEntryTime: 1000, AuditEntryMethod: "GET", AuditEntryRoute: "/api/v1/ClipboardItem/:id", AuditEntryClipboardItems: id, AuditEntryStatus: 200})
+
+	steps := []struct {
+		template string
+		method   string
+		url      string
+		body     string
+		header   map[string]string
+		status   int
+	}{
+		{"/ping", "GET", "/ping", "", nil, http.StatusOK},
+		{"/version", "GET", "/version", "", nil, http.StatusOK},
+		{"/ClipboardItem", "POST", "/ClipboardItem", dumpJSON(itemReq), map[string]string{"X-Clipboard-Archive-Device": "laptop"}, http.StatusCreated},
+		{"/ClipboardItem", "POST", "/ClipboardItem", dumpJSON(itemReq), nil, http.StatusConflict},
+		{"/ClipboardItem", "POST", "/ClipboardItem", "{", nil, http.StatusBadRequest},
+		{"/ClipboardItem", "POST", "/ClipboardItem?ttl=a", dumpJSON(itemReq), nil, http.StatusBadRequest},
+		{"/ClipboardItem", "GET", "/ClipboardItem", "", nil, http.StatusOK},
+		{"/ClipboardItem", "GET", "/ClipboardItem?fields=ClipboardItemText,ClipboardItemSize", "", nil, http.StatusOK},
+		{"/ClipboardItem", "GET", "/ClipboardItem?limit=a", "", nil, http.StatusBadRequest},
+		{"/ClipboardItem", "GET", "/ClipboardItem?device=laptop&sourceApp=a&windowTitle=b", "", nil, http.StatusOK},
+		{"/ClipboardItem/count", "GET", "/ClipboardItem/count", "", nil, http.StatusOK},
+		{"/ClipboardItem/{id}", "GET", "/ClipboardItem/" + id, "", nil, http.StatusOK},
+		{"/ClipboardItem/{id}", "GET", "/ClipboardItem/" + id, "", map[string]string{"If-None-Match": `"1"`}, http.StatusNotModified},
+		{"/ClipboardItem/{id}", "GET", "/ClipboardItem/a", "", nil, http.StatusBadRequest},
+		{"/ClipboardItem/{id}", "GET", "/ClipboardItem/1", "", nil, http.StatusNotFound},
+		{"/ClipboardItem/{id}", "PUT", "/ClipboardItem/" + id, `{"ClipboardItemData": "` + toBase64("a") + `"}`, nil, http.StatusOK},
+		{"/ClipboardItem/{id}", "PUT", "/ClipboardItem/" + id, `{"a": "b"}`, nil, http.StatusBadRequest},
+		{"/ClipboardItem/{id}", "PATCH", "/ClipboardItem/" + id, `{"ClipboardItemText": "b"}`, nil, http.StatusOK},
+		{"/ClipboardItem/{id}", "PATCH", "/ClipboardItem/" + id, `{"ClipboardItemText": "c"}`, map[string]string{"If-Match": `"1"`}, http.StatusPreconditionFailed},
+		{"/ClipboardItem/{id}/revisions", "GET", "/ClipboardItem/" + id + "/revisions", "", nil, http.StatusOK},
+		{"/ClipboardItem/{id}/revisions/{revision}/restore", "POST", "/ClipboardItem/" + id + "/revisions/1/restore", "", nil, http.StatusOK},
+		{"/ClipboardItem/{id}/revisions/{revision}/restore", "POST", "/ClipboardItem/" + id + "/revisions/9/restore", "", nil, http.StatusNotFound},
+		{"/stats", "GET", "/stats", "", nil, http.StatusOK},
+		{"/stats", "GET", "/stats?top=a", "", nil, http.StatusBadRequest},
+		{"/devices", "GET", "/devices", "", nil, http.StatusOK},
+		{"/devices/{device}/push", "POST", "/devices/desktop/push", `{"ClipboardItemTime": ` + id + `}`, nil, http.StatusCreated},
+		{"/devices/{device}/push", "POST", "/devices/desktop/push", `{"ClipboardItemTime": 1}`, nil, http.StatusNotFound},
+		{"/devices/{device}/push", "POST", "/devices/desktop/push", `{"ClipboardItemTime": ` + id + `, "ttl": "a"}`, nil, http.StatusBadRequest},
+		{"/devices/{device}/pull", "GET", "/devices/desktop/pull", "", nil, http.StatusOK},
+		{"/devices/{device}/pull", "GET", "/devices/desktop/pull", "", nil, http.StatusNoContent},
+		{"/devices/{device}/pull", "GET", "/devices/desktop/pull?wait=a", "", nil, http.StatusBadRequest},
+		{"/devices/{device}/push/{id}/ack", "POST", "/devices/desktop/push/1/ack", "", nil, http.StatusOK},
+		{"/devices/{device}/push/{id}/ack", "POST", "/devices/desktop/push/1/ack", "", nil, http.StatusNotFound},
+		{"/ClipboardItem/bulk", "POST", "/ClipboardItem/bulk", `{"action": "trash", "startTimestamp": 0, "dryRun": true}`, nil, http.StatusOK},
+		{"/ClipboardItem/bulk", "POST", "/ClipboardItem/bulk", `{"action": "a"}`, nil, http.StatusBadRequest},
+		{"/ClipboardItem/{id}", "DELETE", "/ClipboardItem/" + id, "", nil, http.StatusOK},
+		{"/changes", "GET", "/changes", "", nil, http.StatusOK},
+		{"/changes", "GET", "/changes?since=a", "", nil, http.StatusBadRequest},
+		{"/sync/changes", "POST", "/sync/changes", `{"ClipboardItemChange": [{"ClipboardItemTime": 1, "ClipboardItemChangeType": "purged"}]}`, nil, http.StatusOK},
+		{"/sync/changes", "POST", "/sync/changes", `{"ClipboardItemChange": [{"ClipboardItemTime": 1, "ClipboardItemChangeType": "created"}]}`, nil, http.StatusBadRequest},
+		{"/trash", "GET", "/trash", "", nil, http.StatusOK},
+		{"/trash/{id}/restore", "POST", "/trash/" + id + "/restore", "", nil, http.StatusOK},
+		{"/trash/{id}/restore", "POST", "/trash/" + id + "/restore", "", nil, http.StatusNotFound},
+		{"/ClipboardItem/{id}", "DELETE", "/ClipboardItem/" + id, "", nil, http.StatusOK},
+		{"/trash/{id}", "DELETE", "/trash/" + id, "", nil, http.StatusOK},
+		{"/trash", "DELETE", "/trash", "", nil, http.StatusOK},
+		{"/sensitive", "GET", "/sensitive", "", nil, http.StatusOK},
+		{"/sensitive", "GET", "/sensitive?limit=a", "", nil, http.StatusBadRequest},
+		{"/webhooks", "POST", "/webhooks", `{"WebhookURL": "http://127.0.0.1:1/", "WebhookEvents": "ClipboardItem.created"}`, nil, http.StatusCreated},
+		{"/webhooks", "POST", "/webhooks", `{"WebhookURL": "ftp://a"}`, nil, http.StatusBadRequest},
+		{"/webhooks", "GET", "/webhooks", "", nil, http.StatusOK},
+		{"/ClipboardItem", "POST", "/ClipboardItem", dumpJSON(clipboardItemToGinH(preparationClipboardItem())), nil, http.StatusCreated},
+		{"/webhooks/{id}/deliveries", "GET", "/webhooks/1/deliveries", "", nil, http.StatusOK},
+		{"/webhooks/{id}/deliveries", "GET", "/webhooks/9/deliveries", "", nil, http.StatusNotFound},
+		{"/webhooks/{id}", "DELETE", "/webhooks/1", "", nil, http.StatusOK},
+		{"/webhooks/{id}", "DELETE", "/webhooks/1", "", nil, http.StatusNotFound},
+		{"/workspaces", "POST", "/workspaces", `{"WorkspaceName": "work"}`, nil, http.StatusCreated},
+		{"/workspaces", "POST", "/workspaces", `{"WorkspaceName": "work"}`, nil, http.StatusConflict},
+		{"/workspaces", "POST", "/workspaces", `{"WorkspaceName": "` + strings.Repeat("w", 1<<20) + `"}`, nil, http.StatusRequestEntityTooLarge},
+		{"/workspaces", "GET", "/workspaces", "", nil, http.StatusOK},
+		{"/workspaces/{workspace}/ClipboardItem/count", "GET", "/workspaces/work/ClipboardItem/count", "", nil, http.StatusOK},
+		{"/workspaces/{workspace}/ClipboardItem/count", "GET", "/workspaces/home/ClipboardItem/count", "", nil, http.StatusNotFound},
+		{"/workspaces/{workspace}", "PATCH", "/workspaces/work", `{"WorkspaceTrashRetention": 1000}`, nil, http.StatusOK},
+		{"/workspaces/{workspace}", "DELETE", "/workspaces/work", "", nil, http.StatusOK},
+		{"/workspaces/{workspace}", "DELETE", "/workspaces/work", "", nil, http.StatusNotFound},
+		{"/admin/ingest-rules", "POST", "/admin/ingest-rules", `{"IngestRuleType": "source_app_deny", "IngestRuleValue": "KeePassXC"}`, nil, http.StatusCreated},
+		{"/admin/ingest-rules", "POST", "/admin/ingest-rules", `{"IngestRuleType": "a", "IngestRuleValue": "b"}`, nil, http.StatusBadRequest},
+		{"/admin/ingest-rules", "GET", "/admin/ingest-rules", "", nil, http.StatusOK},
+		{"/admin/audit-log", "GET", "/admin/audit-log?status=200", "", nil, http.StatusOK},
+		{"/admin/audit-log", "GET", "/admin/audit-log?status=a", "", nil, http.StatusBadRequest},
+		{"/ClipboardItem", "POST", "/ClipboardItem", dumpJSON(clipboardItemToGinH(preparationClipboardItem())), map[string]string{"X-Clipboard-Archive-Source-App": "KeePassXC"}, http.StatusUnprocessableEntity},
+		{"/admin/ingest-rules/{id}", "PATCH", "/admin/ingest-rules/1", `{"IngestRuleValue": "1Password"}`, nil, http.StatusOK},
+		{"/admin/ingest-rules/{id}", "PATCH", "/admin/ingest-rules/9", `{}`, nil, http.StatusNotFound},
+		{"/admin/ingest-rules/{id}", "DELETE", "/admin/ingest-rules/1", "", nil, http.StatusOK},
+		{"/admin/ingest-rules/{id}", "DELETE", "/admin/ingest-rules/1", "", nil, http.StatusNotFound},
+		{"/openapi.json", "GET", "/openapi.json", "", nil, http.StatusOK},
+	}
+
+	for _, step := range steps {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(step.method, "/api/v1"+step.url, strings.NewReader(step.body))
+		for key, value := range step.header {
+			req.Header.Set(key, value)
+		}
+		r.ServeHTTP(w, req)
+
+		if !assert.Equal(t, step.status, w.Code, "%s %s: %s", step.method, step.url, w.Body.String()) {
+			continue
+		}
+		validateOpenAPIResponse(t, spec, step.template, step.method, w)
+	}
+
+	database.Close()
+}
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// patchClipboardItem applies a JSON Merge Patch (RFC 7396) to the editable
+// fields of a ClipboardItem.
+func patchClipboardItem(c *gin.Context) {
+	editClipboardItem(c, true)
+}
@@ -0,0 +1,168 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestPatchClipboardItem(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(`{"clipboardItemText": "';DROP TABLE clipboard_items;"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	item.ClipboardItemText = `';DROP TABLE clipboard_items;`
+	item.ClipboardItemRevision = 2
+	expected := gin.H{
+		"status":        http.StatusOK,
+		"message":       "ClipboardItem updated successfully",
+		"ClipboardItem": item,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, item, item2)
+
+	database.Close()
+}
+
+func TestPatchClipboardItemData(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	data := toBase64(randString(5))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(gin.H{
+		"ClipboardItemText": nil,
+		"ClipboardItemData": data,
+	})))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	item.ClipboardItemText = ""
+	item.ClipboardItemData = data
+	item.ClipboardItemHash = toSha256(data)
+	item.ClipboardItemSize = int64(len(data))
+	item.ClipboardItemRevision = 2
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, item, item2)
+
+	database.Close()
+}
+
+func TestPatchClipboardItemFieldsError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(`{"ClipboardItemHash": "a", "ClipboardItemData": "!"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_clipboard_item_fields",
+		"message": "Invalid ClipboardItem fields",
+		"details": gin.H{
+			"fields": gin.H{
+				"ClipboardItemHash": "field is not editable",
+				"ClipboardItemData": "must be base64",
+			},
+		},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, item, item2)
+
+	database.Close()
+}
+
+func TestPatchClipboardItemUniqueError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 1
+	database.Orm.Create(&item2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/ClipboardItem/%d", item2.ClipboardItemTime), strings.NewReader(dumpJSON(gin.H{
+		"ClipboardItemData": item.ClipboardItemData,
+	})))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusConflict,
+		"code":    "clipboard_item_exists",
+		"message": "ClipboardItem already exists",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestPatchClipboardItemNotFoundError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/api/v1/ClipboardItem/1", strings.NewReader(`{"ClipboardItemText": "test"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_not_found",
+		"message": "ClipboardItem not found",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+type ingestRulePatch struct {
+	IngestRuleType  *string `json:"IngestRuleType"`
+	IngestRuleValue *string `json:"IngestRuleValue"`
+}
+
+// patchIngestRule changes the type or the value of an IngestRule.
+func patchIngestRule(c *gin.Context) {
+	var patch ingestRulePatch
+	var rule database.IngestRule
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	err = database.Orm.First(&rule, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeIngestRuleNotFound, "IngestRule not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating IngestRule", err)
+		return
+	}
+
+	err = c.ShouldBindJSON(&patch)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	if patch.IngestRuleType != nil {
+		rule.IngestRuleType = *patch.IngestRuleType
+	}
+	if patch.IngestRuleValue != nil {
+		rule.IngestRuleValue = *patch.IngestRuleValue
+	}
+	err = validateIngestRule(rule)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidIngestRule, "Invalid IngestRule", err)
+		return
+	}
+
+	err = database.Orm.
+		Model(&rule).
+		Select("ingest_rule_type", "ingest_rule_value").
+		Updates(&rule).Error
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating IngestRule", err)
+		return
+	}
+
+	reloadIngestRules()
+	c.JSON(http.StatusOK, gin.H{
+		"status":     http.StatusOK,
+		"message":    "IngestRule updated successfully",
+		"IngestRule": rule,
+	})
+}
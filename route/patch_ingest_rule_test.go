@@ -0,0 +1,54 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestPatchIngestRule(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.IngestRule{IngestRuleType: "max_size", IngestRuleValue: "1024"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/api/v1/admin/ingest-rules/1", strings.NewReader(`{"IngestRuleValue": "2048"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	rule := loadJSON(w.Body.String())["IngestRule"].(map[string]interface{})
+	assert.Equal(t, "max_size", rule["IngestRuleType"])
+	assert.Equal(t, "2048", rule["IngestRuleValue"])
+
+	// The type has to fit the value it is given with.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/api/v1/admin/ingest-rules/1", strings.NewReader(`{"IngestRuleType": "mime_deny"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/api/v1/admin/ingest-rules/1", strings.NewReader(`{"IngestRuleType": "text_deny", "IngestRuleValue": "("}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_ingest_rule", loadJSON(w.Body.String())["code"])
+
+	var stored database.IngestRule
+	database.Orm.First(&stored)
+	assert.Equal(t, "mime_deny", stored.IngestRuleType)
+	assert.Equal(t, "2048", stored.IngestRuleValue)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/api/v1/admin/ingest-rules/9", strings.NewReader(`{}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "ingest_rule_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
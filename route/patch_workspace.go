@@ -0,0 +1,64 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+type workspacePatch struct {
+	WorkspaceName           *string `json:"WorkspaceName"`
+	WorkspaceTrashRetention *int64  `json:"WorkspaceTrashRetention"`
+}
+
+// patchWorkspace renames a Workspace or changes its trash retention.
+func patchWorkspace(c *gin.Context) {
+	var patch workspacePatch
+
+	workspace, err := database.FindWorkspace(ownerOf(c), c.Params.ByName("workspace"))
+	if err != nil {
+		if errors.Is(err, database.ErrWorkspaceNotFound) {
+			abortWithError(c, http.StatusNotFound, codeWorkspaceNotFound, "Workspace not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating Workspace", err)
+		return
+	}
+
+	err = c.ShouldBindJSON(&patch)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	if patch.WorkspaceName != nil && *patch.WorkspaceName != workspace.WorkspaceName {
+		if !checkWorkspaceName(c, *patch.WorkspaceName) {
+			return
+		}
+		workspace.WorkspaceName = *patch.WorkspaceName
+	}
+	if patch.WorkspaceTrashRetention != nil {
+		workspace.WorkspaceTrashRetention = *patch.WorkspaceTrashRetention
+	}
+
+	err = database.Orm.
+		Model(&workspace).
+		Select("workspace_name", "workspace_trash_retention").
+		Updates(&workspace).Error
+	if isUniqueWorkspaceNameError(err) {
+		abortWithError(c, http.StatusConflict, codeWorkspaceExists, "Workspace already exists", nil)
+		return
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating Workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    http.StatusOK,
+		"message":   "Workspace updated successfully",
+		"Workspace": workspace,
+	})
+}
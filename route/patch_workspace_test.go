@@ -0,0 +1,46 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestPatchWorkspace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.Workspace{WorkspaceName: "work"})
+	database.Orm.Create(&database.Workspace{WorkspaceName: "home"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/api/v1/workspaces/work", strings.NewReader(`{"WorkspaceName": "office", "WorkspaceTrashRetention": -1}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	workspace, err := database.FindWorkspace(0, "office")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), workspace.WorkspaceTrashRetention)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/api/v1/workspaces/office", strings.NewReader(`{"WorkspaceName": "home"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "workspace_exists", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/api/v1/workspaces/work", strings.NewReader(`{}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "workspace_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
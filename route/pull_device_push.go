@@ -0,0 +1,116 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+// leaseDevicePush takes the oldest pending push of a device of owner and
+// hides it for devicePushLease, push is nil when there is none.
+func leaseDevicePush(owner int64, device string) (*database.DevicePush, *ClipboardItem, error) {
+	for {
+		var push database.DevicePush
+		var item ClipboardItem
+
+		now := utils.GetUnixMillisTimestamp()
+		err := database.Orm.
+			Joins("JOIN clipboard_items ON clipboard_items.clipboard_item_owner = device_pushes.device_push_owner AND clipboard_items.clipboard_item_workspace = device_pushes.device_push_workspace AND clipboard_items.clipboard_item_time = device_pushes.clipboard_item_time").
+			Scopes(liveClipboardItems).
+			Where("device_pushes.device_push_owner = ? AND device_pushes.device_name = ?", owner, device).
+			Where("device_pushes.device_push_expires_time > ? AND device_pushes.device_push_lease_time <= ?", now, now).
+			Order("device_pushes.`index`").
+			First(&push).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Another pull may have leased it in between.
+		tx := database.Orm.
+			Model(&push).
+			Where("device_push_lease_time <= ?", now).
+			Update("device_push_lease_time", now+devicePushLease.Milliseconds())
+		if tx.Error != nil {
+			return nil, nil, tx.Error
+		}
+		if tx.RowsAffected == 0 {
+			continue
+		}
+
+		err = database.Orm.
+			Scopes(liveClipboardItems).
+			Select(selectClipboardItemFields(nil)).
+			Where("clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_time = ?", push.DevicePushOwner, push.DevicePushWorkspace, push.ClipboardItemTime).
+			First(&item).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Trashed or expired since, the lease keeps it from coming back.
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return &push, &item, nil
+	}
+}
+
+// pullDevicePush hands the oldest pending push of a device out, waiting up
+// to wait for one. ClipboardItemData is the CopyQ pack, so the device can put
+// it on its clipboard as is. Pushes come back after devicePushLease unless
+// they are acknowledged.
+func pullDevicePush(c *gin.Context) {
+	var wait time.Duration
+	var err error
+
+	device := c.Params.ByName("device")
+
+	_wait := c.Query("wait")
+	if _wait != "" {
+		wait, err = time.ParseDuration(_wait)
+		if err == nil && (wait < 0 || wait > devicePullMaxWait) {
+			err = errors.New("must be between 0 and " + devicePullMaxWait.String())
+		}
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidWait, "Invalid wait", err)
+			return
+		}
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		pushed := devicePushes.wait(ownerOf(c), device)
+		push, item, err := leaseDevicePush(ownerOf(c), device)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error pulling DevicePush", err)
+			return
+		}
+		if push != nil {
+			auditClipboardItems(c, push.ClipboardItemTime)
+			c.JSON(http.StatusOK, gin.H{
+				"status":        http.StatusOK,
+				"message":       "DevicePush pulled successfully",
+				"DevicePush":    push,
+				"ClipboardItem": item,
+			})
+			return
+		}
+
+		select {
+		case <-pushed:
+		case <-deadline.C:
+			c.Status(http.StatusNoContent)
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestPullDevicePush(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	database.Orm.Create(&item)
+
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1, DevicePushExpiresTime: 1})
+	database.Orm.Create(&database.DevicePush{DeviceName: "laptop", ClipboardItemTime: 1, DevicePushExpiresTime: 1 << 62})
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1, DevicePushExpiresTime: 1 << 62})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/devices/desktop/pull", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, float64(3), got["DevicePush"].(map[string]interface{})["Index"])
+	assert.Equal(t, item.ClipboardItemData, got["ClipboardItem"].(map[string]interface{})["ClipboardItemData"])
+
+	// Leased until acknowledged or the lease runs out.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/devices/desktop/pull", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	database.Orm.Model(&database.DevicePush{}).Where("`index` = 3").Update("device_push_lease_time", 1)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/devices/desktop/pull", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	database.Close()
+}
+
+func TestPullDevicePushExpired(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	item.ClipboardItemExpiresTime = 1
+	database.Orm.Create(&item)
+
+	database.Orm.Create(&database.DevicePush{DeviceName: "desktop", ClipboardItemTime: 1, DevicePushExpiresTime: 1 << 62})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/devices/desktop/pull", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	database.Close()
+}
+
+func TestPullDevicePushWait(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemTime = 1
+	database.Orm.Create(&item)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/devices/desktop/push", strings.NewReader(`{"ClipboardItemTime": 1}`))
+		r.ServeHTTP(w, req)
+	}()
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/devices/desktop/pull?wait=10s", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	database.Close()
+}
+
+func TestPullDevicePushBadRequest(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	for _, wait := range []string{"a", "-1s", "2m"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/devices/desktop/pull?wait="+wait, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, codeInvalidWait, loadJSON(w.Body.String())["code"])
+	}
+
+	database.Close()
+}
@@ -0,0 +1,23 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+// purgeTrash permanently deletes every ClipboardItem in trash.
+func purgeTrash(c *gin.Context) {
+	tx := database.Orm.Scopes(ownedClipboardItems(c), trashedClipboardItems).Delete(&ClipboardItem{})
+	if tx.Error != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error purging trash", tx.Error)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  http.StatusOK,
+		"count":   tx.RowsAffected,
+		"message": "Trash purged successfully",
+	})
+}
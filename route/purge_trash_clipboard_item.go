@@ -0,0 +1,52 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// purgeTrashClipboardItem permanently deletes one ClipboardItem in trash.
+func purgeTrashClipboardItem(c *gin.Context) {
+	var item ClipboardItem
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	err = database.Orm.Scopes(ownedClipboardItems(c), trashedClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeTrashItemNotFound, "ClipboardItem not found in trash", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error purging ClipboardItem", err)
+		return
+	}
+
+	// Restored or edited in between, it is no longer the one asked for
+	tx := database.Orm.
+		Where("clipboard_item_deleted_time != 0 AND clipboard_item_revision = ?", item.ClipboardItemRevision).
+		Delete(&item, item.Index)
+	if tx.Error != nil {
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error purging ClipboardItem", tx.Error)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":            http.StatusOK,
+		"message":           "ClipboardItem purged successfully",
+		"ClipboardItemTime": id,
+	})
+}
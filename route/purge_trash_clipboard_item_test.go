@@ -0,0 +1,105 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+func TestPurgeTrashClipboardItem(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/v1/trash/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	expected := gin.H{
+		"status":            http.StatusOK,
+		"message":           "ClipboardItem purged successfully",
+		"ClipboardItemTime": item.ClipboardItemTime,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	err := database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
+	assert.Error(t, err)
+
+	database.Close()
+}
+
+func TestPurgeTrashClipboardItemModified(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	item.ClipboardItemDeletedTime = 1
+	database.Orm.Create(&item)
+
+	// Restored by someone else between reading and purging it.
+	err := database.Orm.Callback().Delete().Before("gorm:delete").Register("test:restore", func(tx *gorm.DB) {
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE clipboard_items SET clipboard_item_deleted_time = 0, clipboard_item_revision = clipboard_item_revision + 1")
+	})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/trash/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, "clipboard_item_modified", loadJSON(w.Body.String())["code"])
+
+	var count int64
+	database.Orm.Model(&ClipboardItem{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	database.Close()
+}
+
+func TestPurgeTrashClipboardItemNotFoundError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/trash/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusNotFound,
+		"code":    "trash_item_not_found",
+		"message": "ClipboardItem not found in trash",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	err := database.Orm.Where("clipboard_item_time = ?", item.ClipboardItemTime).First(&item).Error
+	assert.NoError(t, err)
+
+	database.Close()
+}
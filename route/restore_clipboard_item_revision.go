@@ -0,0 +1,97 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+// restoreClipboardItemRevision makes an earlier revision current again, the
+// replaced content becomes a revision itself.
+func restoreClipboardItemRevision(c *gin.Context) {
+	var item ClipboardItem
+	var revision database.ClipboardItemRevision
+
+	if !checkEditable(c) {
+		return
+	}
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	_revision := c.Params.ByName("revision")
+	revisionNumber, err := strconv.ParseInt(_revision, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidRevision, "Invalid revision", err)
+		return
+	}
+
+	err = database.Orm.Scopes(ownedClipboardItems(c), liveClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error restoring ClipboardItem", err)
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" && !matchETag(ifMatch, clipboardItemETag(item), false) {
+		abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+		return
+	}
+
+	err = database.Orm.
+		Where("clipboard_item_index = ? AND clipboard_item_revision = ?", item.Index, revisionNumber).
+		First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeRevisionNotFound, "ClipboardItem revision not found", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error restoring ClipboardItem", err)
+		return
+	}
+
+	item.ClipboardItemText = revision.ClipboardItemText
+	item.ClipboardItemData = revision.ClipboardItemData
+	item.ClipboardItemHash = revision.ClipboardItemHash
+	if !checkSensitiveContent(c, &item) {
+		return
+	}
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		err := saveClipboardItemRevision(tx, &item)
+		if err != nil {
+			return err
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemUpdated, ownerOf(c), workspaceOf(c), item.ClipboardItemTime, &item)
+	})
+	if err != nil {
+		if isUniqueHashError(err) {
+			abortWithError(c, http.StatusConflict, codeClipboardItemExists, "ClipboardItem already exists", nil)
+			return
+		}
+		if errors.Is(err, errClipboardItemModified) {
+			abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error restoring ClipboardItem", err)
+		return
+	}
+
+	c.Header("ETag", clipboardItemETag(item))
+	c.JSON(http.StatusOK, gin.H{
+		"status":        http.StatusOK,
+		"message":       "ClipboardItem restored successfully",
+		"ClipboardItem": item,
+	})
+}
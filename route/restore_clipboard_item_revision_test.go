@@ -0,0 +1,110 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestRestoreClipboardItemRevision(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(gin.H{
+		"ClipboardItemText": "test",
+		"ClipboardItemData": toBase64("test"),
+	})))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/ClipboardItem/%d/revisions/1/restore", item.ClipboardItemTime), nil)
+	req.Header.Set("If-Match", `"2"`)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	item.ClipboardItemRevision = 3
+	expected := gin.H{
+		"status":        http.StatusOK,
+		"message":       "ClipboardItem restored successfully",
+		"ClipboardItem": item,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, item, item2)
+
+	var count int64
+	database.Orm.Model(&database.ClipboardItemRevision{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	database.Close()
+}
+
+func TestRestoreClipboardItemRevisionParamsError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem/1/revisions/a/restore", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_revision",
+		"message": "Invalid revision",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestRestoreClipboardItemRevisionNotFoundError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/ClipboardItem/%d/revisions/1/restore", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_revision_not_found",
+		"message": "ClipboardItem revision not found",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
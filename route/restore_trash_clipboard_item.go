@@ -0,0 +1,65 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+func restoreTrashClipboardItem(c *gin.Context) {
+	var item ClipboardItem
+
+	_id := c.Params.ByName("id")
+	id, err := strconv.ParseInt(_id, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
+		return
+	}
+
+	err = database.Orm.Scopes(ownedClipboardItems(c), trashedClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, http.StatusNotFound, codeTrashItemNotFound, "ClipboardItem not found in trash", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error restoring ClipboardItem", err)
+		return
+	}
+
+	revision := item.ClipboardItemRevision
+	item.ClipboardItemDeletedTime = 0
+	item.ClipboardItemRevision++
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&item).
+			Where("clipboard_item_revision = ?", revision).
+			Select("clipboard_item_deleted_time", "clipboard_item_revision").
+			Updates(&item)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClipboardItemModified
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemRestored, ownerOf(c), workspaceOf(c), item.ClipboardItemTime, &item)
+	})
+	if err != nil {
+		if errors.Is(err, errClipboardItemModified) {
+			abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error restoring ClipboardItem", err)
+		return
+	}
+
+	c.Header("ETag", clipboardItemETag(item))
+	c.JSON(http.StatusOK, gin.H{
+		"status":        http.StatusOK,
+		"message":       "ClipboardItem restored successfully",
+		"ClipboardItem": item,
+	})
+}
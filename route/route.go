@@ -6,15 +6,54 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
 )
 
 var err error
 
 type ClipboardItem database.ClipboardItem
 
+// BeforeCreate starts a new ClipboardItem at its first revision, and
+// reserves its index when it is to be encrypted.
+func (item *ClipboardItem) BeforeCreate(tx *gorm.DB) error {
+	if item.ClipboardItemRevision == 0 {
+		item.ClipboardItemRevision = 1
+	}
+	return database.ReserveClipboardItemIndex(tx, &item.Index)
+}
+
+// AfterCreate stores the blind tokens a ClipboardItem came with.
+func (item *ClipboardItem) AfterCreate(tx *gorm.DB) error {
+	if len(item.ClipboardItemBlindTokens) == 0 {
+		return nil
+	}
+	return database.SetBlindTokens(tx, item.Index, item.ClipboardItemBlindTokens)
+}
+
+// AfterSave fills the computed ClipboardItemSize, it is never stored.
+func (item *ClipboardItem) AfterSave(tx *gorm.DB) error {
+	item.ClipboardItemSize = int64(len(item.ClipboardItemData))
+	return nil
+}
+
+// AfterFind fills ClipboardItemSize when ClipboardItemData was loaded,
+// otherwise the size selected by SQL is kept.
+func (item *ClipboardItem) AfterFind(tx *gorm.DB) error {
+	if item.ClipboardItemData != "" {
+		item.ClipboardItemSize = int64(len(item.ClipboardItemData))
+	}
+	return nil
+}
+
 func SetupRouter() *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(takeAccessToken(), gin.Logger(), gin.Recovery())
 	r.SetTrustedProxies([]string{"192.168.0.0/24", "172.16.0.0/12", "10.0.0.0/8"}) // Private network
+	r.Use(requestID(), measureRequests(), audit(), errorHandler(), limitBody())
+	r.NoRoute(routeNotFound)
+	if ServeMetrics && MetricsToken != "" {
+		r.GET("/metrics", limitClientIP(), getMetrics)
+	}
 
 	api := r.Group("/api/v1")
 	api.GET("/ping", func(c *gin.Context) {
@@ -30,12 +69,54 @@ func SetupRouter() *gin.Engine {
 			"message": fmt.Sprintf("version %s", database.Version),
 		})
 	})
+	api.GET("/openapi.json", getOpenAPISpec)
+	api.Use(limitClientIP(), authenticate(), rateLimit())
+	api.GET("/workspaces", getWorkspaces)
+	api.POST("/workspaces", insertWorkspace)
+	api.PATCH("/workspaces/:workspace", patchWorkspace)
+	api.DELETE("/workspaces/:workspace", deleteWorkspace)
+	admin := api.Group("/admin", requireAdmin())
+	admin.GET("/ingest-rules", getIngestRules)
+	admin.POST("/ingest-rules", insertIngestRule)
+	admin.PATCH("/ingest-rules/:id", patchIngestRule)
+	admin.DELETE("/ingest-rules/:id", deleteIngestRule)
+	admin.GET("/audit-log", getAuditLog)
+	// Every other route is served for the default Workspace, or the one in
+	// the X-Clipboard-Archive-Workspace header, and below the prefix of a
+	// named one.
+	setupArchiveRoutes(api.Group("", selectWorkspace()))
+	setupArchiveRoutes(api.Group("/workspaces/:workspace", selectWorkspace()))
+
+	return r
+}
+
+func setupArchiveRoutes(api *gin.RouterGroup) {
 	api.POST("/ClipboardItem", insertClipboardItem)
 	api.DELETE("/ClipboardItem/:id", deleteClipboardItem)
 	api.GET("/ClipboardItem", getClipboardItem)
 	api.GET("/ClipboardItem/:id", takeClipboardItem)
 	api.PUT("/ClipboardItem/:id", updateClipboardItem)
+	api.PATCH("/ClipboardItem/:id", patchClipboardItem)
+	api.GET("/ClipboardItem/:id/revisions", getClipboardItemRevisions)
+	api.POST("/ClipboardItem/:id/revisions/:revision/restore", restoreClipboardItemRevision)
 	api.GET("/ClipboardItem/count", getClipboardItemCount)
-
-	return r
+	api.POST("/ClipboardItem/bulk", bulkClipboardItem)
+	api.GET("/stats", getStats)
+	api.GET("/devices", getDevices)
+	api.POST("/devices/:device/push", insertDevicePush)
+	api.GET("/devices/:device/pull", pullDevicePush)
+	api.POST("/devices/:device/push/:id/ack", ackDevicePush)
+	api.GET("/changes", getChanges)
+	api.POST("/sync/changes", applyChanges)
+	api.GET("/events", getEvents)
+	api.GET("/events/ws", getEventsWebSocket)
+	api.GET("/webhooks", getWebhooks)
+	api.POST("/webhooks", insertWebhook)
+	api.DELETE("/webhooks/:id", deleteWebhook)
+	api.GET("/webhooks/:id/deliveries", getWebhookDeliveries)
+	api.GET("/trash", getTrashClipboardItem)
+	api.DELETE("/trash", purgeTrash)
+	api.POST("/trash/:id/restore", restoreTrashClipboardItem)
+	api.DELETE("/trash/:id", purgeTrashClipboardItem)
+	api.GET("/sensitive", getSensitiveClipboardItem)
 }
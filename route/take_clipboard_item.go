@@ -16,34 +16,46 @@ func takeClipboardItem(c *gin.Context) {
 	_id := c.Params.ByName("id")
 	id, err := strconv.ParseInt(_id, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  http.StatusBadRequest,
-			"message": "Invalid ID",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
 		return
 	}
 
-	err = database.Orm.Where("clipboard_item_time = ?", id).First(&item).Error
+	fields, err := parseClipboardItemFields(c.Query("fields"))
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidFields, "Invalid fields", err)
+		return
+	}
+
+	// The ETag is the revision, so it is read even when fields leaves it out.
+	columns := selectClipboardItemFields(fields)
+	if fields != nil {
+		columns = append(columns, clipboardItemColumns["ClipboardItemRevision"])
+	}
+
+	err = database.Orm.
+		Scopes(ownedClipboardItems(c), liveClipboardItems).
+		Select(columns).
+		Where("clipboard_item_time = ?", id).
+		First(&item).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  http.StatusNotFound,
-				"message": "ClipboardItem not found",
-			})
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error taking ClipboardItem",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error taking ClipboardItem", err)
+		return
+	}
+
+	etag := clipboardItemETag(item)
+	c.Header("ETag", etag)
+	if matchETag(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":        http.StatusOK,
 		"message":       "ClipboardItem taken successfully",
-		"ClipboardItem": item,
+		"ClipboardItem": projectClipboardItem(item, fields),
 	})
 }
@@ -37,6 +37,88 @@ func TestTakeClipboardItems(t *testing.T) {
 	database.Close()
 }
 
+func TestTakeClipboardItemsIfNoneMatch(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), nil)
+	req.Header.Set("If-None-Match", etag)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	database.Close()
+}
+
+func TestTakeClipboardItemsFieldsQuery(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/ClipboardItem/%d?fields=ClipboardItemText,ClipboardItemSize", item.ClipboardItemTime), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	expected := gin.H{
+		"status":  http.StatusOK,
+		"message": "ClipboardItem taken successfully",
+		"ClipboardItem": gin.H{
+			"ClipboardItemText": item.ClipboardItemText,
+			"ClipboardItemSize": len(item.ClipboardItemData),
+		},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestTakeClipboardItemsFieldsQueryError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ClipboardItem/1?fields=a", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_fields",
+		"message": "Invalid fields",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	delete(got, "error")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
 func TestTakeClipboardItemsParamsError(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	database.Open("file::memory:?cache=shared")
@@ -53,10 +135,12 @@ func TestTakeClipboardItemsParamsError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_id",
 		"message": "Invalid ID",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -79,10 +163,12 @@ func TestTakeClipboardItemsNotFoundError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_not_found",
 		"message": "ClipboardItem not found",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	assert.Equal(t, expected, got)
 
 	database.Close()
@@ -103,10 +189,12 @@ func TestTakeClipboardItemsDatabaseError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusInternalServerError,
+		"code":    "internal_error",
 		"message": "Error taking ClipboardItem",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 }
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -10,62 +11,109 @@ import (
 	"gorm.io/gorm"
 )
 
+// updateClipboardItem replaces the editable fields of a ClipboardItem,
+// fields missing from the body are reset.
 func updateClipboardItem(c *gin.Context) {
+	editClipboardItem(c, false)
+}
+
+func editClipboardItem(c *gin.Context, partial bool) {
 	var item ClipboardItem
+	var body map[string]json.RawMessage
+
+	if !checkEditable(c) {
+		return
+	}
 
 	_id := c.Params.ByName("id")
 	id, err := strconv.ParseInt(_id, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  http.StatusBadRequest,
-			"message": "Invalid ID",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusBadRequest, codeInvalidID, "Invalid ID", err)
 		return
 	}
 
-	err = database.Orm.Where("clipboard_item_time = ?", id).First(&item).Error
+	err = database.Orm.Scopes(ownedClipboardItems(c), liveClipboardItems).Where("clipboard_item_time = ?", id).First(&item).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  http.StatusNotFound,
-				"message": "ClipboardItem not found",
-			})
+			abortWithError(c, http.StatusNotFound, codeClipboardItemNotFound, "ClipboardItem not found", nil)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error updating ClipboardItem",
-			"error":   err.Error(),
-		})
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating ClipboardItem", err)
 		return
 	}
 
-	err = c.BindJSON(&item)
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" && !matchETag(ifMatch, clipboardItemETag(item), false) {
+		abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+		return
+	}
+
+	err = c.ShouldBindJSON(&body)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  http.StatusBadRequest,
-			"message": "Invalid JSON",
-			"error":   err.Error(),
+		abortWithError(c, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", err)
+		return
+	}
+
+	values, fieldErrors := decodeClipboardItemFields(body, partial)
+	if fieldErrors != nil {
+		abortWithDetails(c, http.StatusBadRequest, codeInvalidClipboardItemFields, "Invalid ClipboardItem fields", gin.H{
+			"fields": fieldErrors,
 		})
 		return
 	}
 
-	item.ClipboardItemTime = id
-	err = database.Orm.Save(&item).Error
+	applyClipboardItemFields(&item, values)
+	if !checkSensitiveContent(c, &item) {
+		return
+	}
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		err := saveClipboardItemRevision(tx, &item)
+		if err != nil {
+			return err
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemUpdated, ownerOf(c), workspaceOf(c), item.ClipboardItemTime, &item)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Error updating ClipboardItem",
-			"error":   err.Error(),
-		})
+		if isUniqueHashError(err) {
+			abortWithError(c, http.StatusConflict, codeClipboardItemExists, "ClipboardItem already exists", nil)
+			return
+		}
+		if errors.Is(err, errClipboardItemModified) {
+			abortWithError(c, http.StatusPreconditionFailed, codeClipboardItemModified, "ClipboardItem has been modified", nil)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error updating ClipboardItem", err)
 		return
 	}
 
+	c.Header("ETag", clipboardItemETag(item))
 	c.JSON(http.StatusOK, gin.H{
 		"status":        http.StatusOK,
 		"message":       "ClipboardItem updated successfully",
 		"ClipboardItem": item,
 	})
+}
 
+// errClipboardItemModified is returned when a ClipboardItem is written over
+// a revision that has since moved on.
+var errClipboardItemModified = errors.New("ClipboardItem has been modified")
+
+// saveClipboardItemRevision stores the editable fields of item, with what
+// detection made of them, as its next revision, nothing is written if the stored revision has moved on.
+func saveClipboardItemRevision(tx *gorm.DB, item *ClipboardItem) error {
+	revision := item.ClipboardItemRevision
+	item.ClipboardItemRevision++
+	result := tx.
+		Model(item).
+		Where("clipboard_item_revision = ?", revision).
+		Select("clipboard_item_text", "clipboard_item_data", "clipboard_item_hash", "clipboard_item_revision",
+			"clipboard_item_sensitive", "clipboard_item_secret", "clipboard_item_expires_time").
+		Updates(item)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errClipboardItemModified
+	}
+	return nil
 }
@@ -21,12 +21,15 @@ func TestUpdateClipboardItem(t *testing.T) {
 	database.Orm.Create(&item)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(`{"clipboardItemText": "';DROP TABLE clipboard_items;"}`))
+	itemReq := clipboardItemToGinH(item)
+	itemReq["ClipboardItemText"] = `';DROP TABLE clipboard_items;`
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(itemReq)))
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	item.ClipboardItemText = `';DROP TABLE clipboard_items;`
+	item.ClipboardItemRevision = 2
 	expected := gin.H{
 		"status":        http.StatusOK,
 		"message":       "ClipboardItem updated successfully",
@@ -42,6 +45,158 @@ func TestUpdateClipboardItem(t *testing.T) {
 	database.Close()
 }
 
+func TestUpdateClipboardItemReplace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	data := toBase64(randString(5))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(gin.H{
+		"ClipboardItemData": data,
+		"ClipboardItemHash": "a",
+	})))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	item.ClipboardItemText = ""
+	item.ClipboardItemData = data
+	item.ClipboardItemHash = toSha256(data)
+	item.ClipboardItemSize = int64(len(data))
+	item.ClipboardItemRevision = 2
+	expected := gin.H{
+		"status":        http.StatusOK,
+		"message":       "ClipboardItem updated successfully",
+		"ClipboardItem": item,
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	assert.Equal(t, expected, got)
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, item, item2)
+
+	database.Close()
+}
+
+func TestUpdateClipboardItemFieldsError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(`{"ClipboardItemText": 1, "a": ""}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusBadRequest,
+		"code":    "invalid_clipboard_item_fields",
+		"message": "Invalid ClipboardItem fields",
+		"details": gin.H{
+			"fields": gin.H{
+				"ClipboardItemText": "must be a string",
+				"ClipboardItemData": "required",
+				"a":                 "unknown field",
+			},
+		},
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestUpdateClipboardItemUniqueError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+	item2 := preparationClipboardItem()
+	item2.ClipboardItemTime = 1
+	database.Orm.Create(&item2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item2.ClipboardItemTime), strings.NewReader(dumpJSON(gin.H{
+		"ClipboardItemData": item.ClipboardItemData,
+	})))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusConflict,
+		"code":    "clipboard_item_exists",
+		"message": "ClipboardItem already exists",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	database.Close()
+}
+
+func TestUpdateClipboardItemIfMatch(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	req.Header.Set("If-Match", `"1"`)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	req.Header.Set("If-Match", `"1"`)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	expected := gin.H{
+		"status":  http.StatusPreconditionFailed,
+		"code":    "clipboard_item_modified",
+		"message": "ClipboardItem has been modified",
+	}
+	expected = reloadJSON(expected)
+	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
+	assert.Equal(t, expected, got)
+
+	// If-Match compares strongly, a weak ETag never matches
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/ClipboardItem/%d", item.ClipboardItemTime), strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	req.Header.Set("If-Match", `W/"2"`)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	var item2 ClipboardItem
+	database.Orm.First(&item2)
+	assert.Equal(t, int64(2), item2.ClipboardItemRevision)
+
+	database.Close()
+}
+
 func TestUpdateClipboardItemParamsError(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	database.Open("file::memory:?cache=shared")
@@ -55,10 +210,12 @@ func TestUpdateClipboardItemParamsError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_id",
 		"message": "Invalid ID",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -81,10 +238,12 @@ func TestUpdateClipboardItemBindJsonError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusBadRequest,
+		"code":    "invalid_json",
 		"message": "Invalid JSON",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
@@ -106,10 +265,12 @@ func TestUpdateClipboardItemNotFoundError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusNotFound,
+		"code":    "clipboard_item_not_found",
 		"message": "ClipboardItem not found",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	assert.Equal(t, expected, got)
 
 	database.Close()
@@ -130,10 +291,12 @@ func TestUpdateClipboardItemDatabaseError(t *testing.T) {
 
 	expected := gin.H{
 		"status":  http.StatusInternalServerError,
+		"code":    "internal_error",
 		"message": "Error updating ClipboardItem",
 	}
 	expected = reloadJSON(expected)
 	got := loadJSON(w.Body.String())
+	delete(got, "request_id")
 	delete(got, "error")
 	assert.Equal(t, expected, got)
 
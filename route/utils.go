@@ -0,0 +1,45 @@
+package route
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/used255/clipboard_archive/v3/database"
+	"gorm.io/gorm"
+)
+
+const uniqueHashError = "constraint failed: UNIQUE constraint failed: clipboard_items.clipboard_item_owner, clipboard_items.clipboard_item_workspace, clipboard_items.clipboard_item_hash (2067)"
+
+const uniqueTimeError = "constraint failed: UNIQUE constraint failed: clipboard_items.clipboard_item_owner, clipboard_items.clipboard_item_workspace, clipboard_items.clipboard_item_time (2067)"
+
+const uniqueWorkspaceNameError = "constraint failed: UNIQUE constraint failed: workspaces.workspace_owner, workspaces.workspace_name (2067)"
+
+func isUniqueHashError(err error) bool {
+	return err != nil && err.Error() == uniqueHashError
+}
+
+func isUniqueTimeError(err error) bool {
+	return err != nil && err.Error() == uniqueTimeError
+}
+
+func isUniqueWorkspaceNameError(err error) bool {
+	return err != nil && err.Error() == uniqueWorkspaceNameError
+}
+
+// hashClipboardItemData hashes ClipboardItemData the same way the CopyQ script does,
+// keyed as inserted ones are.
+func hashClipboardItemData(data string) string {
+	return database.KeyClipboardItemHash(fmt.Sprintf("%x", sha256.Sum256([]byte(data))))
+}
+
+// liveClipboardItems excludes ClipboardItems in trash, and expired ones
+// waiting to be purged.
+func liveClipboardItems(tx *gorm.DB) *gorm.DB {
+	return tx.Scopes(unexpiredClipboardItems).Where("clipboard_items.clipboard_item_deleted_time = 0")
+}
+
+// trashedClipboardItems selects only ClipboardItems in trash that have
+// not expired.
+func trashedClipboardItems(tx *gorm.DB) *gorm.DB {
+	return tx.Scopes(unexpiredClipboardItems).Where("clipboard_items.clipboard_item_deleted_time != 0")
+}
@@ -0,0 +1,25 @@
+package route
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUniqueHashError(t *testing.T) {
+	assert.True(t, isUniqueHashError(errors.New(uniqueHashError)))
+	assert.False(t, isUniqueHashError(errors.New("a")))
+	assert.False(t, isUniqueHashError(nil))
+}
+
+func TestIsUniqueTimeError(t *testing.T) {
+	assert.True(t, isUniqueTimeError(errors.New(uniqueTimeError)))
+	assert.False(t, isUniqueTimeError(errors.New(uniqueHashError)))
+	assert.False(t, isUniqueTimeError(nil))
+}
+
+func TestHashClipboardItemData(t *testing.T) {
+	data := toBase64("The quick brown fox jumps over the lazy dog")
+	assert.Equal(t, toSha256(data), hashClipboardItemData(data))
+}
@@ -0,0 +1,258 @@
+package route
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookDeliveryPending   = "pending"
+	webhookDeliveryDelivered = "delivered"
+	webhookDeliveryFailed    = "failed"
+)
+
+// webhookMaxAttempts is how often a delivery is tried before it is failed.
+const webhookMaxAttempts = 10
+
+// webhookRetryBase is the delay after the first failed attempt, it doubles
+// with every further attempt up to webhookRetryMax.
+var webhookRetryBase = 10 * time.Second
+
+const webhookRetryMax = time.Hour
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookDeliveriesQueued is signalled when deliveries are queued, so the
+// delivery loop does not have to wait for its next tick.
+var WebhookDeliveriesQueued = make(chan struct{}, 1)
+
+func isClipboardItemEventType(typ string) bool {
+	switch typ {
+	case eventClipboardItemCreated, eventClipboardItemUpdated, eventClipboardItemDeleted, eventClipboardItemRestored:
+		return true
+	}
+	return false
+}
+
+// changeMu lets one change at a time commit and reach subscribers, so
+// event IDs, taken inside the transaction, follow the commit order.
+var changeMu sync.Mutex
+
+type pendingEventsKey struct{}
+
+// changeClipboardItems runs change in a transaction, which publishes the
+// events of what it changed with publishClipboardItemEvent. Once it has
+// committed, live subscribers are told and the webhook delivery loop is
+// woken, a rolled back change is never heard of.
+func changeClipboardItems(change func(tx *gorm.DB) error) error {
+	changeMu.Lock()
+	defer changeMu.Unlock()
+
+	events := []event{}
+	err := database.Orm.Transaction(func(tx *gorm.DB) error {
+		return change(tx.WithContext(context.WithValue(tx.Statement.Context, pendingEventsKey{}, &events)))
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		clipboardItemEvents.broadcast(e)
+	}
+	select {
+	case WebhookDeliveriesQueued <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// publishClipboardItemEvent queues the webhook deliveries of a ClipboardItem
+// change in tx, the transaction of changeClipboardItems making it, so both
+// are stored or neither is. The event is broadcast after the commit.
+func publishClipboardItemEvent(tx *gorm.DB, typ string, owner int64, workspace int64, itemTime int64, item *ClipboardItem) error {
+	events := tx.Statement.Context.Value(pendingEventsKey{}).(*[]event)
+	e := clipboardItemEvents.next(typ, owner, workspace, itemTime, item, len(*events))
+	err := queueWebhookDeliveries(tx, e)
+	if err != nil {
+		return err
+	}
+	*events = append(*events, e)
+	return nil
+}
+
+// matchWebhook reports whether webhook subscribes to e. The search is run
+// against the stored ClipboardItem, so it never matches a purged one.
+func matchWebhook(tx *gorm.DB, webhook database.Webhook, e event) (bool, error) {
+	if webhook.WebhookEvents != "" {
+		subscribed := false
+		for _, typ := range strings.Split(webhook.WebhookEvents, ",") {
+			if typ == e.Type {
+				subscribed = true
+			}
+		}
+		if !subscribed {
+			return false, nil
+		}
+	}
+
+	if webhook.WebhookSearch != "" {
+		var count int64
+		err := tx.
+			Raw("SELECT count(*) FROM clipboard_items_fts WHERE clipboard_items_fts MATCH ? AND rowid IN (SELECT `index` FROM clipboard_items WHERE clipboard_item_owner = ? AND clipboard_item_workspace = ? AND clipboard_item_time = ?)", webhook.WebhookSearch, e.Owner, e.Workspace, e.ClipboardItemTime).
+			Scan(&count).Error
+		if err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	}
+	return true, nil
+}
+
+// queueWebhookDeliveries stores a pending delivery of e for every matching
+// webhook of its owner and Workspace in the outbox.
+func queueWebhookDeliveries(tx *gorm.DB, e event) error {
+	webhooks := []database.Webhook{}
+	err := tx.Where("webhook_owner = ? AND webhook_workspace = ?", e.Owner, e.Workspace).Find(&webhooks).Error
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(eventFilter{}.render(e))
+	if err != nil {
+		return err
+	}
+
+	now := utils.GetUnixMillisTimestamp()
+	deliveries := []database.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		matched, err := matchWebhook(tx, webhook, e)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		deliveries = append(deliveries, database.WebhookDelivery{
+			WebhookIndex:                   webhook.Index,
+			WebhookDeliveryEvent:           e.Type,
+			WebhookDeliveryPayload:         string(payload),
+			WebhookDeliveryState:           webhookDeliveryPending,
+			WebhookDeliveryNextAttemptTime: now,
+			WebhookDeliveryCreatedTime:     now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return tx.Create(&deliveries).Error
+}
+
+// DeliverWebhooks makes one attempt at every due delivery in the outbox.
+func DeliverWebhooks() error {
+	deliveries := []database.WebhookDelivery{}
+	err := database.Orm.
+		Where("webhook_delivery_state = ? AND webhook_delivery_next_attempt_time <= ?", webhookDeliveryPending, utils.GetUnixMillisTimestamp()).
+		Order("`index`").
+		Find(&deliveries).Error
+	if err != nil {
+		return err
+	}
+
+	// A delivery that cannot be made or saved does not hold up the rest,
+	// the first error is returned once all are tried.
+	var failed error
+	for i := range deliveries {
+		var webhook database.Webhook
+		delivery := &deliveries[i]
+
+		err = database.Orm.First(&webhook, delivery.WebhookIndex).Error
+		if err != nil {
+			delivery.WebhookDeliveryAttempts++
+			retryWebhookDelivery(delivery, utils.GetUnixMillisTimestamp(), err)
+		} else {
+			deliverWebhook(webhook, delivery)
+		}
+		err = database.Orm.Save(delivery).Error
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// signWebhookPayload is the X-Clipboard-Archive-Signature of a delivery,
+// receivers recompute it over the timestamp header, a dot and the body.
+func signWebhookPayload(secret string, timestamp int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.%s", timestamp, payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookRetryDelay(attempts int64) time.Duration {
+	delay := webhookRetryBase
+	for i := int64(1); i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+func deliverWebhook(webhook database.Webhook, delivery *database.WebhookDelivery) {
+	now := utils.GetUnixMillisTimestamp()
+	delivery.WebhookDeliveryAttempts++
+	delivery.WebhookDeliveryStatusCode = 0
+
+	req, err := http.NewRequest(http.MethodPost, webhook.WebhookURL, strings.NewReader(delivery.WebhookDeliveryPayload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "clipboard_archive/"+database.Version)
+		req.Header.Set("X-Clipboard-Archive-Event", delivery.WebhookDeliveryEvent)
+		req.Header.Set("X-Clipboard-Archive-Delivery", strconv.FormatInt(delivery.Index, 10))
+		req.Header.Set("X-Clipboard-Archive-Timestamp", strconv.FormatInt(now, 10))
+		req.Header.Set("X-Clipboard-Archive-Signature", signWebhookPayload(webhook.WebhookSecret, now, delivery.WebhookDeliveryPayload))
+
+		var resp *http.Response
+		resp, err = webhookClient.Do(req)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			delivery.WebhookDeliveryStatusCode = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				delivery.WebhookDeliveryState = webhookDeliveryDelivered
+				delivery.WebhookDeliveryError = ""
+				delivery.WebhookDeliveryDeliveredTime = now
+				return
+			}
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+		}
+	}
+
+	retryWebhookDelivery(delivery, now, err)
+}
+
+// retryWebhookDelivery records the failed attempt of a delivery and when to
+// try it again, or fails it after webhookMaxAttempts.
+func retryWebhookDelivery(delivery *database.WebhookDelivery, now int64, err error) {
+	delivery.WebhookDeliveryError = err.Error()
+	if delivery.WebhookDeliveryAttempts >= webhookMaxAttempts {
+		delivery.WebhookDeliveryState = webhookDeliveryFailed
+		return
+	}
+	delivery.WebhookDeliveryNextAttemptTime = now + webhookRetryDelay(delivery.WebhookDeliveryAttempts).Milliseconds()
+}
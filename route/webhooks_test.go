@@ -0,0 +1,178 @@
+package route
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+	"github.com/used255/clipboard_archive/v3/utils"
+	"gorm.io/gorm"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// echo -n '1.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=1122767b193110cfec322b6f199b599edbf608ed087f2d27afb0b97d99523908",
+		signWebhookPayload("secret", 1, "{}"),
+	)
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	assert.Equal(t, webhookRetryBase, webhookRetryDelay(1))
+	assert.Equal(t, 4*webhookRetryBase, webhookRetryDelay(3))
+	assert.Equal(t, webhookRetryMax, webhookRetryDelay(webhookMaxAttempts))
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	database.Open("file::memory:?cache=shared")
+
+	var body string
+	var header http.Header
+	status := http.StatusInternalServerError
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	webhook := database.Webhook{WebhookURL: receiver.URL, WebhookSecret: "secret", WebhookEvents: eventClipboardItemCreated}
+	database.Orm.Create(&webhook)
+	searchWebhook := database.Webhook{WebhookURL: receiver.URL, WebhookSecret: "secret", WebhookSearch: "nothing"}
+	database.Orm.Create(&searchWebhook)
+
+	item := preparationClipboardItem()
+	database.Orm.Create(&item)
+	changeClipboardItems(func(tx *gorm.DB) error {
+		publishClipboardItemEvent(tx, eventClipboardItemCreated, 0, 0, item.ClipboardItemTime, &item)
+		return publishClipboardItemEvent(tx, eventClipboardItemDeleted, 0, 0, item.ClipboardItemTime, nil)
+	})
+
+	var deliveries []database.WebhookDelivery
+	database.Orm.Find(&deliveries)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, webhook.Index, deliveries[0].WebhookIndex)
+
+	err := DeliverWebhooks()
+	assert.NoError(t, err)
+
+	var delivery database.WebhookDelivery
+	database.Orm.First(&delivery)
+	assert.Equal(t, webhookDeliveryPending, delivery.WebhookDeliveryState)
+	assert.Equal(t, int64(1), delivery.WebhookDeliveryAttempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.WebhookDeliveryStatusCode)
+	assert.Greater(t, delivery.WebhookDeliveryNextAttemptTime, delivery.WebhookDeliveryCreatedTime)
+
+	timestamp, _ := strconv.ParseInt(header.Get("X-Clipboard-Archive-Timestamp"), 10, 64)
+	assert.Equal(t, signWebhookPayload("secret", timestamp, body), header.Get("X-Clipboard-Archive-Signature"))
+	assert.Equal(t, eventClipboardItemCreated, header.Get("X-Clipboard-Archive-Event"))
+	assert.Equal(t, item.ClipboardItemText, loadJSON(body)["ClipboardItem"].(map[string]interface{})["ClipboardItemText"])
+
+	status = http.StatusNoContent
+	database.Orm.Model(&delivery).Update("webhook_delivery_next_attempt_time", utils.GetUnixMillisTimestamp())
+	err = DeliverWebhooks()
+	assert.NoError(t, err)
+
+	database.Orm.First(&delivery)
+	assert.Equal(t, webhookDeliveryDelivered, delivery.WebhookDeliveryState)
+	assert.Equal(t, int64(2), delivery.WebhookDeliveryAttempts)
+	assert.Equal(t, "", delivery.WebhookDeliveryError)
+
+	database.Close()
+}
+
+func TestDeliverWebhooksContinues(t *testing.T) {
+	database.Open("file::memory:?cache=shared")
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	webhook := database.Webhook{WebhookURL: receiver.URL, WebhookSecret: "secret"}
+	database.Orm.Create(&webhook)
+	now := utils.GetUnixMillisTimestamp()
+	// The first one's webhook is gone
+	database.Orm.Create(&[]database.WebhookDelivery{
+		{WebhookIndex: webhook.Index + 1, WebhookDeliveryState: webhookDeliveryPending, WebhookDeliveryNextAttemptTime: now},
+		{WebhookIndex: webhook.Index, WebhookDeliveryState: webhookDeliveryPending, WebhookDeliveryNextAttemptTime: now},
+	})
+
+	err := DeliverWebhooks()
+	assert.NoError(t, err)
+
+	var deliveries []database.WebhookDelivery
+	database.Orm.Order("`index`").Find(&deliveries)
+	assert.Equal(t, webhookDeliveryPending, deliveries[0].WebhookDeliveryState)
+	assert.Equal(t, int64(1), deliveries[0].WebhookDeliveryAttempts)
+	assert.NotEmpty(t, deliveries[0].WebhookDeliveryError)
+	assert.Greater(t, deliveries[0].WebhookDeliveryNextAttemptTime, now)
+	assert.Equal(t, webhookDeliveryDelivered, deliveries[1].WebhookDeliveryState)
+
+	database.Close()
+}
+
+func TestQueueWebhookDeliveriesRollback(t *testing.T) {
+	database.Open("file::memory:?cache=shared")
+	database.Orm.Create(&database.Webhook{WebhookURL: "http://127.0.0.1:1/", WebhookSecret: "secret"})
+	item := preparationClipboardItem()
+	_, ch, _ := clipboardItemEvents.subscribe(0, false)
+	defer clipboardItemEvents.unsubscribe(ch)
+
+	failed := errors.New("failed")
+	err := changeClipboardItems(func(tx *gorm.DB) error {
+		err := tx.Create(&item).Error
+		if err != nil {
+			return err
+		}
+		err = publishClipboardItemEvent(tx, eventClipboardItemCreated, 0, 0, item.ClipboardItemTime, &item)
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+
+	var count int64
+	database.Orm.Model(&database.WebhookDelivery{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Orm.Model(&ClipboardItem{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	assert.Empty(t, ch)
+
+	err = changeClipboardItems(func(tx *gorm.DB) error {
+		err := tx.Create(&item).Error
+		if err != nil {
+			return err
+		}
+		return publishClipboardItemEvent(tx, eventClipboardItemCreated, 0, 0, item.ClipboardItemTime, &item)
+	})
+	assert.NoError(t, err)
+	database.Orm.Model(&database.WebhookDelivery{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	e := <-ch
+	assert.Equal(t, eventClipboardItemCreated, e.Type)
+	assert.Equal(t, clipboardItemEvents.lastID, e.ID)
+
+	database.Close()
+}
+
+func TestDeliverWebhookGivesUp(t *testing.T) {
+	webhook := database.Webhook{WebhookURL: "http://127.0.0.1:1/", WebhookSecret: "secret"}
+	delivery := database.WebhookDelivery{
+		WebhookDeliveryState:    webhookDeliveryPending,
+		WebhookDeliveryAttempts: webhookMaxAttempts - 1,
+	}
+
+	deliverWebhook(webhook, &delivery)
+
+	assert.Equal(t, webhookDeliveryFailed, delivery.WebhookDeliveryState)
+	assert.Equal(t, int64(webhookMaxAttempts), delivery.WebhookDeliveryAttempts)
+	assert.NotEmpty(t, delivery.WebhookDeliveryError)
+}
@@ -0,0 +1,108 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+const workspaceKey = "workspace"
+const endToEndKey = "endToEnd"
+
+// workspaceHeader addresses a Workspace for clients that cannot use the
+// /workspaces/:workspace path prefix.
+const workspaceHeader = "X-Clipboard-Archive-Workspace"
+
+var workspaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var blindTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+const maxBlindTokens = 1024
+
+// selectWorkspace resolves the Workspace a request addresses, by path
+// prefix or header. Without either it is the default Workspace 0.
+func selectWorkspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Params.ByName("workspace")
+		if name == "" {
+			name = c.GetHeader(workspaceHeader)
+		}
+		if name == "" {
+			c.Set(workspaceKey, int64(0))
+			c.Next()
+			return
+		}
+
+		workspace, err := database.FindWorkspace(ownerOf(c), name)
+		if err != nil {
+			if errors.Is(err, database.ErrWorkspaceNotFound) {
+				abortWithError(c, http.StatusNotFound, codeWorkspaceNotFound, "Workspace not found", nil)
+				return
+			}
+			abortWithError(c, http.StatusInternalServerError, codeInternalError, "Error finding Workspace", err)
+			return
+		}
+
+		c.Set(workspaceKey, workspace.Index)
+		c.Set(endToEndKey, workspace.WorkspaceEndToEnd)
+		c.Next()
+	}
+}
+
+// workspaceOf is the Index of the Workspace a request addresses, 0 for the
+// default one.
+func workspaceOf(c *gin.Context) int64 {
+	return c.GetInt64(workspaceKey)
+}
+
+// endToEndOf reports whether the Workspace a request addresses is end-to-end
+// encrypted, its ClipboardItems are ciphertext searched by blind token.
+func endToEndOf(c *gin.Context) bool {
+	return c.GetBool(endToEndKey)
+}
+
+// checkEditable reports whether the server may change the content of
+// ClipboardItems in the Workspace a request addresses, reporting errors
+// itself. In end-to-end encrypted Workspaces it could not keep the hash and
+// blind tokens in step, clients upload the ClipboardItem again instead.
+func checkEditable(c *gin.Context) bool {
+	if endToEndOf(c) {
+		abortWithError(c, http.StatusConflict, codeEndToEndEncrypted, "ClipboardItems of an end-to-end encrypted Workspace cannot be changed on the server", nil)
+		return false
+	}
+	return true
+}
+
+// checkBlindTokens reports whether the blind tokens of item may be stored,
+// reporting errors itself.
+func checkBlindTokens(c *gin.Context, item *ClipboardItem) bool {
+	err := validateBlindTokens(item.ClipboardItemBlindTokens, endToEndOf(c))
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, codeInvalidBlindTokens, "Invalid ClipboardItemBlindTokens", err)
+		return false
+	}
+	return true
+}
+
+// validateBlindTokens reports what is wrong with tokens, nil if nothing.
+func validateBlindTokens(tokens []string, endToEnd bool) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	if !endToEnd {
+		return errors.New("only end-to-end encrypted Workspaces take blind tokens")
+	}
+	if len(tokens) > maxBlindTokens {
+		return fmt.Errorf("at most %d blind tokens", maxBlindTokens)
+	}
+	for _, token := range tokens {
+		if !blindTokenPattern.MatchString(token) {
+			return errors.New("blind tokens must be 16 to 128 letters, digits, underscores or dashes")
+		}
+	}
+	return nil
+}
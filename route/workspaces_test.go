@@ -0,0 +1,179 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/used255/clipboard_archive/v3/database"
+)
+
+func TestSelectWorkspace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.Workspace{WorkspaceName: "work"})
+
+	// Workspaces may have ClipboardItems at the same time
+	item := preparationClipboardItem()
+	other := item
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(item))))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// The same content is not a duplicate in another Workspace.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces/work/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(other))))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem?search="+item.ClipboardItemText, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	items := loadJSON(w.Body.String())["ClipboardItem"].([]interface{})
+	assert.Len(t, items, 1)
+	assert.Equal(t, float64(item.ClipboardItemTime), items[0].(map[string]interface{})["ClipboardItemTime"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/ClipboardItem?search="+item.ClipboardItemText, nil)
+	req.Header.Set(workspaceHeader, "work")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	items = loadJSON(w.Body.String())["ClipboardItem"].([]interface{})
+	assert.Len(t, items, 1)
+	assert.Equal(t, float64(other.ClipboardItemTime), items[0].(map[string]interface{})["ClipboardItemTime"])
+
+	// Purging it in one Workspace leaves the other alone
+	id := fmt.Sprintf("%d", item.ClipboardItemTime)
+	for _, url := range []string{"/api/v1/ClipboardItem/" + id, "/api/v1/trash/" + id} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("DELETE", url, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, url)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/workspaces/work/ClipboardItem?search="+item.ClipboardItemText, nil)
+	r.ServeHTTP(w, req)
+	assert.Len(t, loadJSON(w.Body.String())["ClipboardItem"], 1)
+
+	for url, typ := range map[string]string{
+		"/api/v1/changes":                 "purged",
+		"/api/v1/workspaces/work/changes": "created",
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", url, nil)
+		r.ServeHTTP(w, req)
+		changes := loadJSON(w.Body.String())["ClipboardItemChange"].([]interface{})
+		assert.Len(t, changes, 1, url)
+		assert.Equal(t, typ, changes[0].(map[string]interface{})["ClipboardItemChangeType"], url)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/api/v1/ClipboardItem/"+id, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/workspaces/home/ClipboardItem/count", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "workspace_not_found", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}
+
+func TestEndToEndWorkspace(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	database.Open("file::memory:?cache=shared")
+	r := SetupRouter()
+
+	database.Orm.Create(&database.Workspace{WorkspaceName: "secret", WorkspaceEndToEnd: true})
+
+	token := "AAAAAAAAAAAAAAAAAAAAAA"
+	item := preparationClipboardItem()
+	body := clipboardItemToGinH(item)
+	body["ClipboardItemBlindTokens"] = []string{token}
+
+	// Only end-to-end encrypted Workspaces take blind tokens.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/ClipboardItem", strings.NewReader(dumpJSON(body)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_blind_tokens", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	invalid := clipboardItemToGinH(item)
+	invalid["ClipboardItemBlindTokens"] = []string{"short"}
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces/secret/ClipboardItem", strings.NewReader(dumpJSON(invalid)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_blind_tokens", loadJSON(w.Body.String())["code"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces/secret/ClipboardItem", strings.NewReader(dumpJSON(body)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Search matches blind tokens, not the text.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/workspaces/secret/ClipboardItem?search="+token, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	items := loadJSON(w.Body.String())["ClipboardItem"].([]interface{})
+	assert.Len(t, items, 1)
+	assert.Equal(t, float64(item.ClipboardItemTime), items[0].(map[string]interface{})["ClipboardItemTime"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/workspaces/secret/ClipboardItem?search="+item.ClipboardItemText, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, loadJSON(w.Body.String())["ClipboardItem"], 0)
+
+	// Nor the blind tokens of another Workspace at the same time
+	database.Orm.Create(&database.Workspace{WorkspaceName: "other", WorkspaceEndToEnd: true})
+	other := preparationClipboardItem()
+	other.ClipboardItemTime = item.ClipboardItemTime
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces/other/ClipboardItem", strings.NewReader(dumpJSON(clipboardItemToGinH(other))))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/workspaces/other/ClipboardItem?search="+token, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, loadJSON(w.Body.String())["ClipboardItem"], 0)
+
+	// The server cannot edit what it cannot read.
+	id := fmt.Sprintf("%d", item.ClipboardItemTime)
+	for _, edit := range []struct {
+		method string
+		path   string
+	}{
+		{"PUT", "/api/v1/workspaces/secret/ClipboardItem/" + id},
+		{"PATCH", "/api/v1/workspaces/secret/ClipboardItem/" + id},
+		{"POST", "/api/v1/workspaces/secret/ClipboardItem/" + id + "/revisions/1/restore"},
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(edit.method, edit.path, strings.NewReader(`{"ClipboardItemText": "edited"}`))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code, edit.path)
+		assert.Equal(t, "end_to_end_encrypted", loadJSON(w.Body.String())["code"])
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/workspaces/secret/webhooks", strings.NewReader(`{"WebhookURL": "http://127.0.0.1/hook", "WebhookSearch": "hello"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_webhook_search", loadJSON(w.Body.String())["code"])
+
+	database.Close()
+}